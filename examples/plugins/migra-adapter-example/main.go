@@ -0,0 +1,192 @@
+// Command migra-adapter-example is a reference migra adapter plugin.
+//
+// It treats every *.sql file in the service migrations directory
+// (migrations_dir, default "migrations") as a migration and records the
+// applied ones, one per line, in a ledger file in the working directory:
+// EXAMPLE_LEDGER from the environment (set it in a tenant connection to
+// keep one ledger per tenant), or .migra-example-ledger. It does not touch
+// a database; it shows how a plugin reads a request from stdin and answers
+// on stdout.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+const defaultLedgerFile = ".migra-example-ledger"
+
+func main() {
+	var req migra.PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fail("invalid request: %v", err)
+	}
+	if req.Version != migra.PluginProtocolVersion {
+		fail("unsupported protocol version %d", req.Version)
+	}
+	if req.Service == nil {
+		fail("request has no service")
+	}
+
+	var response interface{}
+	var err error
+	switch req.Operation {
+	case migra.OperationDeploy:
+		response, err = deploy(&req)
+	case migra.OperationRollback:
+		response, err = rollback(&req)
+	case migra.OperationStatus:
+		response, err = status(&req)
+	default:
+		fail("unsupported operation '%s'", req.Operation)
+	}
+	if err != nil {
+		fail("%v", err)
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(response); err != nil {
+		fail("failed to write response: %v", err)
+	}
+}
+
+// deploy records every pending migration as applied
+func deploy(req *migra.PluginRequest) (*migra.Result, error) {
+	start := time.Now()
+	st, err := status(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var output strings.Builder
+	if req.Tenant != nil {
+		fmt.Fprintf(&output, "tenant: %s\n", req.Tenant.ID)
+	}
+	for _, name := range st.Pending {
+		fmt.Fprintf(&output, "applying %s\n", name)
+	}
+
+	applied := append(st.Applied, st.Pending...)
+	if err := writeLedger(applied); err != nil {
+		return &migra.Result{Success: false, Output: output.String(), Error: err.Error(), Duration: time.Since(start), Timestamp: time.Now()}, nil
+	}
+
+	fmt.Fprintf(&output, "%d migration(s) applied\n", len(st.Pending))
+	return &migra.Result{Success: true, Output: output.String(), Duration: time.Since(start), Timestamp: time.Now()}, nil
+}
+
+// rollback removes the last steps migrations from the ledger
+func rollback(req *migra.PluginRequest) (*migra.Result, error) {
+	start := time.Now()
+	applied, err := readLedger()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Steps > len(applied) {
+		return &migra.Result{
+			Success:   false,
+			Error:     fmt.Sprintf("cannot roll back %d step(s): only %d applied", req.Steps, len(applied)),
+			Duration:  time.Since(start),
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	var output strings.Builder
+	keep := len(applied) - req.Steps
+	for i := len(applied) - 1; i >= keep; i-- {
+		fmt.Fprintf(&output, "reverting %s\n", applied[i])
+	}
+
+	if err := writeLedger(applied[:keep]); err != nil {
+		return &migra.Result{Success: false, Output: output.String(), Error: err.Error(), Duration: time.Since(start), Timestamp: time.Now()}, nil
+	}
+
+	return &migra.Result{Success: true, Output: output.String(), Duration: time.Since(start), Timestamp: time.Now()}, nil
+}
+
+// status compares the migrations directory with the ledger
+func status(req *migra.PluginRequest) (*migra.StatusResult, error) {
+	dir := req.Service.MigrationsDir
+	if dir == "" {
+		dir = "migrations"
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("migrations directory %s not found", dir)
+	}
+	sort.Strings(files)
+
+	applied, err := readLedger()
+	if err != nil {
+		return nil, err
+	}
+	isApplied := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		isApplied[name] = true
+	}
+
+	st := &migra.StatusResult{Applied: applied, Pending: make([]string, 0)}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".sql")
+		if !isApplied[name] {
+			st.Pending = append(st.Pending, name)
+		}
+	}
+	return st, nil
+}
+
+// readLedger returns the applied migrations in order
+func readLedger() ([]string, error) {
+	f, err := os.Open(ledgerFile())
+	if os.IsNotExist(err) {
+		return make([]string, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	applied := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			applied = append(applied, line)
+		}
+	}
+	return applied, scanner.Err()
+}
+
+// writeLedger replaces the ledger with the applied migrations
+func writeLedger(applied []string) error {
+	data := strings.Join(applied, "\n")
+	if data != "" {
+		data += "\n"
+	}
+	return os.WriteFile(ledgerFile(), []byte(data), 0644)
+}
+
+// ledgerFile returns the ledger path
+func ledgerFile() string {
+	if path := os.Getenv("EXAMPLE_LEDGER"); path != "" {
+		return path
+	}
+	return defaultLedgerFile
+}
+
+// fail reports a protocol error on stderr and exits non-zero
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
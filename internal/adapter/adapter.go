@@ -3,10 +3,13 @@ package adapter
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/migra/migra/internal/output"
+	"github.com/migra/migra/internal/process"
 	"github.com/migra/migra/pkg/migra"
 )
 
@@ -27,17 +30,49 @@ func (a *BaseAdapter) Name() string {
 	return a.name
 }
 
-// executeCommand executes a command in the service directory
+// executeCommand executes a command in the service directory. Its output
+// is streamed to the output sink of the context, if any, and captured in
+// the result. The command's process group is terminated when the context
+// is done.
 func (a *BaseAdapter) executeCommand(ctx context.Context, service *migra.Service, tenant *migra.Tenant, command string, args ...string) (*migra.Result, error) {
 	start := time.Now()
 
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Dir = service.WorkingDir
 
-	// Build environment variables
-	// Start with parent process environment
+	cmd.Env = commandEnv(cmd, service, tenant)
+
+	capture := output.NewCapture(ctx, service, tenant, a.sanitizeOutput)
+	cmd.Stdout = capture.Stdout()
+	cmd.Stderr = capture.Stderr()
+
+	err := process.Run(ctx, cmd)
+	out, logFile := capture.Close()
+	duration := time.Since(start)
+
+	result := &migra.Result{
+		Success:   err == nil && cmd.ProcessState.ExitCode() == 0,
+		Output:    out,
+		Duration:  duration,
+		Timestamp: time.Now(),
+		LogFile:   logFile,
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		if len(out) > 0 {
+			result.Error = fmt.Sprintf("%s: %s", err.Error(), out)
+		}
+	}
+
+	return result, nil
+}
+
+// commandEnv builds the environment of a command: the parent process
+// environment, then the service env, then the tenant connection
+func commandEnv(cmd *exec.Cmd, service *migra.Service, tenant *migra.Tenant) []string {
 	env := cmd.Environ()
-	
+
 	// Add service-specific environment variables
 	for k, v := range service.Env {
 		env = append(env, fmt.Sprintf("%s=%s", k, v))
@@ -50,28 +85,67 @@ func (a *BaseAdapter) executeCommand(ctx context.Context, service *migra.Service
 		}
 	}
 
-	cmd.Env = env
+	return env
+}
 
-	output, err := cmd.CombinedOutput()
-	duration := time.Since(start)
+// repeatCommand runs a command the given number of times, stopping at the
+// first failure. It is used by tools that roll back one migration per call.
+func (a *BaseAdapter) repeatCommand(ctx context.Context, service *migra.Service, tenant *migra.Tenant, times int, command string, args ...string) (*migra.Result, error) {
+	start := time.Now()
+	var out strings.Builder
+	var result *migra.Result
+	logFile := ""
+
+	for i := 0; i < times; i++ {
+		var err error
+		result, err = a.executeCommand(ctx, service, tenant, command, args...)
+		if err != nil {
+			return result, err
+		}
 
-	result := &migra.Result{
-		Success:   err == nil && cmd.ProcessState.ExitCode() == 0,
-		Output:    string(output),
-		Duration:  duration,
-		Timestamp: time.Now(),
+		out.WriteString(result.Output)
+		if result.LogFile != "" {
+			logFile = result.LogFile
+		}
+		if !result.Success {
+			break
+		}
 	}
 
-	if err != nil {
-		result.Error = err.Error()
-		if len(output) > 0 {
-			result.Error = fmt.Sprintf("%s: %s", err.Error(), string(output))
-		}
+	if result == nil {
+		return nil, fmt.Errorf("command must run at least once")
 	}
 
+	result.Output = out.String()
+	result.LogFile = logFile
+	result.Duration = time.Since(start)
 	return result, nil
 }
 
+// lookupEnv returns a variable as the command would see it: the tenant
+// connection wins over the service environment, which wins over the
+// process environment
+func (a *BaseAdapter) lookupEnv(service *migra.Service, tenant *migra.Tenant, key string) string {
+	if tenant != nil {
+		if v, ok := tenant.Connection[key]; ok {
+			return v
+		}
+	}
+	if v, ok := service.Env[key]; ok {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// migrationsDir returns the service migrations directory, falling back to
+// def, relative to the working directory
+func (a *BaseAdapter) migrationsDir(service *migra.Service, def string) string {
+	if service.MigrationsDir != "" {
+		return service.MigrationsDir
+	}
+	return def
+}
+
 // sanitizeOutput removes sensitive information from command output
 func (a *BaseAdapter) sanitizeOutput(output string) string {
 	// Remove common password patterns
@@ -1,11 +1,16 @@
 package adapter
 
 import (
+	"bytes"
 	"context"
+	"runtime"
 	"testing"
 
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/output"
 	"github.com/migra/migra/pkg/migra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAdapterRegistry(t *testing.T) {
@@ -75,3 +80,124 @@ func TestPrismaAdapter(t *testing.T) {
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "does not support")
 }
+
+const djangoPlanOutput = `[X]  contenttypes.0001_initial
+[X]  auth.0001_initial
+[X]  billing.0001_initial
+[X]  auth.0002_alter_permission_name
+[X]  billing.0002_invoice_total
+[X]  billing.0003_invoice_currency
+[ ]  billing.0004_invoice_notes
+`
+
+func TestParseDjangoPlan(t *testing.T) {
+	status := parseDjangoPlan(djangoPlanOutput)
+
+	assert.Equal(t, []string{
+		"contenttypes.0001_initial",
+		"auth.0001_initial",
+		"billing.0001_initial",
+		"auth.0002_alter_permission_name",
+		"billing.0002_invoice_total",
+		"billing.0003_invoice_currency",
+	}, status.Applied)
+	assert.Equal(t, []string{"billing.0004_invoice_notes"}, status.Pending)
+}
+
+func TestDjangoRollbackTargets(t *testing.T) {
+	applied := parseDjangoPlan(djangoPlanOutput).Applied
+
+	t.Run("single step", func(t *testing.T) {
+		targets, err := djangoRollbackTargets(applied, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []djangoTarget{{App: "billing", Migration: "0002_invoice_total"}}, targets)
+	})
+
+	t.Run("steps within one app", func(t *testing.T) {
+		targets, err := djangoRollbackTargets(applied, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []djangoTarget{{App: "billing", Migration: "0001_initial"}}, targets)
+	})
+
+	t.Run("steps across apps", func(t *testing.T) {
+		targets, err := djangoRollbackTargets(applied, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []djangoTarget{
+			{App: "billing", Migration: "0001_initial"},
+			{App: "auth", Migration: "0001_initial"},
+		}, targets)
+	})
+
+	t.Run("back to zero", func(t *testing.T) {
+		targets, err := djangoRollbackTargets(applied, 4)
+		require.NoError(t, err)
+		assert.Equal(t, []djangoTarget{
+			{App: "billing", Migration: "zero"},
+			{App: "auth", Migration: "0001_initial"},
+		}, targets)
+	})
+
+	t.Run("too many steps", func(t *testing.T) {
+		_, err := djangoRollbackTargets(applied, 7)
+		assert.Error(t, err)
+	})
+}
+
+func TestDjangoRollbackToInvalidTarget(t *testing.T) {
+	adapter := NewDjangoAdapter()
+	service := &migra.Service{Name: "test", Type: "django", Path: "."}
+
+	_, err := adapter.RollbackTo(context.Background(), service, nil, "0002_invoice_total")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expected app.migration")
+}
+
+func TestRepeatCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands require a POSIX shell")
+	}
+
+	ctx := context.Background()
+	base := NewBaseAdapter("test")
+	service := &migra.Service{Name: "test", WorkingDir: t.TempDir()}
+
+	t.Run("runs once per step", func(t *testing.T) {
+		result, err := base.repeatCommand(ctx, service, nil, 3, "sh", "-c", "echo step >> steps.log; echo ok")
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, "ok\nok\nok\n", result.Output)
+	})
+
+	t.Run("stops at first failure", func(t *testing.T) {
+		result, err := base.repeatCommand(ctx, service, nil, 3, "sh", "-c", "echo failed; exit 1")
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Equal(t, "failed\n", result.Output)
+	})
+}
+
+func TestExecuteCommandStreamsOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands require a POSIX shell")
+	}
+
+	var buf bytes.Buffer
+	log := logger.NewConsoleLogger(logger.Config{Level: logger.LevelInfo, Output: &buf})
+	ctx := output.WithSink(context.Background(), output.NewSink(log, t.TempDir()))
+
+	base := NewBaseAdapter("test")
+	service := &migra.Service{Name: "billing", WorkingDir: t.TempDir()}
+
+	result, err := base.executeCommand(ctx, service, &migra.Tenant{ID: "acme"}, "sh", "-c", "echo applying; echo warning >&2; exit 3")
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	// stdout and stderr are read concurrently, so only their lines are ordered
+	assert.Contains(t, result.Output, "applying\n")
+	assert.Contains(t, result.Output, "warning\n")
+	assert.Len(t, result.Output, len("applying\nwarning\n"))
+	assert.Contains(t, result.Error, "exit status 3")
+	assert.Empty(t, result.LogFile)
+
+	assert.Contains(t, buf.String(), "[billing/acme] applying")
+	assert.Contains(t, buf.String(), "[billing/acme] warning")
+}
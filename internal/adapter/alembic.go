@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// AlembicAdapter implements the Adapter interface for Alembic (SQLAlchemy)
+type AlembicAdapter struct {
+	*BaseAdapter
+}
+
+// NewAlembicAdapter creates a new Alembic adapter
+func NewAlembicAdapter() *AlembicAdapter {
+	return &AlembicAdapter{
+		BaseAdapter: NewBaseAdapter("alembic"),
+	}
+}
+
+// Deploy runs Alembic migrations
+func (a *AlembicAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// Alembic: alembic upgrade head
+	result, err := a.executeCommand(ctx, service, tenant, "alembic", "upgrade", "head")
+	if err != nil {
+		return result, fmt.Errorf("alembic deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back Alembic migrations
+func (a *AlembicAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// Alembic: alembic downgrade -N
+	result, err := a.executeCommand(ctx, service, tenant, "alembic", "downgrade", fmt.Sprintf("-%d", steps))
+	if err != nil {
+		return result, fmt.Errorf("alembic rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for Alembic
+func (a *AlembicAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	history, err := a.executeCommand(ctx, service, tenant, "alembic", "history")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !history.Success {
+		return &migra.StatusResult{
+			LastError: history.Error,
+		}, fmt.Errorf("alembic history failed: %s", history.Error)
+	}
+
+	current, err := a.executeCommand(ctx, service, tenant, "alembic", "current")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !current.Success {
+		return &migra.StatusResult{
+			LastError: current.Error,
+		}, fmt.Errorf("alembic current failed: %s", current.Error)
+	}
+
+	return parseAlembicStatus(history.Output, current.Output), nil
+}
+
+// alembicRevision is a revision from `alembic history`
+type alembicRevision struct {
+	id   string
+	down []string
+}
+
+// parseAlembicHistory parses `alembic history` output into revisions,
+// oldest first. Lines look like:
+//
+//	1975ea83b712 -> 27c6a30d7c24 (head), add account table
+//	<base> -> 1975ea83b712, create users
+//	ae1027a6acf, 27c6a30d7c24 -> 3512b954651e (mergepoint), merge
+func parseAlembicHistory(output string) []alembicRevision {
+	revisions := make([]alembicRevision, 0)
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		left, right, ok := strings.Cut(line, " -> ")
+		if !ok {
+			continue
+		}
+
+		id := strings.FieldsFunc(right, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(id) == 0 {
+			continue
+		}
+
+		rev := alembicRevision{id: id[0]}
+		for _, down := range strings.Split(left, ",") {
+			down = strings.TrimSpace(down)
+			if down != "" && down != "<base>" {
+				rev.down = append(rev.down, down)
+			}
+		}
+		revisions = append(revisions, rev)
+	}
+
+	// History is printed newest first
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+
+	return revisions
+}
+
+// parseAlembicStatus combines `alembic history` and `alembic current` output.
+// Every ancestor of a current revision is applied; the rest are pending.
+func parseAlembicStatus(historyOutput, currentOutput string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	revisions := parseAlembicHistory(historyOutput)
+	byID := make(map[string]alembicRevision, len(revisions))
+	for _, rev := range revisions {
+		byID[rev.id] = rev
+	}
+
+	// `alembic current` prints one line per head, e.g. "27c6a30d7c24 (head)",
+	// mixed with log lines that are skipped
+	applied := make(map[string]bool)
+	lines := strings.Split(currentOutput, "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, ok := byID[fields[0]]; ok {
+			markAlembicApplied(fields[0], byID, applied)
+		}
+	}
+
+	for _, rev := range revisions {
+		if applied[rev.id] {
+			status.Applied = append(status.Applied, rev.id)
+		} else {
+			status.Pending = append(status.Pending, rev.id)
+		}
+	}
+
+	return status
+}
+
+// markAlembicApplied marks a revision and all of its ancestors as applied
+func markAlembicApplied(id string, byID map[string]alembicRevision, applied map[string]bool) {
+	stack := []string{id}
+	for len(stack) > 0 {
+		id = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if applied[id] {
+			continue
+		}
+		applied[id] = true
+		stack = append(stack, byID[id].down...)
+	}
+}
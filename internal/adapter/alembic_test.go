@@ -0,0 +1,52 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlembicAdapter(t *testing.T) {
+	adapter := NewAlembicAdapter()
+	assert.Equal(t, "alembic", adapter.Name())
+}
+
+func TestParseAlembicStatus(t *testing.T) {
+	history := `27c6a30d7c24 -> 4d1b2e6f0a11 (head), add invoices
+1975ea83b712 -> 27c6a30d7c24, add account table
+<base> -> 1975ea83b712, create users
+`
+
+	t.Run("partially applied", func(t *testing.T) {
+		current := "INFO  [alembic.runtime.migration] Context impl PostgresqlImpl.\n" +
+			"INFO  [alembic.runtime.migration] Will assume transactional DDL.\n" +
+			"27c6a30d7c24\n"
+
+		status := parseAlembicStatus(history, current)
+		assert.Equal(t, []string{"1975ea83b712", "27c6a30d7c24"}, status.Applied)
+		assert.Equal(t, []string{"4d1b2e6f0a11"}, status.Pending)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		status := parseAlembicStatus(history, "4d1b2e6f0a11 (head)\n")
+		assert.Len(t, status.Applied, 3)
+		assert.Empty(t, status.Pending)
+	})
+
+	t.Run("empty database", func(t *testing.T) {
+		status := parseAlembicStatus(history, "INFO  [alembic.runtime.migration] Context impl SQLiteImpl.\n")
+		assert.Empty(t, status.Applied)
+		assert.Equal(t, []string{"1975ea83b712", "27c6a30d7c24", "4d1b2e6f0a11"}, status.Pending)
+	})
+
+	t.Run("merge point", func(t *testing.T) {
+		merged := `a1, b1 -> m1 (head) (mergepoint), merge branches
+base1 -> b1, branch b
+base1 -> a1, branch a
+<base> -> base1 (branchpoint), initial
+`
+		status := parseAlembicStatus(merged, "a1\n")
+		assert.Equal(t, []string{"base1", "a1"}, status.Applied)
+		assert.Equal(t, []string{"b1", "m1"}, status.Pending)
+	})
+}
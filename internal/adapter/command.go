@@ -0,0 +1,300 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// CommandAdapter implements the Adapter interface for arbitrary migration
+// tools whose commands are declared in the service configuration
+type CommandAdapter struct {
+	*BaseAdapter
+}
+
+// NewCommandAdapter creates a new generic command adapter
+func NewCommandAdapter() *CommandAdapter {
+	return &CommandAdapter{
+		BaseAdapter: NewBaseAdapter("command"),
+	}
+}
+
+// commandTemplateData is the data available to command argument templates
+type commandTemplateData struct {
+	Service migra.Service
+	Tenant  migra.Tenant
+	Steps   int
+}
+
+// Deploy runs the declared deploy command
+func (a *CommandAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	spec, err := commandSpec(service)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.run(ctx, service, tenant, spec.Deploy, 0)
+	if err != nil {
+		return result, fmt.Errorf("command deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback runs the declared rollback command
+func (a *CommandAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	spec, err := commandSpec(service)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.Rollback) == 0 {
+		return nil, fmt.Errorf("service %s does not declare a rollback command", service.Name)
+	}
+
+	result, err := a.run(ctx, service, tenant, spec.Rollback, steps)
+	if err != nil {
+		return result, fmt.Errorf("command rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status runs the declared status command and parses its output
+func (a *CommandAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	spec, err := commandSpec(service)
+	if err != nil {
+		return &migra.StatusResult{LastError: err.Error()}, err
+	}
+	if len(spec.Status) == 0 {
+		err := fmt.Errorf("service %s does not declare a status command", service.Name)
+		return &migra.StatusResult{LastError: err.Error()}, err
+	}
+
+	result, err := a.run(ctx, service, tenant, spec.Status, 0)
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("command status failed: %s", result.Error)
+	}
+
+	status, err := ParseStatusOutput(spec.StatusParser, result.Output)
+	if err != nil {
+		return &migra.StatusResult{LastError: err.Error()}, err
+	}
+	return status, nil
+}
+
+// run renders the command templates and executes the command
+func (a *CommandAdapter) run(ctx context.Context, service *migra.Service, tenant *migra.Tenant, command []string, steps int) (*migra.Result, error) {
+	data := commandTemplateData{
+		Service: *service,
+		Steps:   steps,
+	}
+	if tenant != nil {
+		data.Tenant = *tenant
+	}
+
+	args, err := RenderCommand(command, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.executeCommand(ctx, service, tenant, args[0], args[1:]...)
+}
+
+// commandSpec returns the command declaration of a service
+func commandSpec(service *migra.Service) (*migra.CommandSpec, error) {
+	if service.Command == nil || len(service.Command.Deploy) == 0 {
+		return nil, fmt.Errorf("service %s has no command.deploy declared", service.Name)
+	}
+	return service.Command, nil
+}
+
+// RenderCommand renders each argument of a command as a Go template
+func RenderCommand(command []string, data interface{}) ([]string, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+
+	args := make([]string, 0, len(command))
+	for i, arg := range command {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template in argument %q: %w", arg, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render argument %q: %w", arg, err)
+		}
+		args = append(args, buf.String())
+	}
+
+	if args[0] == "" {
+		return nil, fmt.Errorf("command renders to an empty executable")
+	}
+	return args, nil
+}
+
+// ParseStatusOutput parses status command output with the configured parser.
+// Without a parser the status has no applied or pending migrations.
+func ParseStatusOutput(parser *migra.StatusParser, output string) (*migra.StatusResult, error) {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	if parser == nil {
+		return status, nil
+	}
+
+	switch parser.Format {
+	case migra.StatusFormatRegex:
+		return parseRegexStatus(parser, output, status)
+	case migra.StatusFormatJSON:
+		return parseJSONStatus(parser, output, status)
+	default:
+		return nil, fmt.Errorf("unsupported status parser format '%s'", parser.Format)
+	}
+}
+
+// parseRegexStatus matches each output line against the applied and pending patterns
+func parseRegexStatus(parser *migra.StatusParser, output string, status *migra.StatusResult) (*migra.StatusResult, error) {
+	var applied, pending *regexp.Regexp
+	var err error
+
+	if parser.Applied != "" {
+		if applied, err = regexp.Compile(parser.Applied); err != nil {
+			return nil, fmt.Errorf("invalid applied pattern: %w", err)
+		}
+	}
+	if parser.Pending != "" {
+		if pending, err = regexp.Compile(parser.Pending); err != nil {
+			return nil, fmt.Errorf("invalid pending pattern: %w", err)
+		}
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if applied != nil {
+			if name, ok := regexMigrationName(applied, line); ok {
+				status.Applied = append(status.Applied, name)
+				continue
+			}
+		}
+		if pending != nil {
+			if name, ok := regexMigrationName(pending, line); ok {
+				status.Pending = append(status.Pending, name)
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// regexMigrationName returns the "name" group, the first group or the
+// whole match of a pattern against a line
+func regexMigrationName(re *regexp.Regexp, line string) (string, bool) {
+	match := re.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+
+	if idx := re.SubexpIndex("name"); idx > 0 {
+		return strings.TrimSpace(match[idx]), true
+	}
+	if len(match) > 1 {
+		return strings.TrimSpace(match[1]), true
+	}
+	return strings.TrimSpace(match[0]), true
+}
+
+// parseJSONStatus reads the applied and pending arrays from JSON output
+func parseJSONStatus(parser *migra.StatusParser, output string, status *migra.StatusResult) (*migra.StatusResult, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse status output as JSON: %w", err)
+	}
+
+	nameField := parser.NameField
+	if nameField == "" {
+		nameField = "name"
+	}
+
+	var err error
+	if parser.Applied != "" {
+		if status.Applied, err = jsonMigrationNames(doc, parser.Applied, nameField); err != nil {
+			return nil, err
+		}
+	}
+	if parser.Pending != "" {
+		if status.Pending, err = jsonMigrationNames(doc, parser.Pending, nameField); err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// jsonMigrationNames follows a dotted path to an array and returns the
+// migration names it holds
+func jsonMigrationNames(doc interface{}, path, nameField string) ([]string, error) {
+	value := doc
+	if path != "." {
+		for _, key := range strings.Split(path, ".") {
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("status output has no object at '%s'", path)
+			}
+			if value, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("status output has no field '%s' in '%s'", key, path)
+			}
+		}
+	}
+
+	if value == nil {
+		return make([]string, 0), nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("status output field '%s' is not an array", path)
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			names = append(names, v)
+		case map[string]interface{}:
+			name, ok := v[nameField].(string)
+			if !ok {
+				return nil, fmt.Errorf("status output entry in '%s' has no string field '%s'", path, nameField)
+			}
+			names = append(names, name)
+		default:
+			names = append(names, fmt.Sprint(v))
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,195 @@
+package adapter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCommand(t *testing.T) {
+	data := commandTemplateData{
+		Service: migra.Service{Name: "billing"},
+		Tenant:  migra.Tenant{ID: "acme"},
+		Steps:   3,
+	}
+
+	t.Run("renders templates", func(t *testing.T) {
+		args, err := RenderCommand([]string{"tool", "down", "{{.Steps}}", "--tenant={{.Tenant.ID}}", "{{.Service.Name}}"}, data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tool", "down", "3", "--tenant=acme", "billing"}, args)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := RenderCommand([]string{"tool", "{{.Steps"}, data)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := RenderCommand([]string{"tool", "{{.Missing}}"}, data)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty command", func(t *testing.T) {
+		_, err := RenderCommand(nil, data)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseStatusOutput(t *testing.T) {
+	t.Run("no parser", func(t *testing.T) {
+		status, err := ParseStatusOutput(nil, "anything")
+		require.NoError(t, err)
+		assert.Empty(t, status.Applied)
+		assert.Empty(t, status.Pending)
+	})
+
+	t.Run("regex with named group", func(t *testing.T) {
+		parser := &migra.StatusParser{
+			Format:  migra.StatusFormatRegex,
+			Applied: `^\[X\] (?P<name>\S+)`,
+			Pending: `^\[ \] (?P<name>\S+)`,
+		}
+		output := "Migrations:\n[X] 001_init\n[X] 002_users\r\n[ ] 003_orders\n"
+
+		status, err := ParseStatusOutput(parser, output)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"001_init", "002_users"}, status.Applied)
+		assert.Equal(t, []string{"003_orders"}, status.Pending)
+	})
+
+	t.Run("regex with first group", func(t *testing.T) {
+		parser := &migra.StatusParser{
+			Format:  migra.StatusFormatRegex,
+			Pending: `pending: (\S+)`,
+		}
+
+		status, err := ParseStatusOutput(parser, "pending: 004_a\npending: 005_b")
+		require.NoError(t, err)
+		assert.Empty(t, status.Applied)
+		assert.Equal(t, []string{"004_a", "005_b"}, status.Pending)
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		parser := &migra.StatusParser{Format: migra.StatusFormatRegex, Applied: `(`}
+		_, err := ParseStatusOutput(parser, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("json with objects and strings", func(t *testing.T) {
+		parser := &migra.StatusParser{
+			Format:    migra.StatusFormatJSON,
+			Applied:   "migrations.applied",
+			Pending:   "migrations.pending",
+			NameField: "id",
+		}
+		output := `{"migrations": {"applied": [{"id": "001_init"}, {"id": "002_users"}], "pending": ["003_orders"]}}`
+
+		status, err := ParseStatusOutput(parser, output)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"001_init", "002_users"}, status.Applied)
+		assert.Equal(t, []string{"003_orders"}, status.Pending)
+	})
+
+	t.Run("json missing field", func(t *testing.T) {
+		parser := &migra.StatusParser{Format: migra.StatusFormatJSON, Pending: "pending"}
+		_, err := ParseStatusOutput(parser, `{"applied": []}`)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		parser := &migra.StatusParser{Format: migra.StatusFormatJSON, Pending: "pending"}
+		_, err := ParseStatusOutput(parser, "not json")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := ParseStatusOutput(&migra.StatusParser{Format: "xml"}, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestCommandAdapter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test commands require a POSIX shell")
+	}
+
+	ctx := context.Background()
+	adapter := NewCommandAdapter()
+	assert.Equal(t, "command", adapter.Name())
+
+	dir := t.TempDir()
+	service := &migra.Service{
+		Name:       "billing",
+		Type:       "command",
+		WorkingDir: dir,
+		Command: &migra.CommandSpec{
+			Deploy:   []string{"sh", "-c", "echo deployed $DATABASE_URL > deploy.log"},
+			Rollback: []string{"sh", "-c", "echo {{.Steps}} {{.Tenant.ID}} > rollback.log"},
+			Status:   []string{"sh", "-c", "printf 'up 001_init\\ndown 002_users\\n'"},
+			StatusParser: &migra.StatusParser{
+				Format:  migra.StatusFormatRegex,
+				Applied: `^up (\S+)`,
+				Pending: `^down (\S+)`,
+			},
+		},
+	}
+	tenant := &migra.Tenant{
+		ID:         "acme",
+		Connection: map[string]string{"DATABASE_URL": "postgres://acme"},
+	}
+
+	t.Run("deploy", func(t *testing.T) {
+		result, err := adapter.Deploy(ctx, service, tenant)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+
+		data, err := os.ReadFile(filepath.Join(dir, "deploy.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "deployed postgres://acme\n", string(data))
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		result, err := adapter.Rollback(ctx, service, tenant, 2)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+
+		data, err := os.ReadFile(filepath.Join(dir, "rollback.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "2 acme\n", string(data))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		status, err := adapter.Status(ctx, service, tenant)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"001_init"}, status.Applied)
+		assert.Equal(t, []string{"002_users"}, status.Pending)
+	})
+
+	t.Run("failed status", func(t *testing.T) {
+		failing := *service
+		failing.Command = &migra.CommandSpec{
+			Deploy: service.Command.Deploy,
+			Status: []string{"sh", "-c", "exit 3"},
+		}
+		_, err := adapter.Status(ctx, &failing, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing rollback command", func(t *testing.T) {
+		noRollback := *service
+		noRollback.Command = &migra.CommandSpec{Deploy: service.Command.Deploy}
+		_, err := adapter.Rollback(ctx, &noRollback, nil, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing command spec", func(t *testing.T) {
+		_, err := adapter.Deploy(ctx, &migra.Service{Name: "bare", Type: "command"}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,104 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// DbmateAdapter implements the Adapter interface for dbmate
+type DbmateAdapter struct {
+	*BaseAdapter
+}
+
+// NewDbmateAdapter creates a new dbmate adapter
+func NewDbmateAdapter() *DbmateAdapter {
+	return &DbmateAdapter{
+		BaseAdapter: NewBaseAdapter("dbmate"),
+	}
+}
+
+// Deploy runs dbmate migrations
+func (a *DbmateAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// dbmate: dbmate up
+	result, err := a.executeCommand(ctx, service, tenant, "dbmate", dbmateArgs(service, "up")...)
+	if err != nil {
+		return result, fmt.Errorf("dbmate deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back dbmate migrations. dbmate rolls back one migration
+// per `rollback`, so it is run once per step.
+func (a *DbmateAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// dbmate: dbmate rollback, once per step
+	result, err := a.repeatCommand(ctx, service, tenant, steps, "dbmate", dbmateArgs(service, "rollback")...)
+	if err != nil {
+		return result, fmt.Errorf("dbmate rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for dbmate
+func (a *DbmateAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, service, tenant, "dbmate", dbmateArgs(service, "status")...)
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("dbmate status failed: %s", result.Error)
+	}
+
+	return parseDbmateStatus(result.Output), nil
+}
+
+// dbmateArgs builds the dbmate arguments. dbmate reads DATABASE_URL from
+// the environment and defaults to db/migrations.
+func dbmateArgs(service *migra.Service, command string) []string {
+	if service.MigrationsDir != "" {
+		return []string{"--migrations-dir", service.MigrationsDir, command}
+	}
+	return []string{command}
+}
+
+// parseDbmateStatus parses `dbmate status` output:
+//
+//	[X] 20240108120000_create_users.sql
+//	[ ] 20240110090000_create_orders.sql
+//
+//	Applied: 1
+//	Pending: 1
+func parseDbmateStatus(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[X]"):
+			status.Applied = append(status.Applied, strings.TrimSpace(line[3:]))
+		case strings.HasPrefix(line, "[ ]"):
+			status.Pending = append(status.Pending, strings.TrimSpace(line[3:]))
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,30 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDbmateArgs(t *testing.T) {
+	adapter := NewDbmateAdapter()
+	assert.Equal(t, "dbmate", adapter.Name())
+
+	assert.Equal(t, []string{"up"}, dbmateArgs(&migra.Service{}, "up"))
+	assert.Equal(t, []string{"--migrations-dir", "sql", "status"}, dbmateArgs(&migra.Service{MigrationsDir: "sql"}, "status"))
+}
+
+func TestParseDbmateStatus(t *testing.T) {
+	output := `[X] 20240108120000_create_users.sql
+[X] 20240109080000_add_email.sql
+[ ] 20240110090000_create_orders.sql
+
+Applied: 2
+Pending: 1
+`
+
+	status := parseDbmateStatus(output)
+	assert.Equal(t, []string{"20240108120000_create_users.sql", "20240109080000_add_email.sql"}, status.Applied)
+	assert.Equal(t, []string{"20240110090000_create_orders.sql"}, status.Pending)
+}
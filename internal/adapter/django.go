@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/migra/migra/pkg/migra"
 )
@@ -32,21 +33,36 @@ func (a *DjangoAdapter) Deploy(ctx context.Context, service *migra.Service, tena
 	return result, nil
 }
 
-// Rollback rolls back Django migrations
+// Rollback rolls back the last steps applied Django migrations.
+// It reads the migration plan and migrates each affected app back to the
+// latest migration that is kept, or to zero if none is.
 func (a *DjangoAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
 	if steps <= 0 {
 		return nil, fmt.Errorf("rollback steps must be positive")
 	}
 
-	// For Django, we need to specify the migration to rollback to
-	// This is a simplified version - in production, you'd query the migration history
-	result, err := a.executeCommand(ctx, service, tenant, "python", "manage.py", "migrate", "--no-input")
+	status, err := a.Status(ctx, service, tenant)
 	if err != nil {
-		return result, fmt.Errorf("django rollback failed: %w", err)
+		return nil, fmt.Errorf("django rollback failed: %w", err)
 	}
 
-	result.Output = a.sanitizeOutput(result.Output)
-	return result, nil
+	targets, err := djangoRollbackTargets(status.Applied, steps)
+	if err != nil {
+		return nil, fmt.Errorf("django rollback failed: %w", err)
+	}
+
+	return a.migrateTo(ctx, service, tenant, targets)
+}
+
+// RollbackTo rolls back a Django app to the named migration.
+// The target has the form "app.migration" (or "app.zero" to unapply all).
+func (a *DjangoAdapter) RollbackTo(ctx context.Context, service *migra.Service, tenant *migra.Tenant, target string) (*migra.Result, error) {
+	app, name, ok := strings.Cut(target, ".")
+	if !ok || app == "" || name == "" {
+		return nil, fmt.Errorf("invalid django rollback target '%s' (expected app.migration)", target)
+	}
+
+	return a.migrateTo(ctx, service, tenant, []djangoTarget{{App: app, Migration: name}})
 }
 
 // Status returns the migration status for Django
@@ -58,13 +74,60 @@ func (a *DjangoAdapter) Status(ctx context.Context, service *migra.Service, tena
 		}, err
 	}
 
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("django status failed: %s", result.Error)
+	}
+
+	return parseDjangoPlan(result.Output), nil
+}
+
+// migrateTo runs manage.py migrate for each target in order, stopping at
+// the first failure
+func (a *DjangoAdapter) migrateTo(ctx context.Context, service *migra.Service, tenant *migra.Tenant, targets []djangoTarget) (*migra.Result, error) {
+	combined := &migra.Result{
+		Success:   true,
+		Timestamp: time.Now(),
+	}
+
+	outputs := make([]string, 0, len(targets))
+	for _, target := range targets {
+		result, err := a.executeCommand(ctx, service, tenant, "python", "manage.py", "migrate", target.App, target.Migration, "--no-input")
+		if err != nil {
+			return result, fmt.Errorf("django rollback failed: %w", err)
+		}
+
+		combined.Duration += result.Duration
+		combined.Timestamp = result.Timestamp
+		outputs = append(outputs, a.sanitizeOutput(result.Output))
+
+		if !result.Success {
+			combined.Success = false
+			combined.Error = result.Error
+			break
+		}
+	}
+
+	combined.Output = strings.Join(outputs, "\n")
+	return combined, nil
+}
+
+// djangoTarget is a migration an app should be migrated back to
+type djangoTarget struct {
+	App       string
+	Migration string
+}
+
+// parseDjangoPlan parses the output of showmigrations --plan, preserving
+// the order migrations are applied in
+func parseDjangoPlan(output string) *migra.StatusResult {
 	status := &migra.StatusResult{
 		Applied: make([]string, 0),
 		Pending: make([]string, 0),
 	}
 
-	// Parse Django migration output
-	lines := strings.Split(result.Output, "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
@@ -72,11 +135,66 @@ func (a *DjangoAdapter) Status(ctx context.Context, service *migra.Service, tena
 		}
 
 		if strings.HasPrefix(line, "[X]") {
-			status.Applied = append(status.Applied, strings.TrimSpace(line[3:]))
+			status.Applied = append(status.Applied, djangoMigrationName(line[3:]))
 		} else if strings.HasPrefix(line, "[ ]") {
-			status.Pending = append(status.Pending, strings.TrimSpace(line[3:]))
+			status.Pending = append(status.Pending, djangoMigrationName(line[3:]))
+		}
+	}
+
+	return status
+}
+
+// djangoMigrationName strips whitespace and any "(applied at ...)" suffix
+// from a showmigrations entry
+func djangoMigrationName(entry string) string {
+	fields := strings.Fields(entry)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// djangoRollbackTargets works out which migration each app must be
+// migrated back to in order to unapply the last steps applied migrations.
+// Targets are returned most recently applied app first.
+func djangoRollbackTargets(applied []string, steps int) ([]djangoTarget, error) {
+	if steps > len(applied) {
+		return nil, fmt.Errorf("cannot roll back %d step(s): only %d migration(s) applied", steps, len(applied))
+	}
+
+	kept := applied[:len(applied)-steps]
+	undone := applied[len(applied)-steps:]
+
+	targets := make([]djangoTarget, 0)
+	seen := make(map[string]bool)
+	for i := len(undone) - 1; i >= 0; i-- {
+		app, _, ok := strings.Cut(undone[i], ".")
+		if !ok {
+			return nil, fmt.Errorf("unexpected migration name '%s'", undone[i])
 		}
+		if seen[app] {
+			continue
+		}
+		seen[app] = true
+
+		target := djangoTarget{App: app, Migration: "zero"}
+		for j := len(kept) - 1; j >= 0; j-- {
+			if keptApp, name, _ := strings.Cut(kept[j], "."); keptApp == app {
+				target.Migration = name
+				break
+			}
+		}
+		targets = append(targets, target)
 	}
 
-	return status, nil
+	return targets, nil
+}
+
+// schemaEnv points the PostgreSQL connection at the tenant schema through
+// the search_path option of PGOPTIONS
+func (a *DjangoAdapter) schemaEnv(service *migra.Service, tenant *migra.Tenant) map[string]string {
+	// PGOPTIONS splits options on spaces not escaped with a backslash
+	schema := strings.NewReplacer(`\`, `\\`, " ", `\ `).Replace(quoteIdentifier(tenant.Schema))
+	options := strings.TrimSpace(a.lookupEnv(service, tenant, "PGOPTIONS") + " -c search_path=" + schema)
+	return map[string]string{"PGOPTIONS": options}
 }
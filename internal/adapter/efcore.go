@@ -0,0 +1,148 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// EFCoreAdapter implements the Adapter interface for Entity Framework Core
+type EFCoreAdapter struct {
+	*BaseAdapter
+}
+
+// NewEFCoreAdapter creates a new Entity Framework Core adapter
+func NewEFCoreAdapter() *EFCoreAdapter {
+	return &EFCoreAdapter{
+		BaseAdapter: NewBaseAdapter("efcore"),
+	}
+}
+
+// Deploy runs Entity Framework Core migrations
+func (a *EFCoreAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// EF Core: dotnet ef database update
+	result, err := a.executeCommand(ctx, service, tenant, "dotnet", "ef", "database", "update")
+	if err != nil {
+		return result, fmt.Errorf("efcore deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back the last steps applied migrations by updating the
+// database to the migration applied before them
+func (a *EFCoreAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	status, err := a.Status(ctx, service, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("efcore rollback failed: %w", err)
+	}
+
+	target, err := efcoreRollbackTarget(status.Applied, steps)
+	if err != nil {
+		return nil, fmt.Errorf("efcore rollback failed: %w", err)
+	}
+
+	return a.RollbackTo(ctx, service, tenant, target)
+}
+
+// RollbackTo updates the database to the named migration ("0" unapplies all)
+func (a *EFCoreAdapter) RollbackTo(ctx context.Context, service *migra.Service, tenant *migra.Tenant, target string) (*migra.Result, error) {
+	// EF Core: dotnet ef database update <migration>
+	result, err := a.executeCommand(ctx, service, tenant, "dotnet", "ef", "database", "update", target)
+	if err != nil {
+		return result, fmt.Errorf("efcore rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for Entity Framework Core
+func (a *EFCoreAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, service, tenant, "dotnet", "ef", "migrations", "list")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("efcore status failed: %s", result.Error)
+	}
+
+	status, err := parseEFCoreMigrations(result.Output)
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, fmt.Errorf("efcore status failed: %w", err)
+	}
+	return status, nil
+}
+
+// efcoreMigration matches a migration line of `dotnet ef migrations list`
+var efcoreMigration = regexp.MustCompile(`^(\d{14}_\w+)(\s+\(Pending\))?$`)
+
+// efcoreNoDatabase is printed by `dotnet ef migrations list` when it
+// cannot read the applied migrations from the database
+const efcoreNoDatabase = "Continuing without the information provided by the database"
+
+// parseEFCoreMigrations parses `dotnet ef migrations list` output:
+//
+//	Build started...
+//	Build succeeded.
+//	20240108120000_InitialCreate
+//	20240110090000_AddOrders (Pending)
+//
+// When the database cannot be reached the list has no (Pending) markers,
+// so which migrations are applied is unknown and an error is returned.
+func parseEFCoreMigrations(output string) (*migra.StatusResult, error) {
+	if strings.Contains(output, efcoreNoDatabase) {
+		return nil, fmt.Errorf("could not read applied migrations from the database")
+	}
+
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		match := efcoreMigration.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		if match[2] != "" {
+			status.Pending = append(status.Pending, match[1])
+		} else {
+			status.Applied = append(status.Applied, match[1])
+		}
+	}
+
+	return status, nil
+}
+
+// efcoreRollbackTarget returns the migration to update to so that the last
+// steps applied migrations are unapplied
+func efcoreRollbackTarget(applied []string, steps int) (string, error) {
+	if len(applied) == 0 {
+		return "", fmt.Errorf("no applied migrations to roll back")
+	}
+	if steps > len(applied) {
+		return "", fmt.Errorf("cannot roll back %d step(s): only %d migration(s) applied", steps, len(applied))
+	}
+	if steps == len(applied) {
+		return "0", nil
+	}
+	return applied[len(applied)-1-steps], nil
+}
@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEFCoreAdapter(t *testing.T) {
+	adapter := NewEFCoreAdapter()
+	assert.Equal(t, "efcore", adapter.Name())
+
+	var _ migra.TargetRollbacker = adapter
+}
+
+func TestParseEFCoreMigrations(t *testing.T) {
+	output := `Build started...
+Build succeeded.
+20240108120000_InitialCreate
+20240109080000_AddEmail
+20240110090000_AddOrders (Pending)
+`
+
+	status, err := parseEFCoreMigrations(output)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20240108120000_InitialCreate", "20240109080000_AddEmail"}, status.Applied)
+	assert.Equal(t, []string{"20240110090000_AddOrders"}, status.Pending)
+
+	t.Run("unreachable database", func(t *testing.T) {
+		output := `Build started...
+Build succeeded.
+An error occurred while accessing the database. Continuing without the information provided by the database. Error: Connection refused
+20240108120000_InitialCreate
+20240109080000_AddEmail
+`
+		_, err := parseEFCoreMigrations(output)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "could not read applied migrations")
+	})
+}
+
+func TestEFCoreRollbackTarget(t *testing.T) {
+	applied := []string{"20240108120000_InitialCreate", "20240109080000_AddEmail", "20240110090000_AddOrders"}
+
+	target, err := efcoreRollbackTarget(applied, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "20240109080000_AddEmail", target)
+
+	target, err = efcoreRollbackTarget(applied, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "20240108120000_InitialCreate", target)
+
+	target, err = efcoreRollbackTarget(applied, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "0", target)
+
+	_, err = efcoreRollbackTarget(applied, 5)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only 3 migration(s) applied")
+
+	_, err = efcoreRollbackTarget(nil, 1)
+	assert.Error(t, err)
+}
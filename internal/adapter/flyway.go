@@ -0,0 +1,214 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// FlywayAdapter implements the Adapter interface for Flyway
+type FlywayAdapter struct {
+	*BaseAdapter
+}
+
+// NewFlywayAdapter creates a new Flyway adapter
+func NewFlywayAdapter() *FlywayAdapter {
+	return &FlywayAdapter{
+		BaseAdapter: NewBaseAdapter("flyway"),
+	}
+}
+
+// flywayEnv maps the generic connection variables to Flyway's own
+var flywayEnv = []jdbcVar{
+	{target: "FLYWAY_URL", generic: "DATABASE_URL"},
+	{target: "FLYWAY_USER", generic: "DATABASE_USER"},
+	{target: "FLYWAY_PASSWORD", generic: "DATABASE_PASSWORD"},
+}
+
+// Deploy runs Flyway migrations
+func (a *FlywayAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// Flyway: flyway migrate
+	result, err := a.executeCommand(ctx, withJDBCEnv(service, tenant, flywayEnv), tenant, "flyway", "migrate")
+	if err != nil {
+		return result, fmt.Errorf("flyway deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback undoes Flyway migrations. Flyway undoes one migration per
+// `undo`, so it is run once per step.
+func (a *FlywayAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// Flyway: flyway undo, once per step
+	result, err := a.repeatCommand(ctx, withJDBCEnv(service, tenant, flywayEnv), tenant, steps, "flyway", "undo")
+	if err != nil {
+		return result, fmt.Errorf("flyway rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for Flyway
+func (a *FlywayAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, withJDBCEnv(service, tenant, flywayEnv), tenant, "flyway", "info")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("flyway status failed: %s", result.Error)
+	}
+
+	return parseFlywayInfo(result.Output), nil
+}
+
+// parseFlywayInfo parses the table printed by `flyway info`:
+//
+//	+-----------+---------+-------------+------+---------------------+---------+
+//	| Category  | Version | Description | Type | Installed On        | State   |
+//	+-----------+---------+-------------+------+---------------------+---------+
+//	| Versioned | 1       | init        | SQL  | 2024-01-08 12:00:00 | Success |
+//	| Versioned | 2       | add email   | SQL  |                     | Pending |
+//
+// Migrations are named by version, or by description for repeatable ones.
+func parseFlywayInfo(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	versionCol, descriptionCol, stateCol := -1, -1, -1
+	failed := make([]string, 0)
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+
+		// The header row locates the columns, which differ between versions
+		if stateCol < 0 {
+			for i, cell := range cells {
+				switch cell {
+				case "Version":
+					versionCol = i
+				case "Description":
+					descriptionCol = i
+				case "State":
+					stateCol = i
+				}
+			}
+			continue
+		}
+
+		if stateCol >= len(cells) {
+			continue
+		}
+
+		name := ""
+		if versionCol >= 0 && versionCol < len(cells) {
+			name = cells[versionCol]
+		}
+		if name == "" && descriptionCol >= 0 && descriptionCol < len(cells) {
+			name = cells[descriptionCol]
+		}
+		if name == "" {
+			continue
+		}
+
+		switch state := cells[stateCol]; {
+		case state == "Pending", state == "Undone", state == "Ignored", state == "Outdated":
+			status.Pending = append(status.Pending, name)
+		case strings.Contains(state, "Failed"):
+			failed = append(failed, name)
+		case state == "Success", state == "Baseline", state == "Out of Order", state == "Missing",
+			strings.HasPrefix(state, "Future"), state == "Above Target", state == "Superseded":
+			status.Applied = append(status.Applied, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		status.Pending = append(status.Pending, failed...)
+		status.LastError = fmt.Sprintf("failed migrations: %s", strings.Join(failed, ", "))
+	}
+
+	return status
+}
+
+// jdbcVar maps a generic connection variable to a tool-specific one
+type jdbcVar struct {
+	target  string
+	generic string
+}
+
+// withJDBCEnv returns a copy of the service whose environment sets each
+// tool-specific variable from the tenant connection, the service env or
+// the process environment. In each source the tool-specific name wins
+// over the generic one, and the tenant wins over the service.
+func withJDBCEnv(service *migra.Service, tenant *migra.Tenant, vars []jdbcVar) *migra.Service {
+	svc := *service
+	svc.Env = make(map[string]string, len(service.Env)+len(vars))
+	for k, v := range service.Env {
+		svc.Env[k] = v
+	}
+
+	for _, v := range vars {
+		if value, ok := lookupJDBCVar(service, tenant, v); ok {
+			svc.Env[v.target] = value
+		}
+	}
+
+	return &svc
+}
+
+// lookupJDBCVar finds the value of a mapped variable
+func lookupJDBCVar(service *migra.Service, tenant *migra.Tenant, v jdbcVar) (string, bool) {
+	sources := make([]map[string]string, 0, 2)
+	if tenant != nil {
+		sources = append(sources, tenant.Connection)
+	}
+	sources = append(sources, service.Env)
+
+	for _, source := range sources {
+		if value, ok := source[v.target]; ok {
+			return value, true
+		}
+		if value, ok := source[v.generic]; ok && isJDBCValue(v.generic, value) {
+			return value, true
+		}
+	}
+
+	if _, ok := os.LookupEnv(v.target); ok {
+		// The tool reads it from the environment itself
+		return "", false
+	}
+	if value, ok := os.LookupEnv(v.generic); ok && isJDBCValue(v.generic, value) {
+		return value, true
+	}
+	return "", false
+}
+
+// isJDBCValue reports whether a generic value can be passed to a JVM tool.
+// Only JDBC URLs are mapped; credentials always are.
+func isJDBCValue(key, value string) bool {
+	return key != "DATABASE_URL" || strings.HasPrefix(value, "jdbc:")
+}
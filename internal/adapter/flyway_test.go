@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlywayAdapter(t *testing.T) {
+	adapter := NewFlywayAdapter()
+	assert.Equal(t, "flyway", adapter.Name())
+}
+
+func TestParseFlywayInfo(t *testing.T) {
+	t.Run("with category column", func(t *testing.T) {
+		output := `Flyway Community Edition 10.4.1 by Redgate
+Schema version: 2
+
++-----------+---------+----------------+------------+---------------------+---------+----------+
+| Category  | Version | Description    | Type       | Installed On        | State   | Undoable |
++-----------+---------+----------------+------------+---------------------+---------+----------+
+| Versioned | 1       | create users   | SQL        | 2024-01-08 12:00:00 | Success | No       |
+| Versioned | 2       | add email      | SQL        | 2024-01-08 12:00:01 | Success | No       |
+| Repeatable|         | refresh views  | SQL        | 2024-01-08 12:00:02 | Success |          |
+| Versioned | 3       | create orders  | SQL        |                     | Pending | No       |
++-----------+---------+----------------+------------+---------------------+---------+----------+
+`
+		status := parseFlywayInfo(output)
+		assert.Equal(t, []string{"1", "2", "refresh views"}, status.Applied)
+		assert.Equal(t, []string{"3"}, status.Pending)
+		assert.Empty(t, status.LastError)
+	})
+
+	t.Run("without category column and failed migration", func(t *testing.T) {
+		output := `+---------+-------------+---------------------+---------+
+| Version | Description | Installed On        | State   |
++---------+-------------+---------------------+---------+
+| 1       | init        | 2024-01-08 12:00:00 | Success |
+| 2       | broken      | 2024-01-08 12:00:01 | Failed  |
++---------+-------------+---------------------+---------+
+`
+		status := parseFlywayInfo(output)
+		assert.Equal(t, []string{"1"}, status.Applied)
+		assert.Equal(t, []string{"2"}, status.Pending)
+		assert.Contains(t, status.LastError, "2")
+	})
+}
+
+func TestWithJDBCEnv(t *testing.T) {
+	t.Setenv("FLYWAY_URL", "")
+	t.Setenv("DATABASE_URL", "")
+
+	service := &migra.Service{
+		Name: "inventory",
+		Env: map[string]string{
+			"FLYWAY_URL":    "jdbc:postgresql://localhost/default",
+			"DATABASE_USER": "app",
+		},
+	}
+
+	t.Run("service env", func(t *testing.T) {
+		svc := withJDBCEnv(service, nil, flywayEnv)
+		assert.Equal(t, "jdbc:postgresql://localhost/default", svc.Env["FLYWAY_URL"])
+		assert.Equal(t, "app", svc.Env["FLYWAY_USER"])
+		assert.NotContains(t, service.Env, "FLYWAY_USER")
+	})
+
+	t.Run("tenant connection wins", func(t *testing.T) {
+		tenant := &migra.Tenant{ID: "acme", Connection: map[string]string{
+			"DATABASE_URL":      "jdbc:postgresql://localhost/acme",
+			"DATABASE_PASSWORD": "secret",
+		}}
+		svc := withJDBCEnv(service, tenant, flywayEnv)
+		assert.Equal(t, "jdbc:postgresql://localhost/acme", svc.Env["FLYWAY_URL"])
+		assert.Equal(t, "app", svc.Env["FLYWAY_USER"])
+		assert.Equal(t, "secret", svc.Env["FLYWAY_PASSWORD"])
+	})
+
+	t.Run("non-jdbc url is not mapped", func(t *testing.T) {
+		tenant := &migra.Tenant{ID: "acme", Connection: map[string]string{
+			"DATABASE_URL": "postgres://localhost/acme",
+		}}
+		svc := withJDBCEnv(service, tenant, flywayEnv)
+		assert.Equal(t, "jdbc:postgresql://localhost/default", svc.Env["FLYWAY_URL"])
+	})
+}
@@ -0,0 +1,195 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// defaultMigrationsDir is the migrations directory used by golang-migrate
+// and goose services unless migrations_dir is set
+const defaultMigrationsDir = "migrations"
+
+// GolangMigrateAdapter implements the Adapter interface for golang-migrate
+type GolangMigrateAdapter struct {
+	*BaseAdapter
+}
+
+// NewGolangMigrateAdapter creates a new golang-migrate adapter
+func NewGolangMigrateAdapter() *GolangMigrateAdapter {
+	return &GolangMigrateAdapter{
+		BaseAdapter: NewBaseAdapter("golang-migrate"),
+	}
+}
+
+// Deploy runs golang-migrate migrations
+func (a *GolangMigrateAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// golang-migrate: migrate -path DIR -database URL up
+	args, err := a.args(service, tenant, "up")
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.executeCommand(ctx, service, tenant, "migrate", args...)
+	if err != nil {
+		return result, fmt.Errorf("golang-migrate deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back golang-migrate migrations
+func (a *GolangMigrateAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// golang-migrate: migrate -path DIR -database URL down N
+	args, err := a.args(service, tenant, "down", strconv.Itoa(steps))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.executeCommand(ctx, service, tenant, "migrate", args...)
+	if err != nil {
+		return result, fmt.Errorf("golang-migrate rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for golang-migrate
+func (a *GolangMigrateAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	args, err := a.args(service, tenant, "version")
+	if err != nil {
+		return &migra.StatusResult{LastError: err.Error()}, err
+	}
+
+	result, err := a.executeCommand(ctx, service, tenant, "migrate", args...)
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	// A database without migrations makes `version` fail with "no migration"
+	version, dirty, ok := parseGolangMigrateVersion(result.Output)
+	if !ok {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("golang-migrate status failed: %s", result.Error)
+	}
+
+	migrations, err := listGolangMigrateMigrations(filepath.Join(service.WorkingDir, a.migrationsDir(service, defaultMigrationsDir)))
+	if err != nil {
+		return &migra.StatusResult{LastError: err.Error()}, err
+	}
+
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+	for _, m := range migrations {
+		if m.version < version || (m.version == version && !dirty) {
+			status.Applied = append(status.Applied, m.name)
+		} else {
+			status.Pending = append(status.Pending, m.name)
+		}
+	}
+	if dirty {
+		status.LastError = fmt.Sprintf("database is dirty at version %d", version)
+	}
+
+	return status, nil
+}
+
+// args builds the golang-migrate arguments. The database URL comes from
+// DATABASE_URL; a configured driver replaces its scheme.
+func (a *GolangMigrateAdapter) args(service *migra.Service, tenant *migra.Tenant, command ...string) ([]string, error) {
+	url := a.lookupEnv(service, tenant, "DATABASE_URL")
+	if url == "" {
+		return nil, fmt.Errorf("DATABASE_URL is not set for service %s", service.Name)
+	}
+
+	if service.Driver != "" {
+		if _, rest, ok := strings.Cut(url, "://"); ok {
+			url = service.Driver + "://" + rest
+		}
+	}
+
+	args := []string{"-path", a.migrationsDir(service, defaultMigrationsDir), "-database", url}
+	return append(args, command...), nil
+}
+
+// parseGolangMigrateVersion parses `migrate version` output such as "3" or
+// "3 (dirty)". A database without migrations is reported as version 0.
+func parseGolangMigrateVersion(output string) (version uint64, dirty bool, ok bool) {
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "no migration") {
+			return 0, false, true
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !isDigits(fields[0]) {
+			continue
+		}
+
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		return v, strings.Contains(line, "(dirty)"), true
+	}
+
+	return 0, false, false
+}
+
+// golangMigrateFile is an up migration in the migrations directory
+type golangMigrateFile struct {
+	version uint64
+	name    string
+}
+
+// golangMigrateUpFile matches golang-migrate up migration file names
+var golangMigrateUpFile = regexp.MustCompile(`^(\d+)_.*\.up\.[^.]+$`)
+
+// listGolangMigrateMigrations lists the up migrations in dir, ordered by version
+func listGolangMigrateMigrations(dir string) ([]golangMigrateFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	migrations := make([]golangMigrateFile, 0)
+	for _, entry := range entries {
+		match := golangMigrateUpFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		name := entry.Name()[:strings.LastIndex(entry.Name(), ".up.")]
+		migrations = append(migrations, golangMigrateFile{version: version, name: name})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,89 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGolangMigrateArgs(t *testing.T) {
+	adapter := NewGolangMigrateAdapter()
+	assert.Equal(t, "golang-migrate", adapter.Name())
+
+	service := &migra.Service{
+		Name: "orders",
+		Env:  map[string]string{"DATABASE_URL": "postgres://localhost/orders"},
+	}
+
+	t.Run("service database url", func(t *testing.T) {
+		args, err := adapter.args(service, nil, "up")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"-path", "migrations", "-database", "postgres://localhost/orders", "up"}, args)
+	})
+
+	t.Run("tenant connection and options", func(t *testing.T) {
+		withOptions := *service
+		withOptions.MigrationsDir = "db/sql"
+		withOptions.Driver = "pgx5"
+		tenant := &migra.Tenant{ID: "acme", Connection: map[string]string{"DATABASE_URL": "postgres://localhost/acme"}}
+
+		args, err := adapter.args(&withOptions, tenant, "down", "2")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"-path", "db/sql", "-database", "pgx5://localhost/acme", "down", "2"}, args)
+	})
+
+	t.Run("missing database url", func(t *testing.T) {
+		t.Setenv("DATABASE_URL", "")
+		_, err := adapter.args(&migra.Service{Name: "orders"}, nil, "up")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseGolangMigrateVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		version uint64
+		dirty   bool
+		ok      bool
+	}{
+		{name: "clean", output: "3\n", version: 3, ok: true},
+		{name: "dirty", output: "4 (dirty)\n", version: 4, dirty: true, ok: true},
+		{name: "no migration", output: "error: no migration\n", version: 0, ok: true},
+		{name: "failure", output: "error: failed to open database\n", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, dirty, ok := parseGolangMigrateVersion(tt.output)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.version, version)
+			assert.Equal(t, tt.dirty, dirty)
+		})
+	}
+}
+
+func TestListGolangMigrateMigrations(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"000010_add_index.up.sql",
+		"000002_add_users.up.sql",
+		"000002_add_users.down.sql",
+		"000001_init.up.sql",
+		"README.md",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(""), 0644))
+	}
+
+	migrations, err := listGolangMigrateMigrations(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []golangMigrateFile{
+		{version: 1, name: "000001_init"},
+		{version: 2, name: "000002_add_users"},
+		{version: 10, name: "000010_add_index"},
+	}, migrations)
+}
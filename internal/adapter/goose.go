@@ -0,0 +1,123 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// GooseAdapter implements the Adapter interface for goose
+type GooseAdapter struct {
+	*BaseAdapter
+}
+
+// NewGooseAdapter creates a new goose adapter
+func NewGooseAdapter() *GooseAdapter {
+	return &GooseAdapter{
+		BaseAdapter: NewBaseAdapter("goose"),
+	}
+}
+
+// Deploy runs goose migrations
+func (a *GooseAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// goose: goose -dir DIR DRIVER DBSTRING up
+	result, err := a.executeCommand(ctx, service, tenant, "goose", a.args(service, tenant, "up")...)
+	if err != nil {
+		return result, fmt.Errorf("goose deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back goose migrations. goose rolls back one migration per
+// `down`, so it is run once per step.
+func (a *GooseAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// goose: goose -dir DIR DRIVER DBSTRING down, once per step
+	result, err := a.repeatCommand(ctx, service, tenant, steps, "goose", a.args(service, tenant, "down")...)
+	if err != nil {
+		return result, fmt.Errorf("goose rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for goose
+func (a *GooseAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, service, tenant, "goose", a.args(service, tenant, "status")...)
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("goose status failed: %s", result.Error)
+	}
+
+	return parseGooseStatus(result.Output), nil
+}
+
+// args builds the goose arguments. The driver comes from the service or
+// GOOSE_DRIVER and the connection string from GOOSE_DBSTRING or
+// DATABASE_URL; without both, goose reads its own environment.
+func (a *GooseAdapter) args(service *migra.Service, tenant *migra.Tenant, command ...string) []string {
+	args := []string{"-dir", a.migrationsDir(service, defaultMigrationsDir)}
+
+	driver := service.Driver
+	if driver == "" {
+		driver = a.lookupEnv(service, tenant, "GOOSE_DRIVER")
+	}
+	dbString := a.lookupEnv(service, tenant, "GOOSE_DBSTRING")
+	if dbString == "" {
+		dbString = a.lookupEnv(service, tenant, "DATABASE_URL")
+	}
+
+	if driver != "" && dbString != "" {
+		args = append(args, driver, dbString)
+	}
+	return append(args, command...)
+}
+
+// parseGooseStatus parses `goose status` output:
+//
+//	Applied At                  Migration
+//	=======================================
+//	Mon Jan  8 12:00:00 2024 -- 00001_create_users.sql
+//	Pending                  -- 00002_add_email.sql
+func parseGooseStatus(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		state, migration, ok := strings.Cut(line, " -- ")
+		if !ok {
+			continue
+		}
+
+		migration = strings.TrimSpace(migration)
+		if migration == "" {
+			continue
+		}
+
+		if strings.HasSuffix(strings.TrimSpace(state), "Pending") {
+			status.Pending = append(status.Pending, migration)
+		} else {
+			status.Applied = append(status.Applied, migration)
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGooseArgs(t *testing.T) {
+	adapter := NewGooseAdapter()
+	assert.Equal(t, "goose", adapter.Name())
+
+	t.Run("driver and database url", func(t *testing.T) {
+		service := &migra.Service{
+			Name:          "catalog",
+			Driver:        "postgres",
+			MigrationsDir: "sql/migrations",
+			Env:           map[string]string{"DATABASE_URL": "postgres://localhost/catalog"},
+		}
+		args := adapter.args(service, nil, "up")
+		assert.Equal(t, []string{"-dir", "sql/migrations", "postgres", "postgres://localhost/catalog", "up"}, args)
+	})
+
+	t.Run("goose environment", func(t *testing.T) {
+		t.Setenv("GOOSE_DRIVER", "")
+		t.Setenv("GOOSE_DBSTRING", "")
+		t.Setenv("DATABASE_URL", "")
+		args := adapter.args(&migra.Service{Name: "catalog"}, nil, "status")
+		assert.Equal(t, []string{"-dir", "migrations", "status"}, args)
+	})
+}
+
+func TestParseGooseStatus(t *testing.T) {
+	output := `2024/01/08 12:00:00     Applied At                  Migration
+2024/01/08 12:00:00     =======================================
+2024/01/08 12:00:00     Mon Jan  8 12:00:00 2024 -- 00001_create_users.sql
+2024/01/08 12:00:00     Mon Jan  8 12:00:01 2024 -- 00002_add_email.go
+2024/01/08 12:00:00     Pending                  -- 00003_create_orders.sql
+`
+
+	status := parseGooseStatus(output)
+	assert.Equal(t, []string{"00001_create_users.sql", "00002_add_email.go"}, status.Applied)
+	assert.Equal(t, []string{"00003_create_orders.sql"}, status.Pending)
+}
@@ -0,0 +1,131 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// KnexAdapter implements the Adapter interface for Knex
+type KnexAdapter struct {
+	*BaseAdapter
+}
+
+// NewKnexAdapter creates a new Knex adapter
+func NewKnexAdapter() *KnexAdapter {
+	return &KnexAdapter{
+		BaseAdapter: NewBaseAdapter("knex"),
+	}
+}
+
+// Deploy runs Knex migrations
+func (a *KnexAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// Knex: npx knex migrate:latest
+	result, err := a.executeCommand(ctx, service, tenant, "npx", "knex", "migrate:latest")
+	if err != nil {
+		return result, fmt.Errorf("knex deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back Knex migrations. `migrate:down` undoes the last
+// migration, so it is run once per step.
+func (a *KnexAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// Knex: npx knex migrate:down, once per step
+	result, err := a.repeatCommand(ctx, service, tenant, steps, "npx", "knex", "migrate:down")
+	if err != nil {
+		return result, fmt.Errorf("knex rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for Knex
+func (a *KnexAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, service, tenant, "npx", "knex", "migrate:list")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("knex status failed: %s", result.Error)
+	}
+
+	return parseKnexList(result.Output), nil
+}
+
+// parseKnexList parses `knex migrate:list` output:
+//
+//	Using environment: production
+//	Found 2 Completed Migration file/files.
+//	20240108120000_create_users.js
+//	20240108120100_add_email.js
+//	Found 1 Pending Migration file/files.
+//	20240110090000_create_orders.js
+func parseKnexList(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	var section *[]string
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(stripANSI(line))
+		if line == "" {
+			continue
+		}
+
+		lower := strings.ToLower(line)
+		switch {
+		case strings.Contains(lower, "completed migration"):
+			section = &status.Applied
+			continue
+		case strings.Contains(lower, "pending migration"):
+			section = &status.Pending
+			continue
+		case strings.HasPrefix(lower, "using environment"), strings.HasPrefix(lower, "requiring external module"):
+			continue
+		}
+
+		if section != nil && !strings.ContainsAny(line, " \t") {
+			*section = append(*section, line)
+		}
+	}
+
+	return status
+}
+
+// stripANSI removes terminal color escape sequences from a line
+func stripANSI(line string) string {
+	if !strings.Contains(line, "\x1b[") {
+		return line
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\x1b' && i+1 < len(line) && line[i+1] == '[' {
+			// Skip to the final byte of the sequence
+			i += 2
+			for i < len(line) && (line[i] < '@' || line[i] > '~') {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnexAdapter(t *testing.T) {
+	adapter := NewKnexAdapter()
+	assert.Equal(t, "knex", adapter.Name())
+}
+
+func TestParseKnexList(t *testing.T) {
+	t.Run("completed and pending", func(t *testing.T) {
+		output := "Using environment: production\n" +
+			"\x1b[32mFound 2 Completed Migration file/files.\x1b[39m\n" +
+			"\x1b[36m20240108120000_create_users.js \x1b[39m\n" +
+			"20240108120100_add_email.js\n" +
+			"\x1b[33mFound 1 Pending Migration file/files.\x1b[39m\n" +
+			"20240110090000_create_orders.js\n"
+
+		status := parseKnexList(output)
+		assert.Equal(t, []string{"20240108120000_create_users.js", "20240108120100_add_email.js"}, status.Applied)
+		assert.Equal(t, []string{"20240110090000_create_orders.js"}, status.Pending)
+	})
+
+	t.Run("nothing pending", func(t *testing.T) {
+		output := "Found 1 Completed Migration file/files.\n20240108120000_create_users.js\nNo Pending Migration files Found.\n"
+
+		status := parseKnexList(output)
+		assert.Equal(t, []string{"20240108120000_create_users.js"}, status.Applied)
+		assert.Empty(t, status.Pending)
+	})
+}
@@ -58,6 +58,12 @@ func (a *LaravelAdapter) Status(ctx context.Context, service *migra.Service, ten
 		}, err
 	}
 
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("laravel status failed: %s", result.Error)
+	}
+
 	status := &migra.StatusResult{
 		Applied: make([]string, 0),
 		Pending: make([]string, 0),
@@ -88,3 +94,12 @@ func (a *LaravelAdapter) Status(ctx context.Context, service *migra.Service, ten
 
 	return status, nil
 }
+
+// schemaEnv sets the variables read by the schema and search_path options
+// of Laravel's pgsql connection
+func (a *LaravelAdapter) schemaEnv(service *migra.Service, tenant *migra.Tenant) map[string]string {
+	return map[string]string{
+		"DB_SCHEMA":      tenant.Schema,
+		"DB_SEARCH_PATH": tenant.Schema,
+	}
+}
@@ -0,0 +1,108 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// LiquibaseAdapter implements the Adapter interface for Liquibase
+type LiquibaseAdapter struct {
+	*BaseAdapter
+}
+
+// NewLiquibaseAdapter creates a new Liquibase adapter
+func NewLiquibaseAdapter() *LiquibaseAdapter {
+	return &LiquibaseAdapter{
+		BaseAdapter: NewBaseAdapter("liquibase"),
+	}
+}
+
+// liquibaseEnv maps the generic connection variables to Liquibase's own
+var liquibaseEnv = []jdbcVar{
+	{target: "LIQUIBASE_COMMAND_URL", generic: "DATABASE_URL"},
+	{target: "LIQUIBASE_COMMAND_USERNAME", generic: "DATABASE_USER"},
+	{target: "LIQUIBASE_COMMAND_PASSWORD", generic: "DATABASE_PASSWORD"},
+}
+
+// Deploy runs Liquibase changesets
+func (a *LiquibaseAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// Liquibase: liquibase update
+	result, err := a.executeCommand(ctx, withJDBCEnv(service, tenant, liquibaseEnv), tenant, "liquibase", "update")
+	if err != nil {
+		return result, fmt.Errorf("liquibase deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back Liquibase changesets
+func (a *LiquibaseAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// Liquibase: liquibase rollbackCount N
+	result, err := a.executeCommand(ctx, withJDBCEnv(service, tenant, liquibaseEnv), tenant, "liquibase", "rollbackCount", fmt.Sprintf("%d", steps))
+	if err != nil {
+		return result, fmt.Errorf("liquibase rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the changeset status for Liquibase. `status` only lists
+// undeployed changesets, so Applied is always empty.
+func (a *LiquibaseAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, withJDBCEnv(service, tenant, liquibaseEnv), tenant, "liquibase", "status", "--verbose")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("liquibase status failed: %s", result.Error)
+	}
+
+	return parseLiquibaseStatus(result.Output), nil
+}
+
+// parseLiquibaseStatus parses `liquibase status --verbose` output:
+//
+//	2 changesets have not been applied to APP@jdbc:postgresql://localhost/app
+//	     db/changelog.xml::1::alice
+//	     db/changelog.xml::2::bob
+func parseLiquibaseStatus(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	listing := false
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if strings.Contains(line, "have not been applied") || strings.Contains(line, "has not been applied") {
+			listing = true
+			continue
+		}
+		if !listing {
+			continue
+		}
+
+		changeset := strings.TrimSpace(line)
+		if strings.Count(changeset, "::") < 2 || strings.ContainsAny(changeset, " \t") {
+			listing = changeset == ""
+			continue
+		}
+		status.Pending = append(status.Pending, changeset)
+	}
+
+	return status
+}
@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiquibaseAdapter(t *testing.T) {
+	adapter := NewLiquibaseAdapter()
+	assert.Equal(t, "liquibase", adapter.Name())
+}
+
+func TestParseLiquibaseStatus(t *testing.T) {
+	t.Run("pending changesets", func(t *testing.T) {
+		output := `Starting Liquibase at 12:00:00 (version 4.25.1)
+Liquibase Version: 4.25.1
+2 changesets have not been applied to APP@jdbc:postgresql://localhost:5432/app
+     db/changelog/db.changelog-master.yaml::1::alice
+     db/changelog/db.changelog-master.yaml::2::bob
+Liquibase command 'status' was executed successfully.
+`
+		status := parseLiquibaseStatus(output)
+		assert.Empty(t, status.Applied)
+		assert.Equal(t, []string{
+			"db/changelog/db.changelog-master.yaml::1::alice",
+			"db/changelog/db.changelog-master.yaml::2::bob",
+		}, status.Pending)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		output := "APP@jdbc:postgresql://localhost:5432/app is up to date\nLiquibase command 'status' was executed successfully.\n"
+		status := parseLiquibaseStatus(output)
+		assert.Empty(t, status.Pending)
+	})
+}
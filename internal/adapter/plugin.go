@@ -0,0 +1,134 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/migra/migra/internal/output"
+	"github.com/migra/migra/internal/process"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// PluginAdapter implements the Adapter interface by running an external
+// migra-adapter-<type> executable that speaks JSON over stdin/stdout
+type PluginAdapter struct {
+	*BaseAdapter
+	path string
+}
+
+// NewPluginAdapter creates a new adapter for the plugin executable at path
+func NewPluginAdapter(name, path string) *PluginAdapter {
+	return &PluginAdapter{
+		BaseAdapter: NewBaseAdapter(name),
+		path:        path,
+	}
+}
+
+// Path returns the plugin executable path
+func (a *PluginAdapter) Path() string {
+	return a.path
+}
+
+// Deploy asks the plugin to run migrations
+func (a *PluginAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	result, err := a.runResult(ctx, migra.OperationDeploy, service, tenant, 0)
+	if err != nil {
+		return result, fmt.Errorf("%s deploy failed: %w", a.Name(), err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback asks the plugin to roll back migrations
+func (a *PluginAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	result, err := a.runResult(ctx, migra.OperationRollback, service, tenant, steps)
+	if err != nil {
+		return result, fmt.Errorf("%s rollback failed: %w", a.Name(), err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status asks the plugin for the migration status
+func (a *PluginAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	status := &migra.StatusResult{}
+	if err := a.call(ctx, migra.OperationStatus, service, tenant, 0, status); err != nil {
+		err = fmt.Errorf("%s status failed: %w", a.Name(), err)
+		return &migra.StatusResult{LastError: err.Error()}, err
+	}
+
+	if status.Applied == nil {
+		status.Applied = make([]string, 0)
+	}
+	if status.Pending == nil {
+		status.Pending = make([]string, 0)
+	}
+	return status, nil
+}
+
+// runResult calls the plugin for an operation answered with a Result
+func (a *PluginAdapter) runResult(ctx context.Context, op migra.Operation, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	start := time.Now()
+	result := &migra.Result{}
+	if err := a.call(ctx, op, service, tenant, steps, result); err != nil {
+		return nil, err
+	}
+
+	if result.Duration == 0 {
+		result.Duration = time.Since(start)
+	}
+	if result.Timestamp.IsZero() {
+		result.Timestamp = time.Now()
+	}
+	return result, nil
+}
+
+// call sends a request to the plugin and decodes its response into out
+func (a *PluginAdapter) call(ctx context.Context, op migra.Operation, service *migra.Service, tenant *migra.Tenant, steps int, out interface{}) error {
+	request, err := json.Marshal(&migra.PluginRequest{
+		Version:   migra.PluginProtocolVersion,
+		Operation: op,
+		Service:   service,
+		Tenant:    tenant,
+		Steps:     steps,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, a.path)
+	cmd.Dir = service.WorkingDir
+	cmd.Env = commandEnv(cmd, service, tenant)
+	cmd.Stdin = bytes.NewReader(request)
+
+	// Stdout carries the response; stderr is the plugin's log output
+	var stdout bytes.Buffer
+	capture := output.NewCapture(ctx, service, tenant, a.sanitizeOutput)
+	cmd.Stdout = &stdout
+	cmd.Stderr = capture.Stderr()
+
+	err = process.Run(ctx, cmd)
+	stderr, _ := capture.Close()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr); msg != "" {
+			return fmt.Errorf("plugin %s: %w: %s", a.path, err, msg)
+		}
+		return fmt.Errorf("plugin %s: %w", a.path, err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return fmt.Errorf("plugin %s returned an invalid response: %w", a.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package adapter
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildExamplePlugin builds the reference plugin into dir under the given
+// adapter type and returns its path
+func buildExamplePlugin(t *testing.T, dir, pluginType string) string {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found on PATH")
+	}
+
+	path := filepath.Join(dir, migra.PluginPrefix+pluginType)
+	if runtime.GOOS == "windows" {
+		path += ".exe"
+	}
+
+	cmd := exec.Command(goBin, "build", "-o", path, "github.com/migra/migra/examples/plugins/migra-adapter-example")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	return path
+}
+
+// TestPluginConformance runs the reference plugin through the protocol
+func TestPluginConformance(t *testing.T) {
+	pluginDir := t.TempDir()
+	path := buildExamplePlugin(t, pluginDir, "example")
+
+	t.Run("discovery", func(t *testing.T) {
+		found, ok := migra.LookupPlugin("example", []string{pluginDir})
+		require.True(t, ok)
+		assert.Equal(t, path, found)
+
+		_, ok = migra.LookupPlugin("missing", []string{pluginDir})
+		assert.False(t, ok)
+	})
+
+	t.Run("registry loads plugins next to built-ins", func(t *testing.T) {
+		shadow, err := os.ReadFile(path)
+		require.NoError(t, err)
+		shadowPath := filepath.Join(pluginDir, migra.PluginPrefix+"django"+filepath.Ext(path))
+		require.NoError(t, os.WriteFile(shadowPath, shadow, 0755))
+		defer os.Remove(shadowPath)
+
+		registry := NewDefaultRegistry()
+		loaded := registry.LoadPlugins([]string{pluginDir})
+		assert.Contains(t, loaded, "example")
+		assert.NotContains(t, loaded, "django")
+
+		adapter, err := registry.Get("example")
+		require.NoError(t, err)
+		assert.IsType(t, &PluginAdapter{}, adapter)
+
+		adapter, err = registry.Get("django")
+		require.NoError(t, err)
+		assert.IsType(t, &DjangoAdapter{}, adapter)
+	})
+
+	t.Run("deploy, status and rollback", func(t *testing.T) {
+		ctx := context.Background()
+		workDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(workDir, "migrations"), 0755))
+		for _, name := range []string{"001_init.sql", "002_users.sql", "003_orders.sql"} {
+			require.NoError(t, os.WriteFile(filepath.Join(workDir, "migrations", name), []byte("-- sql"), 0644))
+		}
+
+		adapter := NewPluginAdapter("example", path)
+		service := &migra.Service{Name: "ledger", Type: "example", WorkingDir: workDir}
+		tenant := &migra.Tenant{ID: "acme", Connection: map[string]string{"EXAMPLE_LEDGER": "acme.ledger"}}
+
+		status, err := adapter.Status(ctx, service, tenant)
+		require.NoError(t, err)
+		assert.Empty(t, status.Applied)
+		assert.Equal(t, []string{"001_init", "002_users", "003_orders"}, status.Pending)
+
+		result, err := adapter.Deploy(ctx, service, tenant)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Contains(t, result.Output, "tenant: acme")
+		assert.Contains(t, result.Output, "3 migration(s) applied")
+		assert.False(t, result.Timestamp.IsZero())
+
+		// The tenant connection reaches the plugin environment
+		assert.FileExists(t, filepath.Join(workDir, "acme.ledger"))
+
+		result, err = adapter.Rollback(ctx, service, tenant, 2)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+
+		status, err = adapter.Status(ctx, service, tenant)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"001_init"}, status.Applied)
+		assert.Equal(t, []string{"002_users", "003_orders"}, status.Pending)
+
+		// A migration failure is a result, not an error
+		result, err = adapter.Rollback(ctx, service, tenant, 5)
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.NotEmpty(t, result.Error)
+	})
+
+	t.Run("protocol error", func(t *testing.T) {
+		adapter := NewPluginAdapter("example", path)
+		service := &migra.Service{Name: "ledger", Type: "example", WorkingDir: t.TempDir()}
+
+		status, err := adapter.Status(context.Background(), service, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "migrations directory migrations not found")
+		assert.NotEmpty(t, status.LastError)
+	})
+}
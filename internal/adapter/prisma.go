@@ -88,5 +88,21 @@ func (a *PrismaAdapter) Status(ctx context.Context, service *migra.Service, tena
 		}
 	}
 
+	// prisma migrate status exits non-zero when migrations are pending,
+	// so only treat a failure with nothing pending as an error
+	if !result.Success && len(status.Pending) == 0 {
+		status.LastError = result.Error
+		return status, fmt.Errorf("prisma status failed: %s", result.Error)
+	}
+
 	return status, nil
 }
+
+// schemaEnv sets the schema parameter of the DATABASE_URL
+func (a *PrismaAdapter) schemaEnv(service *migra.Service, tenant *migra.Tenant) map[string]string {
+	dsn := a.lookupEnv(service, tenant, "DATABASE_URL")
+	if dsn == "" {
+		return nil
+	}
+	return map[string]string{"DATABASE_URL": withQueryParam(dsn, "schema", tenant.Schema)}
+}
@@ -0,0 +1,110 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// RailsAdapter implements the Adapter interface for Rails (ActiveRecord)
+type RailsAdapter struct {
+	*BaseAdapter
+}
+
+// NewRailsAdapter creates a new Rails adapter
+func NewRailsAdapter() *RailsAdapter {
+	return &RailsAdapter{
+		BaseAdapter: NewBaseAdapter("rails"),
+	}
+}
+
+// Deploy runs Rails migrations
+func (a *RailsAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// Rails: bin/rails db:migrate
+	result, err := a.executeCommand(ctx, service, tenant, "bin/rails", "db:migrate")
+	if err != nil {
+		return result, fmt.Errorf("rails deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback rolls back Rails migrations
+func (a *RailsAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// Rails: bin/rails db:rollback STEP=N
+	result, err := a.executeCommand(ctx, service, tenant, "bin/rails", "db:rollback", fmt.Sprintf("STEP=%d", steps))
+	if err != nil {
+		return result, fmt.Errorf("rails rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for Rails
+func (a *RailsAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, service, tenant, "bin/rails", "db:migrate:status")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("rails status failed: %s", result.Error)
+	}
+
+	return parseRailsStatus(result.Output), nil
+}
+
+// parseRailsStatus parses `bin/rails db:migrate:status` output:
+//
+//	 Status   Migration ID    Migration Name
+//	--------------------------------------------------
+//	   up     20240101120000  Create users
+//	  down    20240215093000  Add email to users
+func parseRailsStatus(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !isDigits(fields[1]) {
+			continue
+		}
+
+		switch fields[0] {
+		case "up":
+			status.Applied = append(status.Applied, fields[1])
+		case "down":
+			status.Pending = append(status.Pending, fields[1])
+		}
+	}
+
+	return status
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
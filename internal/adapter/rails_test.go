@@ -0,0 +1,29 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRailsAdapter(t *testing.T) {
+	adapter := NewRailsAdapter()
+	assert.Equal(t, "rails", adapter.Name())
+}
+
+func TestParseRailsStatus(t *testing.T) {
+	output := `
+database: storefront_production
+
+ Status   Migration ID    Migration Name
+--------------------------------------------------
+   up     20240101120000  Create users
+   up     20240110081500  ********** NO FILE **********
+  down    20240215093000  Add email to users
+  down    20240301100000  Create orders
+`
+
+	status := parseRailsStatus(output)
+	assert.Equal(t, []string{"20240101120000", "20240110081500"}, status.Applied)
+	assert.Equal(t, []string{"20240215093000", "20240301100000"}, status.Pending)
+}
@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/migra/migra/pkg/migra"
 )
@@ -37,11 +38,46 @@ func (r *Registry) GetForService(service *migra.Service) (migra.Adapter, error)
 	return r.Get(service.Type)
 }
 
+// Has reports whether an adapter is registered under name
+func (r *Registry) Has(name string) bool {
+	_, ok := r.adapters[name]
+	return ok
+}
+
+// LoadPlugins registers the migra-adapter-<type> plugins found in dirs
+// and on PATH. Adapters that are already registered take precedence.
+// It returns the types that were loaded.
+func (r *Registry) LoadPlugins(dirs []string) []string {
+	loaded := make([]string, 0)
+	for pluginType, path := range migra.FindPlugins(dirs) {
+		if r.Has(pluginType) {
+			continue
+		}
+		r.Register(pluginType, NewPluginAdapter(pluginType, path))
+		loaded = append(loaded, pluginType)
+	}
+
+	sort.Strings(loaded)
+	return loaded
+}
+
 // NewDefaultRegistry creates a registry with all built-in adapters
 func NewDefaultRegistry() *Registry {
 	registry := NewRegistry()
 	registry.Register("django", NewDjangoAdapter())
 	registry.Register("laravel", NewLaravelAdapter())
 	registry.Register("prisma", NewPrismaAdapter())
+	registry.Register("alembic", NewAlembicAdapter())
+	registry.Register("rails", NewRailsAdapter())
+	registry.Register("golang-migrate", NewGolangMigrateAdapter())
+	registry.Register("goose", NewGooseAdapter())
+	registry.Register("flyway", NewFlywayAdapter())
+	registry.Register("liquibase", NewLiquibaseAdapter())
+	registry.Register("knex", NewKnexAdapter())
+	registry.Register("typeorm", NewTypeORMAdapter())
+	registry.Register("sequelize", NewSequelizeAdapter())
+	registry.Register("efcore", NewEFCoreAdapter())
+	registry.Register("dbmate", NewDbmateAdapter())
+	registry.Register("command", NewCommandAdapter())
 	return registry
 }
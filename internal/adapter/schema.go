@@ -0,0 +1,102 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// SchemaEnvVar holds the tenant schema in the environment of every
+// migration command of a schema_per_tenant run
+const SchemaEnvVar = "MIGRA_TENANT_SCHEMA"
+
+// schemaTargeter is implemented by adapters that point their tool at the
+// tenant schema through its own connection settings
+type schemaTargeter interface {
+	schemaEnv(service *migra.Service, tenant *migra.Tenant) map[string]string
+}
+
+// TargetSchema returns a copy of the tenant whose connection points the
+// adapter's tool at the tenant schema. Tenants without a schema are
+// returned as they are.
+func TargetSchema(adp migra.Adapter, service *migra.Service, tenant *migra.Tenant) *migra.Tenant {
+	if tenant == nil || tenant.Schema == "" {
+		return tenant
+	}
+
+	targeted := *tenant
+	targeted.Connection = make(map[string]string, len(tenant.Connection)+2)
+	for k, v := range tenant.Connection {
+		targeted.Connection[k] = v
+	}
+	targeted.Connection[SchemaEnvVar] = tenant.Schema
+
+	if t, ok := adp.(schemaTargeter); ok {
+		for k, v := range t.schemaEnv(service, tenant) {
+			targeted.Connection[k] = v
+		}
+	}
+	return &targeted
+}
+
+// plainIdentifier matches identifiers PostgreSQL reads as they are written
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// quoteIdentifier double-quotes a PostgreSQL identifier unless it is
+// lowercase and needs no quoting, so mixed-case schemas keep their case
+func quoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// CreateSchema creates the tenant schema if it does not exist, connecting
+// to PostgreSQL with the DATABASE_URL of the service and tenant
+func CreateSchema(ctx context.Context, service *migra.Service, tenant *migra.Tenant) error {
+	dsn := NewBaseAdapter("schema").lookupEnv(service, tenant, "DATABASE_URL")
+	if dsn == "" {
+		return fmt.Errorf("creating schema %s requires DATABASE_URL", tenant.Schema)
+	}
+
+	db, err := sql.Open("postgres", schemaDSN(dsn))
+	if err != nil {
+		return fmt.Errorf("failed to open database connection: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pq.QuoteIdentifier(tenant.Schema)); err != nil {
+		return fmt.Errorf("failed to create schema %s: %w", tenant.Schema, err)
+	}
+	return nil
+}
+
+// schemaDSN prepares a DATABASE_URL for lib/pq, which would send Prisma's
+// schema parameter to the server as a setting
+func schemaDSN(dsn string) string {
+	return withQueryParam(dsn, "schema", "")
+}
+
+// withQueryParam sets a query parameter of a connection URL, or removes it
+// when value is empty. Values that are not URLs are returned unchanged.
+func withQueryParam(dsn, key, value string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return dsn
+	}
+
+	query := u.Query()
+	if value == "" {
+		query.Del(key)
+	} else {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
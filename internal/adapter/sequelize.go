@@ -0,0 +1,96 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// SequelizeAdapter implements the Adapter interface for Sequelize
+type SequelizeAdapter struct {
+	*BaseAdapter
+}
+
+// NewSequelizeAdapter creates a new Sequelize adapter
+func NewSequelizeAdapter() *SequelizeAdapter {
+	return &SequelizeAdapter{
+		BaseAdapter: NewBaseAdapter("sequelize"),
+	}
+}
+
+// Deploy runs Sequelize migrations
+func (a *SequelizeAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// Sequelize: npx sequelize-cli db:migrate
+	result, err := a.executeCommand(ctx, service, tenant, "npx", "sequelize-cli", "db:migrate")
+	if err != nil {
+		return result, fmt.Errorf("sequelize deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback undoes Sequelize migrations. `db:migrate:undo` undoes the last
+// migration, so it is run once per step.
+func (a *SequelizeAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// Sequelize: npx sequelize-cli db:migrate:undo, once per step
+	result, err := a.repeatCommand(ctx, service, tenant, steps, "npx", "sequelize-cli", "db:migrate:undo")
+	if err != nil {
+		return result, fmt.Errorf("sequelize rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for Sequelize
+func (a *SequelizeAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, service, tenant, "npx", "sequelize-cli", "db:migrate:status")
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	if !result.Success {
+		return &migra.StatusResult{
+			LastError: result.Error,
+		}, fmt.Errorf("sequelize status failed: %s", result.Error)
+	}
+
+	return parseSequelizeStatus(result.Output), nil
+}
+
+// parseSequelizeStatus parses `sequelize-cli db:migrate:status` output:
+//
+//	up 20240108120000-create-users.js
+//	down 20240110090000-create-orders.js
+func parseSequelizeStatus(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		fields := strings.Fields(stripANSI(line))
+		if len(fields) != 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "up":
+			status.Applied = append(status.Applied, fields[1])
+		case "down":
+			status.Pending = append(status.Pending, fields[1])
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,28 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequelizeAdapter(t *testing.T) {
+	adapter := NewSequelizeAdapter()
+	assert.Equal(t, "sequelize", adapter.Name())
+}
+
+func TestParseSequelizeStatus(t *testing.T) {
+	output := `
+Sequelize CLI [Node: 20.10.0, CLI: 6.6.2, ORM: 6.35.2]
+
+Loaded configuration file "config/config.js".
+Using environment "production".
+up 20240108120000-create-users.js
+up 20240108120100-add-email.js
+down 20240110090000-create-orders.js
+`
+
+	status := parseSequelizeStatus(output)
+	assert.Equal(t, []string{"20240108120000-create-users.js", "20240108120100-add-email.js"}, status.Applied)
+	assert.Equal(t, []string{"20240110090000-create-orders.js"}, status.Pending)
+}
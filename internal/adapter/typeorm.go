@@ -0,0 +1,132 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// TypeORMAdapter implements the Adapter interface for TypeORM
+type TypeORMAdapter struct {
+	*BaseAdapter
+}
+
+// NewTypeORMAdapter creates a new TypeORM adapter
+func NewTypeORMAdapter() *TypeORMAdapter {
+	return &TypeORMAdapter{
+		BaseAdapter: NewBaseAdapter("typeorm"),
+	}
+}
+
+// typeormDataSources are the data source files looked up, in order
+var typeormDataSources = []string{
+	"data-source.ts",
+	"src/data-source.ts",
+	"data-source.js",
+	"src/data-source.js",
+}
+
+// Deploy runs TypeORM migrations
+func (a *TypeORMAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	// TypeORM: npx typeorm migration:run -d data-source
+	result, err := a.executeCommand(ctx, service, tenant, "npx", typeormArgs(service, "migration:run")...)
+	if err != nil {
+		return result, fmt.Errorf("typeorm deploy failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Rollback reverts TypeORM migrations. `migration:revert` undoes the last
+// migration, so it is run once per step.
+func (a *TypeORMAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	// TypeORM: npx typeorm migration:revert -d data-source, once per step
+	result, err := a.repeatCommand(ctx, service, tenant, steps, "npx", typeormArgs(service, "migration:revert")...)
+	if err != nil {
+		return result, fmt.Errorf("typeorm rollback failed: %w", err)
+	}
+
+	result.Output = a.sanitizeOutput(result.Output)
+	return result, nil
+}
+
+// Status returns the migration status for TypeORM
+func (a *TypeORMAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	result, err := a.executeCommand(ctx, service, tenant, "npx", typeormArgs(service, "migration:show")...)
+	if err != nil {
+		return &migra.StatusResult{
+			LastError: err.Error(),
+		}, err
+	}
+
+	status := parseTypeORMShow(result.Output)
+
+	// Some TypeORM versions exit non-zero when migrations are pending,
+	// so only treat a failure with nothing pending as an error
+	if !result.Success && len(status.Pending) == 0 {
+		status.LastError = result.Error
+		return status, fmt.Errorf("typeorm status failed: %s", result.Error)
+	}
+
+	return status, nil
+}
+
+// typeormArgs builds the npx arguments for a TypeORM command. A TypeScript
+// data source runs through typeorm-ts-node-commonjs; without a data source
+// file TypeORM falls back to its ormconfig.
+func typeormArgs(service *migra.Service, command string) []string {
+	for _, file := range typeormDataSources {
+		if _, err := os.Stat(filepath.Join(service.WorkingDir, file)); err != nil {
+			continue
+		}
+		if filepath.Ext(file) == ".ts" {
+			return []string{"typeorm-ts-node-commonjs", command, "-d", file}
+		}
+		return []string{"typeorm", command, "-d", file}
+	}
+
+	return []string{"typeorm", command}
+}
+
+// parseTypeORMShow parses `typeorm migration:show` output:
+//
+//	[X] 1 CreateUsers1704715200000
+//	[ ] AddEmail1704801600000
+func parseTypeORMShow(output string) *migra.StatusResult {
+	status := &migra.StatusResult{
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(stripANSI(line))
+
+		var target *[]string
+		switch {
+		case strings.HasPrefix(line, "[X]"):
+			target = &status.Applied
+		case strings.HasPrefix(line, "[ ]"):
+			target = &status.Pending
+		default:
+			continue
+		}
+
+		fields := strings.Fields(line[3:])
+		if len(fields) == 0 {
+			continue
+		}
+		*target = append(*target, fields[len(fields)-1])
+	}
+
+	return status
+}
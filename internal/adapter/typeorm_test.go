@@ -0,0 +1,50 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeORMArgs(t *testing.T) {
+	adapter := NewTypeORMAdapter()
+	assert.Equal(t, "typeorm", adapter.Name())
+
+	t.Run("ormconfig", func(t *testing.T) {
+		service := &migra.Service{WorkingDir: t.TempDir()}
+		assert.Equal(t, []string{"typeorm", "migration:run"}, typeormArgs(service, "migration:run"))
+	})
+
+	t.Run("typescript data source", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "data-source.ts"), []byte(""), 0644))
+
+		service := &migra.Service{WorkingDir: dir}
+		assert.Equal(t, []string{"typeorm-ts-node-commonjs", "migration:show", "-d", "src/data-source.ts"}, typeormArgs(service, "migration:show"))
+	})
+
+	t.Run("javascript data source", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "data-source.js"), []byte(""), 0644))
+
+		service := &migra.Service{WorkingDir: dir}
+		assert.Equal(t, []string{"typeorm", "migration:revert", "-d", "data-source.js"}, typeormArgs(service, "migration:revert"))
+	})
+}
+
+func TestParseTypeORMShow(t *testing.T) {
+	output := `query: SELECT * FROM "migrations" "migrations" ORDER BY "id" DESC
+ [X] 1 CreateUsers1704715200000
+ [X] 2 AddEmail1704801600000
+ [ ] CreateOrders1704888000000
+`
+
+	status := parseTypeORMShow(output)
+	assert.Equal(t, []string{"CreateUsers1704715200000", "AddEmail1704801600000"}, status.Applied)
+	assert.Equal(t, []string{"CreateOrders1704888000000"}, status.Pending)
+}
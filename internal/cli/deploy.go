@@ -2,18 +2,16 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"github.com/migra/migra/internal/adapter"
 	"github.com/migra/migra/internal/config"
 	"github.com/migra/migra/internal/engine"
 	"github.com/migra/migra/internal/logger"
-	"github.com/migra/migra/internal/state"
-	"github.com/migra/migra/pkg/migra"
+	"github.com/migra/migra/pkg/orchestrator"
 	"github.com/spf13/cobra"
 )
 
@@ -21,6 +19,7 @@ var (
 	deployServiceFilter string
 	deployDryRun        bool
 	deployParallel      bool
+	deployResume        string
 )
 
 // deployCmd represents the deploy command
@@ -38,58 +37,33 @@ func init() {
 	deployCmd.Flags().StringVar(&deployServiceFilter, "service", "", "filter by service name")
 	deployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "dry run without executing migrations")
 	deployCmd.Flags().BoolVar(&deployParallel, "parallel", false, "override execution strategy to use parallel")
+	deployCmd.Flags().StringVar(&deployResume, "resume", "", "resume a failed run, skipping services that already succeeded in it")
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
-	start := time.Now()
-
 	// Load configuration
 	cfg, err := config.LoadFromFile(cfgFile)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Validate configuration
-	if err := config.Validate(cfg); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
-	}
-
 	// Setup logger
 	logLevel := logger.ParseLevel(cfg.Logging.Level)
-	log := logger.NewLogger(cfg.Logging.Format, logLevel, verbose, quiet)
+	log := logger.NewLogger(cfg.Logging.Format, logLevel, verbose, quiet || jsonOutput)
 
 	log.Info("Starting migration deployment")
 
-	// Setup state manager
-	workDir, _ := os.Getwd()
-	stateManager := state.NewManager(workDir)
-	if err := stateManager.Load(); err != nil {
-		log.Warn("Failed to load state, starting fresh", logger.F("error", err.Error()))
-	}
-
-	// Setup adapter registry
-	registry := adapter.NewDefaultRegistry()
-
-	// Filter services if needed
-	services := cfg.Services
-	if deployServiceFilter != "" {
-		filtered := make([]migra.Service, 0)
-		for _, svc := range cfg.Services {
-			if svc.Name == deployServiceFilter {
-				filtered = append(filtered, svc)
-			}
-		}
-		if len(filtered) == 0 {
-			return fmt.Errorf("service '%s' not found", deployServiceFilter)
-		}
-		services = filtered
-		log.Info(fmt.Sprintf("Filtered to service: %s", deployServiceFilter))
+	opts := orchestrator.Options{
+		Services: selectedServices(deployServiceFilter),
+		DryRun:   deployDryRun,
+		Quiet:    jsonOutput,
 	}
-
-	// Determine execution strategy
-	strategy := cfg.Execution.Strategy
 	if deployParallel {
-		strategy = config.StrategyParallel
+		opts.Strategy = orchestrator.StrategyParallel
+	}
+	orch, err := newOrchestrator(cfg, opts)
+	if err != nil {
+		return err
 	}
 
 	// Setup context with cancellation
@@ -105,36 +79,25 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	// Create execution engine
-	var eng engine.Engine
-	if strategy == config.StrategyParallel {
-		parallelLimit := cfg.Execution.ParallelLimit
-		if parallelLimit == 0 {
-			parallelLimit = cfg.ParallelLimit
-		}
-		if parallelLimit == 0 {
-			parallelLimit = config.DefaultParallelLimit
-		}
-		log.Info(fmt.Sprintf("Using parallel execution (limit: %d)", parallelLimit))
-		eng = engine.NewParallelEngine(registry, stateManager, log, cfg.Execution.StopOnFailure, deployDryRun, parallelLimit)
+	// Execute migrations
+	var result *orchestrator.Result
+	if deployResume != "" {
+		result, err = orch.ResumeDeploy(ctx, deployResume)
 	} else {
-		log.Info("Using sequential execution")
-		eng = engine.NewSequentialEngine(registry, stateManager, log, cfg.Execution.StopOnFailure, deployDryRun)
+		result, err = orch.Deploy(ctx)
 	}
-
-	// Execute migrations
-	log.Info(fmt.Sprintf("Executing migrations for %d service(s)", len(services)))
-	results, err := eng.Execute(ctx, services, migra.OperationDeploy)
 	if err != nil {
-		return fmt.Errorf("execution failed: %w", err)
+		return lockHint(err)
 	}
 
 	// Summarize results
-	summary := engine.SummarizeResults(results, time.Since(start))
+	summary := engine.SummarizeResults(result.Services, result.Duration)
 
 	// Print summary
 	if jsonOutput {
-		printJSONSummary(summary)
+		if err := printJSONSummary(summary); err != nil {
+			return err
+		}
 	} else {
 		printConsoleSummary(summary, log)
 	}
@@ -156,22 +119,57 @@ func printConsoleSummary(summary *engine.Result, log logger.Logger) {
 	fmt.Printf("Total Services:  %d\n", len(summary.Services))
 	fmt.Printf("Successful:      %d\n", summary.TotalSuccess)
 	fmt.Printf("Failed:          %d\n", summary.TotalFailure)
+	if summary.TotalTimeout > 0 {
+		fmt.Printf("Timed Out:       %d\n", summary.TotalTimeout)
+	}
+	if summary.TotalSkipped > 0 {
+		fmt.Printf("Skipped:         %d\n", summary.TotalSkipped)
+	}
+	if summary.TotalResumed > 0 {
+		fmt.Printf("Resumed:         %d (already succeeded)\n", summary.TotalResumed)
+	}
+	if summary.TotalRetried > 0 {
+		fmt.Printf("Retried:         %d\n", summary.TotalRetried)
+	}
 	fmt.Printf("Total Duration:  %s\n", summary.Duration)
 	fmt.Println(separator)
 
 	if summary.TotalFailure > 0 {
 		fmt.Println("\nFailed Services:")
 		for _, svc := range summary.Services {
-			if !svc.Success {
+			if !svc.Success && !svc.Skipped {
+				fmt.Printf("  - %s: %s\n", svc.ServiceName, svc.Error)
+			}
+		}
+	}
+
+	if summary.TotalSkipped > 0 {
+		fmt.Println("\nSkipped Services:")
+		for _, svc := range summary.Services {
+			if svc.Skipped {
 				fmt.Printf("  - %s: %s\n", svc.ServiceName, svc.Error)
 			}
 		}
 	}
 }
 
-func printJSONSummary(summary *engine.Result) {
-	// This would encode the summary as JSON
-	fmt.Printf(`{"total":%d,"success":%d,"failure":%d,"duration":"%s"}`, 
-		len(summary.Services), summary.TotalSuccess, summary.TotalFailure, summary.Duration)
-	fmt.Println()
+// printJSONSummary prints the totals and the result of every service,
+// including the attempts of retried services
+func printJSONSummary(summary *engine.Result) error {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"total":     len(summary.Services),
+		"success":   summary.TotalSuccess,
+		"failure":   summary.TotalFailure,
+		"skipped":   summary.TotalSkipped,
+		"timed_out": summary.TotalTimeout,
+		"retried":   summary.TotalRetried,
+		"resumed":   summary.TotalResumed,
+		"duration":  summary.Duration.String(),
+		"services":  summary.Services,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
 }
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/migra/migra/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyOperation string
+	historyService   string
+	historyTenant    string
+	historyUser      string
+	historySince     string
+	historyFailed    bool
+	historyLimit     int
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List previous runs",
+	Long: `List runs recorded in the execution history (.migra/history.jsonl),
+newest first. Use "migra history show <run-id>" for the details of a run.`,
+	RunE: runHistory,
+}
+
+// historyShowCmd represents the history show command
+var historyShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Show the details of a run",
+	Long:  `Show every service and tenant touched by a run, with results and output.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runHistoryShow,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+
+	historyCmd.Flags().StringVar(&historyOperation, "operation", "", "filter by operation (deploy, rollback)")
+	historyCmd.Flags().StringVar(&historyService, "service", "", "filter by service name")
+	historyCmd.Flags().StringVar(&historyTenant, "tenant", "", "filter by tenant ID")
+	historyCmd.Flags().StringVar(&historyUser, "user", "", "filter by user")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "only runs since a date (2006-01-02) or duration ago (24h, 7d)")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "only failed runs")
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "maximum number of runs to list (0 for all)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	filter := state.HistoryFilter{
+		Operation:  historyOperation,
+		Service:    historyService,
+		Tenant:     historyTenant,
+		User:       historyUser,
+		FailedOnly: historyFailed,
+		Limit:      historyLimit,
+	}
+
+	if historySince != "" {
+		since, err := parseSince(historySince)
+		if err != nil {
+			return err
+		}
+		filter.Since = since
+	}
+
+	workDir, _ := os.Getwd()
+	records, err := state.NewHistory(workDir).List(filter)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No runs recorded")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "RUN ID\tSTARTED\tOPERATION\tUSER\tRESULT\tSERVICES\tTENANTS\tDURATION")
+	fmt.Fprintln(w, "------\t-------\t---------\t----\t------\t--------\t-------\t--------")
+
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n",
+			r.ID,
+			r.StartedAt.Format("2006-01-02 15:04:05"),
+			r.Operation,
+			r.User,
+			runResult(r.Success),
+			len(r.Services),
+			len(r.Tenants),
+			r.Duration.Round(time.Millisecond),
+		)
+	}
+
+	w.Flush()
+	return nil
+}
+
+func runHistoryShow(cmd *cobra.Command, args []string) error {
+	workDir, _ := os.Getwd()
+	record, err := state.NewHistory(workDir).Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode run: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	separator := "============================================================"
+	fmt.Println(separator)
+	fmt.Printf("RUN %s\n", record.ID)
+	fmt.Println(separator)
+	fmt.Printf("Operation:   %s\n", record.Operation)
+	if record.ResumedFrom != "" {
+		fmt.Printf("Resumes:     %s\n", record.ResumedFrom)
+	}
+	fmt.Printf("Result:      %s\n", runResult(record.Success))
+	fmt.Printf("User:        %s\n", record.User)
+	fmt.Printf("Host:        %s\n", record.Host)
+	if record.GitCommit != "" {
+		fmt.Printf("Git Commit:  %s\n", record.GitCommit)
+	}
+	fmt.Printf("Started:     %s\n", record.StartedAt.Format(time.RFC3339))
+	fmt.Printf("Duration:    %s\n", record.Duration.Round(time.Millisecond))
+	fmt.Printf("Services:    %s\n", strings.Join(record.Services, ", "))
+	if len(record.Tenants) > 0 {
+		fmt.Printf("Tenants:     %d\n", len(record.Tenants))
+	}
+	fmt.Println(separator)
+
+	for _, item := range record.Items {
+		name := item.Service
+		if item.Tenant != "" {
+			name = fmt.Sprintf("%s (tenant: %s)", item.Service, item.Tenant)
+		}
+
+		result := runResult(item.Success)
+		if item.Skipped {
+			result = "skipped"
+		} else if item.TimedOut {
+			result = "timed out"
+		} else if item.Resumed {
+			result = "already succeeded"
+		}
+		fmt.Printf("\n%s: %s (%s)\n", name, result, item.Duration.Round(time.Millisecond))
+
+		if len(item.Attempts) > 1 {
+			for _, attempt := range item.Attempts {
+				outcome := runResult(attempt.Success)
+				if attempt.TimedOut {
+					outcome = "timed out"
+				}
+				fmt.Printf("  attempt %d: %s (%s)", attempt.Number, outcome, attempt.Duration.Round(time.Millisecond))
+				if attempt.Error != "" {
+					fmt.Printf(": %s", attempt.Error)
+				}
+				fmt.Println()
+			}
+		}
+		if item.Error != "" {
+			fmt.Printf("  error: %s\n", item.Error)
+		}
+		if item.Output != "" {
+			for _, line := range strings.Split(strings.TrimRight(item.Output, "\n"), "\n") {
+				fmt.Printf("  | %s\n", line)
+			}
+		}
+		if item.LogFile != "" {
+			fmt.Printf("  full output: %s\n", item.LogFile)
+		}
+	}
+
+	return nil
+}
+
+// runResult formats a run or item result
+func runResult(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
+
+// parseSince parses a date (2006-01-02), an RFC3339 timestamp or a
+// duration ago such as 24h or 7d
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value '%s' (use 2006-01-02, 24h or 7d)", value)
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/lock"
+	"github.com/migra/migra/pkg/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Inspect and manage the run lock",
+	Long: `Commands for the run lock that prevents concurrent deploys and
+rollbacks against the same databases.`,
+}
+
+// lockStatusCmd represents the lock status command
+var lockStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who holds the run lock",
+	RunE:  runLockStatus,
+}
+
+// lockForceUnlockCmd represents the lock force-unlock command
+var lockForceUnlockCmd = &cobra.Command{
+	Use:   "force-unlock",
+	Short: "Break the run lock",
+	Long: `Break the run lock regardless of who holds it. Only use this when the
+holder is known to be dead; a run that is still alive keeps running.`,
+	RunE: runLockForceUnlock,
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+	lockCmd.AddCommand(lockStatusCmd)
+	lockCmd.AddCommand(lockForceUnlockCmd)
+}
+
+func runLockStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadFromFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	backend, err := configuredLockBackend(cfg)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	holder, err := backend.Status(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to read lock status: %w", err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"backend": backend.Name(),
+			"locked":  holder != nil,
+			"holder":  holder,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode lock status: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Backend:     %s\n", backend.Name())
+	if holder == nil {
+		fmt.Println("Status:      unlocked")
+		return nil
+	}
+
+	fmt.Println("Status:      locked")
+	fmt.Printf("Holder:      %s\n", holder.Holder)
+	if holder.Operation != "" {
+		fmt.Printf("Operation:   %s\n", holder.Operation)
+		fmt.Printf("PID:         %d\n", holder.PID)
+		fmt.Printf("Acquired:    %s\n", holder.AcquiredAt.Format(time.RFC3339))
+		fmt.Printf("Heartbeat:   %s\n", holder.HeartbeatAt.Format(time.RFC3339))
+		fmt.Printf("Expires:     %s\n", holder.ExpiresAt.Format(time.RFC3339))
+	}
+	if holder.Expired() {
+		fmt.Println("\nThe holder has missed its heartbeat; the next run will take over the lock.")
+	}
+
+	return nil
+}
+
+func runLockForceUnlock(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadFromFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	backend, err := configuredLockBackend(cfg)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	ctx := context.Background()
+	holder, err := backend.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read lock status: %w", err)
+	}
+
+	if err := backend.ForceUnlock(ctx); err != nil {
+		return fmt.Errorf("failed to force unlock: %w", err)
+	}
+
+	if holder == nil {
+		fmt.Println("Lock was not held")
+	} else {
+		fmt.Printf("Lock held by %s has been released\n", holder.Holder)
+	}
+	return nil
+}
+
+// configuredLockBackend returns the lock backend, failing if locking is disabled
+func configuredLockBackend(cfg *config.Config) (lock.Backend, error) {
+	if cfg.Lock == nil || !cfg.Lock.Enabled {
+		return nil, fmt.Errorf("run locking is not enabled in configuration")
+	}
+	if err := config.Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	workDir, _ := os.Getwd()
+	return lock.NewBackend(cfg.Lock, workDir)
+}
+
+// lockHint points at 'migra lock status' when another run holds the run
+// lock
+func lockHint(err error) error {
+	if errors.Is(err, orchestrator.ErrLocked) {
+		return fmt.Errorf("%w (use 'migra lock status' to inspect)", err)
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/tenant"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/spf13/cobra"
+)
+
+// exitCodePending is returned by plan when migrations are pending
+const exitCodePending = 2
+
+var (
+	planServiceFilter string
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show pending migrations for every service",
+	Long: `Query the migration status of every configured service (and every
+tenant when tenancy is enabled) and list the migrations a deploy would apply.
+
+Exit codes: 0 when nothing is pending, 2 when migrations are pending,
+1 when status could not be determined.`,
+	RunE: runPlan,
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	planCmd.Flags().StringVar(&planServiceFilter, "service", "", "filter by service name")
+	addTenantSourceFlags(planCmd)
+}
+
+// planEntry holds the pending migrations for a service, optionally for a tenant
+type planEntry struct {
+	Service string   `json:"service"`
+	Tenant  string   `json:"tenant,omitempty"`
+	Applied int      `json:"applied"`
+	Pending []string `json:"pending"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// planReport is the complete output of the plan command
+type planReport struct {
+	Entries      []planEntry `json:"entries"`
+	TotalPending int         `json:"total_pending"`
+	TotalErrors  int         `json:"total_errors"`
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadFromFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Validate configuration
+	if err := config.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Setup logger
+	logLevel := logger.ParseLevel(cfg.Logging.Level)
+	log := logger.NewLogger(cfg.Logging.Format, logLevel, verbose, quiet || jsonOutput)
+
+	// Filter services if needed
+	services := cfg.Services
+	if planServiceFilter != "" {
+		filtered := make([]migra.Service, 0)
+		for _, svc := range cfg.Services {
+			if svc.Name == planServiceFilter {
+				filtered = append(filtered, svc)
+			}
+		}
+		if len(filtered) == 0 {
+			return fmt.Errorf("service '%s' not found", planServiceFilter)
+		}
+		services = filtered
+	}
+
+	// Setup adapter registry
+	registry := newRegistry(cfg, log)
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle interrupt signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		log.Warn("Received interrupt signal, stopping...")
+		cancel()
+	}()
+
+	// Load tenants when tenancy is enabled
+	tenants := []*migra.Tenant{nil}
+	if cfg.Tenancy != nil && cfg.Tenancy.Enabled {
+		if err := applyTenantSourceFlags(cfg); err != nil {
+			return err
+		}
+		source, err := tenant.NewSource(cfg.Tenancy)
+		if err != nil {
+			return err
+		}
+		tenants, err = source.LoadTenants(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load tenants: %w", err)
+		}
+		log.Info(fmt.Sprintf("Found %d tenants", len(tenants)))
+	}
+
+	report := &planReport{
+		Entries: make([]planEntry, 0, len(services)*len(tenants)),
+	}
+
+	for _, tnt := range tenants {
+		for i := range services {
+			entry := planService(ctx, registry, &services[i], tnt)
+			report.TotalPending += len(entry.Pending)
+			if entry.Error != "" {
+				report.TotalErrors++
+			}
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printConsolePlan(report)
+	}
+
+	if report.TotalErrors > 0 {
+		return fmt.Errorf("failed to determine status for %d service(s)", report.TotalErrors)
+	}
+	if report.TotalPending > 0 {
+		return &exitError{code: exitCodePending}
+	}
+	return nil
+}
+
+// planService collects the pending migrations for a single service and tenant
+func planService(ctx context.Context, registry *adapter.Registry, service *migra.Service, tnt *migra.Tenant) planEntry {
+	entry := planEntry{
+		Service: service.Name,
+		Pending: make([]string, 0),
+	}
+	if tnt != nil {
+		entry.Tenant = tnt.ID
+	}
+
+	adp, err := registry.GetForService(service)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	status, err := adp.Status(ctx, service, tnt)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	entry.Applied = len(status.Applied)
+	entry.Pending = append(entry.Pending, status.Pending...)
+	return entry
+}
+
+func printConsolePlan(report *planReport) {
+	separator := "============================================================"
+	fmt.Println(separator)
+	fmt.Println("MIGRATION PLAN")
+	fmt.Println(separator)
+
+	for _, entry := range report.Entries {
+		name := entry.Service
+		if entry.Tenant != "" {
+			name = fmt.Sprintf("%s (tenant: %s)", entry.Service, entry.Tenant)
+		}
+
+		switch {
+		case entry.Error != "":
+			fmt.Printf("\n%s: error: %s\n", name, entry.Error)
+		case len(entry.Pending) == 0:
+			fmt.Printf("\n%s: up to date (%d applied)\n", name, entry.Applied)
+		default:
+			fmt.Printf("\n%s: %d pending\n", name, len(entry.Pending))
+			for _, migration := range entry.Pending {
+				fmt.Printf("  [ ] %s\n", migration)
+			}
+		}
+	}
+
+	fmt.Println("\n" + separator)
+	fmt.Printf("Total Pending:   %d\n", report.TotalPending)
+	if report.TotalErrors > 0 {
+		fmt.Printf("Errors:          %d\n", report.TotalErrors)
+	}
+	fmt.Println(separator)
+}
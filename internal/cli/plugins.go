@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/spf13/cobra"
+)
+
+// pluginsCmd represents the plugins command
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List external adapter plugins",
+	Long: `List the migra-adapter-<type> executables found in the configured
+plugin directories and on PATH. Built-in adapters take precedence over
+plugins of the same type.`,
+	RunE: runPlugins,
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+}
+
+func runPlugins(cmd *cobra.Command, args []string) error {
+	var dirs []string
+	if cfg, err := config.LoadFromFile(cfgFile); err == nil {
+		dirs = cfg.PluginDirs()
+	}
+
+	plugins := migra.FindPlugins(dirs)
+	builtin := adapter.NewDefaultRegistry()
+
+	types := make([]string, 0, len(plugins))
+	for pluginType := range plugins {
+		types = append(types, pluginType)
+	}
+	sort.Strings(types)
+
+	if jsonOutput {
+		entries := make([]map[string]interface{}, 0, len(types))
+		for _, pluginType := range types {
+			entries = append(entries, map[string]interface{}{
+				"type":     pluginType,
+				"path":     plugins[pluginType],
+				"shadowed": builtin.Has(pluginType),
+			})
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plugins: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(types) == 0 {
+		fmt.Printf("No %s<type> plugins found\n", migra.PluginPrefix)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tPATH\t")
+	for _, pluginType := range types {
+		note := ""
+		if builtin.Has(pluginType) {
+			note = "(shadowed by built-in adapter)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", pluginType, plugins[pluginType], note)
+	}
+	return w.Flush()
+}
+
+// newRegistry creates the adapter registry with the built-in adapters and
+// any external adapter plugins
+func newRegistry(cfg *config.Config, log logger.Logger) *adapter.Registry {
+	registry := adapter.NewDefaultRegistry()
+	for _, pluginType := range registry.LoadPlugins(cfg.PluginDirs()) {
+		log.Debug("Loaded adapter plugin", logger.F("type", pluginType))
+	}
+	return registry
+}
@@ -7,12 +7,9 @@ import (
 	"os/signal"
 	"syscall"
 
-	"github.com/migra/migra/internal/adapter"
 	"github.com/migra/migra/internal/config"
-	"github.com/migra/migra/internal/engine"
 	"github.com/migra/migra/internal/logger"
-	"github.com/migra/migra/internal/state"
-	"github.com/migra/migra/pkg/migra"
+	"github.com/migra/migra/pkg/orchestrator"
 	"github.com/spf13/cobra"
 )
 
@@ -20,14 +17,18 @@ var (
 	rollbackService string
 	rollbackSteps   int
 	rollbackTenant  string
+	rollbackTo      string
 )
 
 // rollbackCmd represents the rollback command
 var rollbackCmd = &cobra.Command{
 	Use:   "rollback",
 	Short: "Rollback migrations",
-	Long:  `Rollback the most recent migration for specified services.`,
-	RunE:  runRollback,
+	Long: `Rollback the most recent migrations for the specified service.
+
+Use --steps to roll back a number of migrations, or --to to roll back to a
+named migration (for Django: app.migration, or app.zero to unapply an app).`,
+	RunE: runRollback,
 }
 
 func init() {
@@ -36,10 +37,18 @@ func init() {
 	rollbackCmd.Flags().StringVar(&rollbackService, "service", "", "service name (required)")
 	rollbackCmd.Flags().IntVar(&rollbackSteps, "steps", 1, "number of steps to rollback")
 	rollbackCmd.Flags().StringVar(&rollbackTenant, "tenant", "", "tenant ID (for multi-tenant)")
+	rollbackCmd.Flags().StringVar(&rollbackTo, "to", "", "target migration to roll back to")
 	rollbackCmd.MarkFlagRequired("service")
 }
 
 func runRollback(cmd *cobra.Command, args []string) error {
+	if rollbackTo != "" && cmd.Flags().Changed("steps") {
+		return fmt.Errorf("--to and --steps cannot be used together")
+	}
+	if rollbackTo == "" && rollbackSteps <= 0 {
+		return fmt.Errorf("--steps must be positive")
+	}
+
 	// Load configuration
 	cfg, err := config.LoadFromFile(cfgFile)
 	if err != nil {
@@ -50,31 +59,17 @@ func runRollback(cmd *cobra.Command, args []string) error {
 	logLevel := logger.ParseLevel(cfg.Logging.Level)
 	log := logger.NewLogger(cfg.Logging.Format, logLevel, verbose, quiet)
 
-	log.Info(fmt.Sprintf("Rolling back service: %s", rollbackService))
-
-	// Find service
-	var targetService *migra.Service
-	for _, svc := range cfg.Services {
-		if svc.Name == rollbackService {
-			targetService = &svc
-			break
-		}
+	if rollbackTo != "" {
+		log.Info(fmt.Sprintf("Rolling back service %s to %s", rollbackService, rollbackTo))
+	} else {
+		log.Info(fmt.Sprintf("Rolling back service %s by %d step(s)", rollbackService, rollbackSteps))
 	}
 
-	if targetService == nil {
-		return fmt.Errorf("service '%s' not found", rollbackService)
-	}
-
-	// Setup state manager
-	workDir, _ := os.Getwd()
-	stateManager := state.NewManager(workDir)
-	if err := stateManager.Load(); err != nil {
-		log.Warn("Failed to load state", logger.F("error", err.Error()))
+	orch, err := newOrchestrator(cfg, orchestrator.Options{Services: selectedServices(rollbackService)})
+	if err != nil {
+		return err
 	}
 
-	// Setup adapter registry
-	registry := adapter.NewDefaultRegistry()
-
 	// Setup context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -88,17 +83,14 @@ func runRollback(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	// Create execution engine
-	eng := engine.NewSequentialEngine(registry, stateManager, log, true, false)
-
 	// Execute rollback
-	results, err := eng.Execute(ctx, []migra.Service{*targetService}, migra.OperationRollback)
+	result, err := orch.Rollback(ctx, orchestrator.RollbackOptions{Steps: rollbackSteps, Target: rollbackTo})
 	if err != nil {
-		return fmt.Errorf("rollback failed: %w", err)
+		return lockHint(err)
 	}
 
-	if len(results) > 0 && !results[0].Success {
-		return fmt.Errorf("rollback failed: %s", results[0].Error)
+	if len(result.Services) > 0 && !result.Services[0].Success {
+		return fmt.Errorf("rollback failed: %s", result.Services[0].Error)
 	}
 
 	log.Info("Rollback completed successfully")
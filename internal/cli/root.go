@@ -1,17 +1,20 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/migra/migra/internal/config"
 	"github.com/migra/migra/pkg/migra"
+	"github.com/migra/migra/pkg/orchestrator"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile string
-	verbose bool
-	quiet   bool
+	cfgFile    string
+	verbose    bool
+	quiet      bool
 	jsonOutput bool
 )
 
@@ -28,9 +31,29 @@ including Django, Laravel, and Prisma.`,
 	SilenceErrors: true,
 }
 
+// exitError carries a specific process exit code out of a command
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
 // Execute runs the root command
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintln(os.Stderr, exitErr.err)
+			}
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -44,3 +67,23 @@ func init() {
 
 	rootCmd.Version = fmt.Sprintf("%s (built: %s)", migra.Version, migra.BuildTime)
 }
+
+// newOrchestrator creates the orchestrator running a command in the
+// current directory, logging to stdout with the global output flags
+func newOrchestrator(cfg *config.Config, opts orchestrator.Options) (*orchestrator.Orchestrator, error) {
+	workDir, _ := os.Getwd()
+	opts.WorkDir = workDir
+	opts.LogOutput = os.Stdout
+	opts.Verbose = verbose
+	opts.Quiet = opts.Quiet || quiet
+	return orchestrator.New(cfg, opts)
+}
+
+// selectedServices returns the services selected by a --service flag,
+// nil for all services
+func selectedServices(name string) []string {
+	if name == "" {
+		return nil
+	}
+	return []string{name}
+}
@@ -1,25 +1,40 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 
-	"github.com/migra/migra/internal/adapter"
 	"github.com/migra/migra/internal/config"
 	"github.com/migra/migra/internal/logger"
-	"github.com/migra/migra/internal/state"
 	"github.com/migra/migra/internal/tenant"
 	"github.com/migra/migra/pkg/migra"
+	"github.com/migra/migra/pkg/orchestrator"
 	"github.com/spf13/cobra"
 )
 
 var (
 	tenantsMaxParallel   int
 	tenantsStopOnFailure bool
+	tenantsResume        string
+	tenantsDryRun        bool
+	tenantsYes           bool
+	tenantsIDs           []string
+	tenantsFile          string
+	tenantsExclude       []string
+	tenantsSelectors     []string
+	tenantsEnv           string
+	tenantsService       string
+	tenantsSourceType    string
+	tenantsSourceEnv     string
+	tenantsSourceFile    string
+	tenantsSourceCommand string
+	tenantsSourceDir     string
 )
 
 // tenantsCmd represents the tenants command
@@ -43,6 +58,11 @@ func init() {
 
 	tenantsDeployCmd.Flags().IntVar(&tenantsMaxParallel, "max-parallel", 0, "maximum parallel tenant executions")
 	tenantsDeployCmd.Flags().BoolVar(&tenantsStopOnFailure, "stop-on-failure", false, "stop on first tenant failure")
+	tenantsDeployCmd.Flags().BoolVar(&tenantsDryRun, "dry-run", false, "show the tenants and rollout waves without executing migrations")
+	tenantsDeployCmd.Flags().BoolVar(&tenantsYes, "yes", false, "start every rollout wave without asking for confirmation")
+	tenantsDeployCmd.Flags().StringVar(&tenantsResume, "resume", "", "resume a failed run, skipping tenant services that already succeeded in it")
+	addTenantFilterFlags(tenantsDeployCmd, "deploy")
+	addTenantSourceFlags(tenantsDeployCmd)
 }
 
 func runTenantsDeploy(cmd *cobra.Command, args []string) error {
@@ -63,51 +83,11 @@ func runTenantsDeploy(cmd *cobra.Command, args []string) error {
 
 	log.Info("Starting multi-tenant migration deployment")
 
-	// Setup state manager
-	workDir, _ := os.Getwd()
-	stateManager := state.NewManager(workDir)
-	if err := stateManager.Load(); err != nil {
-		log.Warn("Failed to load state, starting fresh", logger.F("error", err.Error()))
-	}
-
-	// Setup adapter registry
-	registry := adapter.NewDefaultRegistry()
-
-	// Create tenant source
-	var source tenant.Source
-	switch cfg.Tenancy.TenantSource {
-	case config.TenantSourceEnv:
-		source = tenant.NewEnvSource("MIGRA_TENANTS")
-	case config.TenantSourceFile:
-		// Assume file path is in environment or default
-		filePath := os.Getenv("MIGRA_TENANTS_FILE")
-		if filePath == "" {
-			filePath = "tenants.json"
-		}
-		source = tenant.NewFileSource(filePath)
-	case config.TenantSourceCommand:
-		command := os.Getenv("MIGRA_TENANTS_COMMAND")
-		if command == "" {
-			return fmt.Errorf("MIGRA_TENANTS_COMMAND environment variable not set")
-		}
-		parts := strings.Split(command, " ")
-		source = tenant.NewCommandSource(parts[0], parts[1:]...)
-	default:
-		return fmt.Errorf("unsupported tenant source: %s", cfg.Tenancy.TenantSource)
-	}
-
-	// Determine max parallel
-	maxParallel := tenantsMaxParallel
-	if maxParallel == 0 {
-		maxParallel = cfg.Tenancy.MaxParallel
+	orch, err := newTenantOrchestrator(cfg)
+	if err != nil {
+		return err
 	}
 
-	// Determine stop on failure
-	stopOnFailure := tenantsStopOnFailure || cfg.Tenancy.StopOnFailure
-
-	// Create tenant executor
-	executor := tenant.NewExecutor(source, registry, stateManager, log, stopOnFailure, maxParallel)
-
 	// Setup context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -121,17 +101,204 @@ func runTenantsDeploy(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
+	if tenantsDryRun {
+		plan, err := orch.RolloutPlan(ctx)
+		if err != nil {
+			return err
+		}
+		return printWavePlan(plan, cfg.Tenancy.Rollout)
+	}
+
 	// Execute tenant migrations
-	results, err := executor.Execute(ctx, cfg.Services, migra.OperationDeploy)
+	var result *orchestrator.TenantsResult
+	if tenantsResume != "" {
+		result, err = orch.ResumeTenants(ctx, tenantsResume)
+	} else {
+		result, err = orch.DeployTenants(ctx)
+	}
 	if err != nil {
-		return fmt.Errorf("tenant execution failed: %w", err)
+		return lockHint(err)
 	}
 
 	// Print summary
+	failureCount, skippedCount := printTenantSummary("TENANT MIGRATION SUMMARY", result.Tenants)
+	if failureCount > 0 || skippedCount > 0 {
+		return fmt.Errorf("deployment completed with %d tenant failure(s) and %d skipped", failureCount, skippedCount)
+	}
+
+	log.Info("Tenant deployment completed successfully")
+	return nil
+}
+
+// confirmWave asks on the terminal whether to start the next rollout wave
+func confirmWave(ctx context.Context, next *tenant.Wave) (bool, error) {
+	if tenantsYes {
+		return true, nil
+	}
+
+	fmt.Printf("\nStart wave %s with %d tenant(s)? [y/N]: ", next.Name, len(next.Tenants))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// printWavePlan prints the tenants of every rollout wave without running
+// anything
+func printWavePlan(plan *orchestrator.RolloutPlan, rollout *config.RolloutConfig) error {
+	if jsonOutput {
+		waves := make([]map[string]interface{}, 0, len(plan.Waves))
+		for _, wave := range plan.Waves {
+			waves = append(waves, map[string]interface{}{
+				"name":                wave.Name,
+				"tenants":             wave.TenantIDs(),
+				"max_failure_percent": wave.MaxFailurePercent,
+			})
+		}
+		doc := map[string]interface{}{"waves": waves}
+		if len(plan.Skipped) > 0 {
+			doc["skipped_wave_tenants"] = plan.Skipped
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode rollout plan: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	separator := "============================================================"
+	fmt.Println(separator)
+	fmt.Println("[DRY RUN] TENANT ROLLOUT PLAN")
+	fmt.Println(separator)
+	fmt.Printf("Total Tenants:   %d\n", plan.Tenants)
+	fmt.Printf("Waves:           %d\n", len(plan.Waves))
+	if len(plan.Skipped) > 0 {
+		fmt.Printf("Skipped:         %s (listed in waves, excluded by the filter)\n", strings.Join(plan.Skipped, ", "))
+	}
+	if rollout != nil {
+		if rollout.Pause > 0 {
+			fmt.Printf("Pause:           %s between waves\n", rollout.Pause)
+		}
+		if rollout.Confirm {
+			fmt.Println("Confirm:         before every wave after the first")
+		}
+	}
+	fmt.Println(separator)
+
+	for i, wave := range plan.Waves {
+		if rollout != nil {
+			fmt.Printf("\nWave %d: %s (%d tenants, abort above %g%% failures)\n", i+1, wave.Name, len(wave.Tenants), wave.MaxFailurePercent)
+		} else {
+			fmt.Printf("\nWave %d: %s (%d tenants)\n", i+1, wave.Name, len(wave.Tenants))
+		}
+		for _, id := range wave.TenantIDs() {
+			fmt.Printf("  - %s\n", id)
+		}
+	}
+	return nil
+}
+
+// addTenantFilterFlags registers the flags selecting the tenants of a
+// command
+func addTenantFilterFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().StringSliceVar(&tenantsIDs, "tenant", nil, verb+" only these tenants (repeatable)")
+	cmd.Flags().StringVar(&tenantsFile, "tenants-file", "", verb+" only the tenants listed in a file, one ID per line")
+	cmd.Flags().StringSliceVar(&tenantsExclude, "exclude-tenant", nil, "skip these tenants (repeatable)")
+	cmd.Flags().StringArrayVar(&tenantsSelectors, "selector", nil, verb+" only tenants whose labels match, e.g. region=eu,plan!=free (repeatable)")
+	cmd.Flags().StringVar(&tenantsEnv, "env", os.Getenv("MIGRA_ENV"), "environment whose default tenant selector applies (default $MIGRA_ENV)")
+}
+
+// addTenantSourceFlags registers the flags overriding the configured
+// tenant source
+func addTenantSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&tenantsSourceType, "source", "", "tenant source type, overriding tenancy.source (env, file, command, sql, http)")
+	cmd.Flags().StringVar(&tenantsSourceEnv, "source-env", "", "environment variable listing the tenants of the env source")
+	cmd.Flags().StringVar(&tenantsSourceFile, "source-file", "", "tenant file of the file source")
+	cmd.Flags().StringVar(&tenantsSourceCommand, "source-command", "", "shell command printing the tenants of the command source")
+	cmd.Flags().StringVar(&tenantsSourceDir, "source-dir", "", "working directory of the tenant source command")
+}
+
+// applyTenantSourceFlags overrides the configured tenant source with the
+// command flags and validates the result. Changing the source type drops
+// the options of the configured type.
+func applyTenantSourceFlags(cfg *config.Config) error {
+	if tenantsSourceType == "" && tenantsSourceEnv == "" && tenantsSourceFile == "" &&
+		tenantsSourceCommand == "" && tenantsSourceDir == "" {
+		return nil
+	}
+
+	source := &config.TenantSourceConfig{}
+	if cfg.Tenancy.Source != nil && (tenantsSourceType == "" || tenantsSourceType == cfg.Tenancy.Source.Type) {
+		*source = *cfg.Tenancy.Source
+	}
+	if tenantsSourceType != "" {
+		source.Type = tenantsSourceType
+	}
+	if tenantsSourceEnv != "" {
+		source.Env = tenantsSourceEnv
+	}
+	if tenantsSourceFile != "" {
+		source.File = tenantsSourceFile
+	}
+	if tenantsSourceCommand != "" {
+		source.Command = []string{tenantsSourceCommand}
+		source.Shell = true
+	}
+	if tenantsSourceDir != "" {
+		source.Dir = tenantsSourceDir
+	}
+
+	cfg.Tenancy.Source = source
+	cfg.Tenancy.TenantSource = ""
+	if err := config.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid tenant source flags: %w", err)
+	}
+	return nil
+}
+
+// newTenantOrchestrator creates the orchestrator of a tenant command,
+// applying the tenant source, filter, service and execution flags
+func newTenantOrchestrator(cfg *config.Config) (*orchestrator.Orchestrator, error) {
+	if err := applyTenantSourceFlags(cfg); err != nil {
+		return nil, err
+	}
+	if tenantsMaxParallel > 0 {
+		cfg.Tenancy.MaxParallel = tenantsMaxParallel
+	}
+	if tenantsStopOnFailure {
+		cfg.Tenancy.StopOnFailure = true
+	}
+
+	filter, err := tenantFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	return newOrchestrator(cfg, orchestrator.Options{
+		Services:    selectedServices(tenantsService),
+		Tenants:     filter,
+		Environment: tenantsEnv,
+		ConfirmWave: confirmWave,
+	})
+}
+
+// printTenantSummary prints the outcome of a multi-tenant run and returns
+// the number of failed and skipped tenants
+func printTenantSummary(title string, results []tenant.TenantResult) (int, int) {
 	successCount := 0
 	failureCount := 0
+	resumedCount := 0
+	skippedCount := 0
 	for _, r := range results {
-		if r.Success {
+		if r.Resumed {
+			resumedCount++
+		}
+		if r.Skipped {
+			skippedCount++
+		} else if r.Success {
 			successCount++
 		} else {
 			failureCount++
@@ -140,23 +307,77 @@ func runTenantsDeploy(cmd *cobra.Command, args []string) error {
 
 	separator := "============================================================"
 	fmt.Println("\n" + separator)
-	fmt.Println("TENANT MIGRATION SUMMARY")
+	fmt.Println(title)
 	fmt.Println(separator)
 	fmt.Printf("Total Tenants:   %d\n", len(results))
 	fmt.Printf("Successful:      %d\n", successCount)
 	fmt.Printf("Failed:          %d\n", failureCount)
+	if skippedCount > 0 {
+		fmt.Printf("Skipped:         %d\n", skippedCount)
+	}
+	if resumedCount > 0 {
+		fmt.Printf("Resumed:         %d (already succeeded)\n", resumedCount)
+	}
 	fmt.Println(separator)
 
 	if failureCount > 0 {
 		fmt.Println("\nFailed Tenants:")
 		for _, r := range results {
-			if !r.Success {
+			if !r.Success && !r.Skipped {
+				fmt.Printf("  - %s: %s\n", r.TenantID, r.Error)
+			}
+		}
+	}
+	if skippedCount > 0 {
+		fmt.Println("\nSkipped Tenants:")
+		for _, r := range results {
+			if r.Skipped {
 				fmt.Printf("  - %s: %s\n", r.TenantID, r.Error)
 			}
 		}
-		return fmt.Errorf("deployment completed with %d tenant failure(s)", failureCount)
 	}
+	return failureCount, skippedCount
+}
 
-	log.Info("Tenant deployment completed successfully")
-	return nil
+// tenantServiceNames returns the names of the services selected by
+// --service
+func tenantServiceNames(cfg *config.Config) []string {
+	if tenantsService != "" {
+		return []string{tenantsService}
+	}
+	names := make([]string, len(cfg.Services))
+	for i, svc := range cfg.Services {
+		names[i] = svc.Name
+	}
+	return names
+}
+
+// tenantFilter builds the tenant filter from the command flags. Without
+// --selector the orchestrator applies the default selector of --env.
+func tenantFilter() (tenant.Filter, error) {
+	filter := tenant.Filter{
+		IDs:     append([]string{}, tenantsIDs...),
+		Exclude: tenantsExclude,
+	}
+
+	if tenantsFile != "" {
+		ids, err := tenant.ReadTenantIDs(tenantsFile)
+		if err != nil {
+			return filter, err
+		}
+		if len(ids) == 0 {
+			return filter, fmt.Errorf("tenants file %s lists no tenants", tenantsFile)
+		}
+		filter.IDs = append(filter.IDs, ids...)
+	}
+
+	for _, s := range tenantsSelectors {
+		selector, err := migra.ParseSelector(s)
+		if err != nil {
+			return filter, err
+		}
+		filter.Selector = append(filter.Selector, selector...)
+	}
+
+	return filter, nil
 }
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var tenantsRollbackSteps int
+
+// tenantsRollbackCmd represents the tenants rollback command
+var tenantsRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Rollback migrations on tenants",
+	Long: `Roll back the most recent migrations of every service for the selected
+tenants. Services are rolled back in reverse dependency order.`,
+	RunE: runTenantsRollback,
+}
+
+func init() {
+	tenantsCmd.AddCommand(tenantsRollbackCmd)
+
+	tenantsRollbackCmd.Flags().IntVar(&tenantsRollbackSteps, "steps", 1, "number of steps to rollback per service")
+	tenantsRollbackCmd.Flags().StringVar(&tenantsService, "service", "", "roll back only this service")
+	tenantsRollbackCmd.Flags().IntVar(&tenantsMaxParallel, "max-parallel", 0, "maximum parallel tenant executions")
+	tenantsRollbackCmd.Flags().BoolVar(&tenantsStopOnFailure, "stop-on-failure", false, "stop on first tenant failure")
+	addTenantFilterFlags(tenantsRollbackCmd, "roll back")
+	addTenantSourceFlags(tenantsRollbackCmd)
+}
+
+func runTenantsRollback(cmd *cobra.Command, args []string) error {
+	if tenantsRollbackSteps <= 0 {
+		return fmt.Errorf("--steps must be positive")
+	}
+
+	// Load configuration
+	cfg, err := config.LoadFromFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Check if tenancy is enabled
+	if cfg.Tenancy == nil || !cfg.Tenancy.Enabled {
+		return fmt.Errorf("tenancy is not enabled in configuration")
+	}
+
+	// Setup logger
+	logLevel := logger.ParseLevel(cfg.Logging.Level)
+	log := logger.NewLogger(cfg.Logging.Format, logLevel, verbose, quiet)
+
+	log.Info(fmt.Sprintf("Rolling back tenant migrations by %d step(s)", tenantsRollbackSteps))
+
+	orch, err := newTenantOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle interrupt signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		log.Warn("Received interrupt signal, stopping...")
+		cancel()
+	}()
+
+	// Execute tenant rollbacks
+	result, err := orch.RollbackTenants(ctx, tenantsRollbackSteps)
+	if err != nil {
+		return lockHint(err)
+	}
+
+	failureCount, _ := printTenantSummary("TENANT ROLLBACK SUMMARY", result.Tenants)
+	if failureCount > 0 {
+		return fmt.Errorf("rollback completed with %d tenant failure(s)", failureCount)
+	}
+
+	log.Info("Tenant rollback completed successfully")
+	return nil
+}
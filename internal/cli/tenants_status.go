@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/tenant"
+	"github.com/spf13/cobra"
+)
+
+var tenantsOnlyDrifted bool
+
+// tenantsStatusCmd represents the tenants status command
+var tenantsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show migration status across tenants",
+	Long: `Query the migration status of every service for the selected tenants and
+print the number of pending migrations as a tenant by service matrix.
+
+A tenant is drifted when the applied migrations of one of its services
+differ from those of most tenants, or could not be determined.`,
+	RunE: runTenantsStatus,
+}
+
+func init() {
+	tenantsCmd.AddCommand(tenantsStatusCmd)
+
+	tenantsStatusCmd.Flags().BoolVar(&tenantsOnlyDrifted, "only-drifted", false, "show only tenants that drifted from the majority")
+	tenantsStatusCmd.Flags().StringVar(&tenantsService, "service", "", "show only this service")
+	tenantsStatusCmd.Flags().IntVar(&tenantsMaxParallel, "max-parallel", 0, "maximum parallel tenant queries")
+	addTenantFilterFlags(tenantsStatusCmd, "show")
+	addTenantSourceFlags(tenantsStatusCmd)
+}
+
+func runTenantsStatus(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadFromFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Check if tenancy is enabled
+	if cfg.Tenancy == nil || !cfg.Tenancy.Enabled {
+		return fmt.Errorf("tenancy is not enabled in configuration")
+	}
+
+	// Setup logger
+	logLevel := logger.ParseLevel(cfg.Logging.Level)
+	log := logger.NewLogger(cfg.Logging.Format, logLevel, verbose, quiet)
+
+	orch, err := newTenantOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle interrupt signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		log.Warn("Received interrupt signal, stopping...")
+		cancel()
+	}()
+
+	statuses, err := orch.TenantStatus(ctx)
+	if err != nil {
+		return err
+	}
+	services := tenantServiceNames(cfg)
+
+	drifted := 0
+	shown := make([]tenant.TenantStatus, 0, len(statuses))
+	for _, st := range statuses {
+		if st.Drifted {
+			drifted++
+		}
+		if st.Drifted || !tenantsOnlyDrifted {
+			shown = append(shown, st)
+		}
+	}
+
+	if jsonOutput {
+		return printTenantStatusJSON(services, shown, len(statuses), drifted)
+	}
+	printTenantStatusMatrix(services, shown, len(statuses), drifted)
+	return nil
+}
+
+// printTenantStatusJSON prints the tenant statuses as JSON
+func printTenantStatusJSON(services []string, statuses []tenant.TenantStatus, total, drifted int) error {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"services":      services,
+		"total_tenants": total,
+		"drifted":       drifted,
+		"tenants":       statuses,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tenant status: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printTenantStatusMatrix prints the pending migrations of every tenant
+// and service. Drifted cells are marked with *, and cells whose status
+// could not be determined show "error".
+func printTenantStatusMatrix(services []string, statuses []tenant.TenantStatus, total, drifted int) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "TENANT")
+	for _, name := range services {
+		fmt.Fprintf(w, "\t%s", name)
+	}
+	fmt.Fprintln(w, "\tDRIFTED")
+
+	for _, st := range statuses {
+		fmt.Fprint(w, st.TenantID)
+		for _, svc := range st.Services {
+			cell := fmt.Sprintf("%d", len(svc.Pending))
+			if svc.Error != "" {
+				cell = "error"
+			}
+			if svc.Drifted {
+				cell += "*"
+			}
+			fmt.Fprintf(w, "\t%s", cell)
+		}
+		if st.Drifted {
+			fmt.Fprintln(w, "\tyes")
+		} else {
+			fmt.Fprintln(w, "\t-")
+		}
+	}
+	w.Flush()
+
+	fmt.Printf("\nTenants: %d, drifted: %d\n", total, drifted)
+
+	header := false
+	for _, st := range statuses {
+		for _, svc := range st.Services {
+			if svc.Error == "" {
+				continue
+			}
+			if !header {
+				fmt.Println("\nErrors:")
+				header = true
+			}
+			fmt.Printf("  - %s/%s: %s\n", st.TenantID, svc.Service, svc.Error)
+		}
+	}
+}
@@ -1,34 +1,206 @@
 package config
 
 import (
+	"time"
+
 	"github.com/migra/migra/pkg/migra"
 )
 
 // Config represents the main configuration structure
 type Config struct {
-	Services      []migra.Service  `yaml:"services" json:"services"`
-	Discovery     *DiscoveryConfig `yaml:"discovery,omitempty" json:"discovery,omitempty"`
-	Execution     ExecutionConfig  `yaml:"execution" json:"execution"`
-	Tenancy       *TenancyConfig   `yaml:"tenancy,omitempty" json:"tenancy,omitempty"`
-	Logging       LoggingConfig    `yaml:"logging" json:"logging"`
-	GlobalEnv     map[string]string `yaml:"global_env,omitempty" json:"global_env,omitempty"`
-	ParallelLimit int              `yaml:"parallel_limit,omitempty" json:"parallel_limit,omitempty"`
+	Services      []migra.Service    `yaml:"services" json:"services"`
+	Discovery     *DiscoveryConfig   `yaml:"discovery,omitempty" json:"discovery,omitempty"`
+	Execution     ExecutionConfig    `yaml:"execution" json:"execution"`
+	Tenancy       *TenancyConfig     `yaml:"tenancy,omitempty" json:"tenancy,omitempty"`
+	Logging       LoggingConfig      `yaml:"logging" json:"logging"`
+	Lock          *LockConfig        `yaml:"lock,omitempty" json:"lock,omitempty"`
+	Plugins       *PluginsConfig     `yaml:"plugins,omitempty" json:"plugins,omitempty"`
+	GlobalEnv     map[string]string  `yaml:"global_env,omitempty" json:"global_env,omitempty"`
+	Retry         *migra.RetryPolicy `yaml:"retry,omitempty" json:"retry,omitempty"`
+	ParallelLimit int                `yaml:"parallel_limit,omitempty" json:"parallel_limit,omitempty"`
 }
 
 // ExecutionConfig defines how migrations should be executed
 type ExecutionConfig struct {
-	Strategy      string `yaml:"strategy" json:"strategy"`
-	StopOnFailure bool   `yaml:"stop_on_failure" json:"stop_on_failure"`
-	ParallelLimit int    `yaml:"parallel_limit,omitempty" json:"parallel_limit,omitempty"`
+	Strategy      string        `yaml:"strategy" json:"strategy"`
+	StopOnFailure bool          `yaml:"stop_on_failure" json:"stop_on_failure"`
+	ParallelLimit int           `yaml:"parallel_limit,omitempty" json:"parallel_limit,omitempty"`
+	Timeout       time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	GracePeriod   time.Duration `yaml:"grace_period,omitempty" json:"grace_period,omitempty"`
 }
 
 // TenancyConfig defines multi-tenant configuration
 type TenancyConfig struct {
-	Enabled       bool   `yaml:"enabled" json:"enabled"`
-	Mode          string `yaml:"mode" json:"mode"`
-	TenantSource  string `yaml:"tenant_source" json:"tenant_source"`
-	StopOnFailure bool   `yaml:"stop_on_failure" json:"stop_on_failure"`
-	MaxParallel   int    `yaml:"max_parallel,omitempty" json:"max_parallel,omitempty"`
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	Mode    string `yaml:"mode" json:"mode"`
+	// TenantSource is a deprecated alias of Source.Type, moved there when
+	// defaults are applied
+	TenantSource string `yaml:"tenant_source,omitempty" json:"tenant_source,omitempty"`
+	// Source is the type and options of the tenant source
+	Source        *TenantSourceConfig `yaml:"source,omitempty" json:"source,omitempty"`
+	StopOnFailure bool                `yaml:"stop_on_failure" json:"stop_on_failure"`
+	MaxParallel   int                 `yaml:"max_parallel,omitempty" json:"max_parallel,omitempty"`
+	Timeout       time.Duration       `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Rollout       *RolloutConfig      `yaml:"rollout,omitempty" json:"rollout,omitempty"`
+	// Selectors are the default tenant label selectors per environment
+	Selectors map[string]string `yaml:"selectors,omitempty" json:"selectors,omitempty"`
+	// Schema is the schema name template of schema_per_tenant mode, in
+	// which {id} is replaced by the tenant ID
+	Schema string `yaml:"schema,omitempty" json:"schema,omitempty"`
+	// CreateSchema creates missing tenant schemas before deploying
+	CreateSchema bool `yaml:"create_schema,omitempty" json:"create_schema,omitempty"`
+}
+
+// TenantSourceConfig configures where tenants are loaded from. The options
+// of each source type only apply to that type.
+type TenantSourceConfig struct {
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// Env is the environment variable read by the env source
+	Env string `yaml:"env,omitempty" json:"env,omitempty"`
+	// File is the JSON or YAML tenant file of the file source
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	// Command is the program and arguments of the command source. In shell
+	// mode it is a single script run by the system shell.
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+	Shell   bool     `yaml:"shell,omitempty" json:"shell,omitempty"`
+	// Dir is the working directory of the command
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// SQL is the query of the sql source
+	SQL *SQLSourceConfig `yaml:"sql,omitempty" json:"sql,omitempty"`
+	// HTTP is the API of the http source
+	HTTP *HTTPSourceConfig `yaml:"http,omitempty" json:"http,omitempty"`
+}
+
+// HTTPSourceConfig loads tenants from a JSON API. The tenant array is
+// found at Path in each response, and pages are followed until the API
+// reports no more.
+type HTTPSourceConfig struct {
+	URL     string            `yaml:"url" json:"url"`
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Auth    *HTTPAuthConfig   `yaml:"auth,omitempty" json:"auth,omitempty"`
+	// Path is the dotted path to the tenant array; "." is the whole body
+	Path       string            `yaml:"path,omitempty" json:"path,omitempty"`
+	Pagination *PaginationConfig `yaml:"pagination,omitempty" json:"pagination,omitempty"`
+	// Fields maps tenant fields to dotted paths in each array item. Without
+	// it, items are read like the file source.
+	Fields  *TenantFields `yaml:"fields,omitempty" json:"fields,omitempty"`
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// HTTPAuthConfig authenticates tenant API requests with credentials read
+// from environment variables
+type HTTPAuthConfig struct {
+	Type        string `yaml:"type" json:"type"`
+	TokenEnv    string `yaml:"token_env,omitempty" json:"token_env,omitempty"`
+	UsernameEnv string `yaml:"username_env,omitempty" json:"username_env,omitempty"`
+	PasswordEnv string `yaml:"password_env,omitempty" json:"password_env,omitempty"`
+}
+
+// PaginationConfig defines how tenant API pages are requested. Cursor
+// pagination passes the cursor found at CursorPath in each response until
+// it is empty; page pagination counts pages until one comes back with
+// fewer than Size tenants, or none.
+type PaginationConfig struct {
+	Type        string `yaml:"type" json:"type"`
+	CursorParam string `yaml:"cursor_param,omitempty" json:"cursor_param,omitempty"`
+	CursorPath  string `yaml:"cursor_path,omitempty" json:"cursor_path,omitempty"`
+	PageParam   string `yaml:"page_param,omitempty" json:"page_param,omitempty"`
+	SizeParam   string `yaml:"size_param,omitempty" json:"size_param,omitempty"`
+	Size        int    `yaml:"size,omitempty" json:"size,omitempty"`
+	MaxPages    int    `yaml:"max_pages,omitempty" json:"max_pages,omitempty"`
+}
+
+// TenantFields maps tenant fields to dotted paths in a tenant object
+type TenantFields struct {
+	ID         string            `yaml:"id,omitempty" json:"id,omitempty"`
+	Schema     string            `yaml:"schema,omitempty" json:"schema,omitempty"`
+	Connection map[string]string `yaml:"connection,omitempty" json:"connection,omitempty"`
+	Labels     map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+}
+
+// SQLSourceConfig loads tenants with a query. Each row is a tenant; the
+// mappings name the columns holding its connection variables and labels.
+type SQLSourceConfig struct {
+	Driver string `yaml:"driver" json:"driver"`
+	DSN    string `yaml:"dsn,omitempty" json:"dsn,omitempty"`
+	DSNEnv string `yaml:"dsn_env,omitempty" json:"dsn_env,omitempty"`
+	Query  string `yaml:"query" json:"query"`
+	// IDColumn holds the tenant ID
+	IDColumn string `yaml:"id_column,omitempty" json:"id_column,omitempty"`
+	// SchemaColumn holds the tenant schema in schema_per_tenant mode
+	SchemaColumn string `yaml:"schema_column,omitempty" json:"schema_column,omitempty"`
+	// Connection maps connection variable names to columns
+	Connection map[string]string `yaml:"connection,omitempty" json:"connection,omitempty"`
+	// Labels maps label names to columns
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+}
+
+// RolloutConfig stages a multi-tenant deploy in waves. Tenants not
+// covered by any wave are deployed in a final wave.
+type RolloutConfig struct {
+	Waves []WaveConfig `yaml:"waves" json:"waves"`
+	// MaxFailurePercent is the share of a wave's tenants allowed to fail
+	// before the rollout is aborted, for waves that do not set their own
+	MaxFailurePercent float64 `yaml:"max_failure_percent,omitempty" json:"max_failure_percent,omitempty"`
+	// Pause is the time waited between waves
+	Pause time.Duration `yaml:"pause,omitempty" json:"pause,omitempty"`
+	// Confirm asks for confirmation before every wave after the first
+	Confirm bool `yaml:"confirm,omitempty" json:"confirm,omitempty"`
+}
+
+// WaveConfig defines one wave of a rollout: an explicit list of tenants,
+// or a percentage of all tenants deployed by the end of the wave. A wave
+// with neither takes all remaining tenants.
+type WaveConfig struct {
+	Name              string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Tenants           []string `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+	Percent           float64  `yaml:"percent,omitempty" json:"percent,omitempty"`
+	MaxFailurePercent *float64 `yaml:"max_failure_percent,omitempty" json:"max_failure_percent,omitempty"`
+}
+
+// LockConfig defines run locking configuration
+type LockConfig struct {
+	Enabled bool          `yaml:"enabled" json:"enabled"`
+	Backend string        `yaml:"backend" json:"backend"`
+	Name    string        `yaml:"name,omitempty" json:"name,omitempty"`
+	TTL     time.Duration `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	DSN     string        `yaml:"dsn,omitempty" json:"dsn,omitempty"`
+	DSNEnv  string        `yaml:"dsn_env,omitempty" json:"dsn_env,omitempty"`
+}
+
+// PluginsConfig defines where adapter plugins are looked up besides PATH
+type PluginsConfig struct {
+	Dirs []string `yaml:"dirs,omitempty" json:"dirs,omitempty"`
+}
+
+// EffectiveParallelLimit returns the concurrency limit for parallel and
+// dag execution: execution.parallel_limit, then parallel_limit, then the
+// default
+func (c *Config) EffectiveParallelLimit() int {
+	if c.Execution.ParallelLimit > 0 {
+		return c.Execution.ParallelLimit
+	}
+	if c.ParallelLimit > 0 {
+		return c.ParallelLimit
+	}
+	return DefaultParallelLimit
+}
+
+// DefaultSelector returns the default tenant selector of an environment,
+// or an empty string
+func (c *Config) DefaultSelector(env string) string {
+	if c.Tenancy == nil || env == "" {
+		return ""
+	}
+	return c.Tenancy.Selectors[env]
+}
+
+// PluginDirs returns the configured plugin directories
+func (c *Config) PluginDirs() []string {
+	if c.Plugins == nil {
+		return nil
+	}
+	return c.Plugins.Dirs
 }
 
 // LoggingConfig defines logging configuration
@@ -42,6 +214,7 @@ type LoggingConfig struct {
 const (
 	StrategySequential = "sequential"
 	StrategyParallel   = "parallel"
+	StrategyDAG        = "dag"
 
 	TenancyModeDatabase = "database_per_tenant"
 	TenancyModeSchema   = "schema_per_tenant"
@@ -49,6 +222,21 @@ const (
 	TenantSourceEnv     = "env"
 	TenantSourceFile    = "file"
 	TenantSourceCommand = "command"
+	TenantSourceSQL     = "sql"
+	TenantSourceHTTP    = "http"
+
+	HTTPAuthBearer = "bearer"
+	HTTPAuthBasic  = "basic"
+
+	PaginationCursor = "cursor"
+	PaginationPage   = "page"
+
+	SQLDriverPostgres = "postgres"
+	SQLDriverMySQL    = "mysql"
+	SQLDriverSQLite   = "sqlite"
+
+	LockBackendFile     = "file"
+	LockBackendPostgres = "postgres"
 
 	LogLevelDebug = "debug"
 	LogLevelInfo  = "info"
@@ -58,9 +246,22 @@ const (
 	LogFormatConsole = "console"
 	LogFormatJSON    = "json"
 
-	FrameworkDjango = "django"
+	FrameworkDjango  = "django"
 	FrameworkLaravel = "laravel"
-	FrameworkPrisma = "prisma"
+	FrameworkPrisma  = "prisma"
+	FrameworkAlembic = "alembic"
+	FrameworkRails   = "rails"
+
+	FrameworkGolangMigrate = "golang-migrate"
+	FrameworkGoose         = "goose"
+	FrameworkFlyway        = "flyway"
+	FrameworkLiquibase     = "liquibase"
+	FrameworkKnex          = "knex"
+	FrameworkTypeORM       = "typeorm"
+	FrameworkSequelize     = "sequelize"
+	FrameworkEFCore        = "efcore"
+	FrameworkDbmate        = "dbmate"
+	FrameworkCommand       = "command"
 )
 
 // Default values
@@ -70,4 +271,17 @@ const (
 	DefaultParallelLimit = 5
 	DefaultLogLevel      = LogLevelInfo
 	DefaultLogFormat     = LogFormatConsole
+	DefaultLockBackend   = LockBackendFile
+	DefaultLockName      = "migra"
+	DefaultLockTTL       = 5 * time.Minute
+	DefaultGracePeriod   = 10 * time.Second
+	DefaultRetryBackoff  = time.Second
+	DefaultSchema        = "{id}"
+	DefaultIDColumn      = "id"
+	DefaultTenantsEnv    = "MIGRA_TENANTS"
+	DefaultTenantsFile   = "tenants.json"
+	DefaultHTTPTimeout   = 30 * time.Second
+	DefaultCursorParam   = "cursor"
+	DefaultPageParam     = "page"
+	DefaultMaxPages      = 1000
 )
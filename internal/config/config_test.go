@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/migra/migra/pkg/migra"
 	"github.com/stretchr/testify/assert"
@@ -96,6 +99,77 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown dependency",
+			config: &Config{
+				Services: []migra.Service{
+					{Name: "svc1", Type: FrameworkDjango, Path: ".", DependsOn: []string{"missing"}},
+				},
+				Execution: ExecutionConfig{
+					Strategy:      StrategyDAG,
+					ParallelLimit: 2,
+				},
+				Logging: LoggingConfig{
+					Level:  LogLevelInfo,
+					Format: LogFormatConsole,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dependency cycle",
+			config: &Config{
+				Services: []migra.Service{
+					{Name: "svc1", Type: FrameworkDjango, Path: ".", DependsOn: []string{"svc2"}},
+					{Name: "svc2", Type: FrameworkDjango, Path: ".", DependsOn: []string{"svc1"}},
+				},
+				Execution: ExecutionConfig{
+					Strategy:      StrategyDAG,
+					ParallelLimit: 2,
+				},
+				Logging: LoggingConfig{
+					Level:  LogLevelInfo,
+					Format: LogFormatConsole,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dependencies without dag strategy",
+			config: &Config{
+				Services: []migra.Service{
+					{Name: "svc1", Type: FrameworkDjango, Path: "."},
+					{Name: "svc2", Type: FrameworkDjango, Path: ".", DependsOn: []string{"svc1"}},
+				},
+				Execution: ExecutionConfig{
+					Strategy:      StrategyParallel,
+					ParallelLimit: 2,
+				},
+				Logging: LoggingConfig{
+					Level:  LogLevelInfo,
+					Format: LogFormatConsole,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid dependencies",
+			config: &Config{
+				Services: []migra.Service{
+					{Name: "svc1", Type: FrameworkDjango, Path: "."},
+					{Name: "svc2", Type: FrameworkDjango, Path: ".", DependsOn: []string{"svc1"}},
+				},
+				Execution: ExecutionConfig{
+					Strategy:      StrategyDAG,
+					ParallelLimit: 2,
+				},
+				Logging: LoggingConfig{
+					Level:  LogLevelInfo,
+					Format: LogFormatConsole,
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -109,3 +183,577 @@ func TestValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDependencyCycleMessage(t *testing.T) {
+	cfg := &Config{
+		Services: []migra.Service{
+			{Name: "a", Type: FrameworkDjango, Path: ".", DependsOn: []string{"b"}},
+			{Name: "b", Type: FrameworkDjango, Path: ".", DependsOn: []string{"c"}},
+			{Name: "c", Type: FrameworkDjango, Path: ".", DependsOn: []string{"a"}},
+		},
+		Execution: ExecutionConfig{Strategy: StrategyDAG, ParallelLimit: 2},
+		Logging:   LoggingConfig{Level: LogLevelInfo, Format: LogFormatConsole},
+	}
+
+	err := Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle detected: a -> b -> c -> a")
+}
+
+func TestLoadLockConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+
+lock:
+  enabled: true
+  ttl: 90s
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Lock)
+	assert.Equal(t, LockBackendFile, cfg.Lock.Backend)
+	assert.Equal(t, DefaultLockName, cfg.Lock.Name)
+	assert.Equal(t, 90*time.Second, cfg.Lock.TTL)
+	assert.NoError(t, Validate(cfg))
+
+	cfg.Lock.Backend = LockBackendPostgres
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lock.dsn")
+}
+
+func TestValidateCommandService(t *testing.T) {
+	newConfig := func(service migra.Service) *Config {
+		return &Config{
+			Services:  []migra.Service{service},
+			Execution: ExecutionConfig{Strategy: StrategySequential},
+			Logging:   LoggingConfig{Level: LogLevelInfo, Format: LogFormatConsole},
+		}
+	}
+
+	t.Run("valid command service", func(t *testing.T) {
+		err := Validate(newConfig(migra.Service{
+			Name: "svc1", Type: FrameworkCommand, Path: ".",
+			Command: &migra.CommandSpec{
+				Deploy:   []string{"tool", "up"},
+				Rollback: []string{"tool", "down", "{{.Steps}}"},
+				Status:   []string{"tool", "status"},
+				StatusParser: &migra.StatusParser{
+					Format:  migra.StatusFormatRegex,
+					Pending: `^pending (\S+)`,
+				},
+			},
+		}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing deploy command", func(t *testing.T) {
+		err := Validate(newConfig(migra.Service{Name: "svc1", Type: FrameworkCommand, Path: "."}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "command.deploy is required")
+	})
+
+	t.Run("invalid template and pattern", func(t *testing.T) {
+		err := Validate(newConfig(migra.Service{
+			Name: "svc1", Type: FrameworkCommand, Path: ".",
+			Command: &migra.CommandSpec{
+				Deploy: []string{"tool", "{{.Steps"},
+				Status: []string{"tool", "status"},
+				StatusParser: &migra.StatusParser{
+					Format:  migra.StatusFormatRegex,
+					Applied: `(`,
+				},
+			},
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid template in command.deploy")
+		assert.Contains(t, err.Error(), "invalid command.status_parser.applied pattern")
+	})
+
+	t.Run("command on other type", func(t *testing.T) {
+		err := Validate(newConfig(migra.Service{
+			Name: "svc1", Type: FrameworkDjango, Path: ".",
+			Command: &migra.CommandSpec{Deploy: []string{"tool"}},
+		}))
+		assert.Error(t, err)
+	})
+}
+
+func TestDetectFramework(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		content string
+		want    string
+	}{
+		{name: "django", files: []string{"manage.py"}, want: FrameworkDjango},
+		{name: "laravel", files: []string{"artisan"}, want: FrameworkLaravel},
+		{name: "prisma", files: []string{"prisma/schema.prisma"}, want: FrameworkPrisma},
+		{name: "alembic", files: []string{"alembic.ini"}, want: FrameworkAlembic},
+		{name: "rails", files: []string{"bin/rails", "db/migrate/20240101120000_create_users.rb"}, want: FrameworkRails},
+		{name: "rails without migrations", files: []string{"bin/rails"}, want: ""},
+		{name: "golang-migrate", files: []string{"migrations/000001_init.up.sql", "migrations/000001_init.down.sql"}, want: FrameworkGolangMigrate},
+		{name: "goose", files: []string{"migrations/00001_init.sql"}, content: "-- +goose Up\nCREATE TABLE t (id int);\n", want: FrameworkGoose},
+		{name: "plain sql", files: []string{"migrations/init.sql"}, content: "CREATE TABLE t (id int);\n", want: ""},
+		{name: "flyway", files: []string{"flyway.conf"}, want: FrameworkFlyway},
+		{name: "liquibase properties", files: []string{"liquibase.properties"}, want: FrameworkLiquibase},
+		{name: "liquibase changelog", files: []string{"db.changelog-master.yaml"}, want: FrameworkLiquibase},
+		{name: "knex", files: []string{"knexfile.ts"}, want: FrameworkKnex},
+		{name: "typeorm ormconfig", files: []string{"ormconfig.json"}, want: FrameworkTypeORM},
+		{name: "typeorm data source", files: []string{"src/data-source.ts"}, want: FrameworkTypeORM},
+		{name: "sequelize", files: []string{".sequelizerc"}, want: FrameworkSequelize},
+		{name: "efcore", files: []string{"Orders.csproj", "Migrations/20240108120000_InitialCreate.cs"}, want: FrameworkEFCore},
+		{name: "dbmate", files: []string{"db/migrations/20240108120000_create_users.sql"}, content: "-- migrate:up\ncreate table users (id int);\n", want: FrameworkDbmate},
+		{name: "unknown", files: []string{"README.md"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				path := filepath.Join(dir, file)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			}
+
+			assert.Equal(t, tt.want, NewDiscoverer(dir).detectFramework(dir))
+		})
+	}
+}
+
+func TestValidatePluginType(t *testing.T) {
+	pluginDir := t.TempDir()
+	name := migra.PluginPrefix + "sqitch"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, name), []byte("#!/bin/sh\n"), 0755))
+
+	cfg := &Config{
+		Services:  []migra.Service{{Name: "svc1", Type: "sqitch", Path: "."}},
+		Execution: ExecutionConfig{Strategy: StrategySequential},
+		Logging:   LoggingConfig{Level: LogLevelInfo, Format: LogFormatConsole},
+	}
+
+	err := Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported type 'sqitch'")
+
+	cfg.Plugins = &PluginsConfig{Dirs: []string{pluginDir}}
+	assert.NoError(t, Validate(cfg))
+}
+
+func TestLoadTimeoutConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+    timeout: 5m
+
+execution:
+  timeout: 30m
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cfg.Services[0].Timeout)
+	assert.Equal(t, 30*time.Minute, cfg.Execution.Timeout)
+	assert.Equal(t, DefaultGracePeriod, cfg.Execution.GracePeriod)
+	assert.NoError(t, Validate(cfg))
+
+	cfg.Services[0].Timeout = -time.Second
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout")
+
+	cfg.Services[0].Timeout = 0
+	cfg.Execution.GracePeriod = -time.Second
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "grace_period")
+}
+
+func TestLoadRetryConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: billing
+    type: django
+    path: .
+  - name: users
+    type: django
+    path: .
+    retry:
+      max_attempts: 5
+      backoff: 2s
+      retry_on:
+        - deadlock detected
+
+retry:
+  max_attempts: 3
+  max_backoff: 30s
+  jitter: 0.2
+  retry_on:
+    - could not connect
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Retry)
+	assert.Equal(t, DefaultRetryBackoff, cfg.Retry.Backoff)
+
+	// Services without a policy inherit the global one
+	assert.Same(t, cfg.Retry, cfg.Services[0].Retry)
+	assert.Equal(t, 5, cfg.Services[1].Retry.MaxAttempts)
+	assert.Equal(t, 2*time.Second, cfg.Services[1].Retry.Backoff)
+	assert.Equal(t, []string{"deadlock detected"}, cfg.Services[1].Retry.RetryOn)
+	assert.NoError(t, Validate(cfg))
+
+	cfg.Retry.Jitter = 2
+	cfg.Services[1].Retry.MaxAttempts = 0
+	cfg.Services[1].Retry.RetryOn = []string{"("}
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry.jitter")
+	assert.Contains(t, err.Error(), "services[1] (users): retry.max_attempts")
+	assert.Contains(t, err.Error(), "invalid retry_on pattern")
+	assert.Equal(t, 1, strings.Count(err.Error(), "jitter"))
+}
+
+func TestValidateRollout(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+
+tenancy:
+  enabled: true
+  tenant_source: file
+  rollout:
+    max_failure_percent: 1
+    pause: 5m
+    confirm: true
+    waves:
+      - name: canary
+        tenants: [acme, globex]
+        max_failure_percent: 0
+      - percent: 5
+      - percent: 25
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	rollout := cfg.Tenancy.Rollout
+	require.NotNil(t, rollout)
+	require.Len(t, rollout.Waves, 3)
+	assert.Equal(t, 5*time.Minute, rollout.Pause)
+	require.NotNil(t, rollout.Waves[0].MaxFailurePercent)
+	assert.Equal(t, 0.0, *rollout.Waves[0].MaxFailurePercent)
+	assert.Nil(t, rollout.Waves[1].MaxFailurePercent)
+	assert.NoError(t, Validate(cfg))
+
+	rollout.Waves = append(rollout.Waves, WaveConfig{Name: "rest"}, WaveConfig{Percent: 20, Tenants: []string{"acme"}})
+	rollout.Waves[2].Percent = 4
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "waves[2]: percent must be greater")
+	assert.Contains(t, err.Error(), "waves[3] (rest): only the last wave")
+	assert.Contains(t, err.Error(), "waves[4]: set either tenants or percent")
+}
+
+func TestLoadTenantSelectors(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+
+tenancy:
+  enabled: true
+  tenant_source: file
+  selectors:
+    production: region=eu,plan=enterprise
+    staging: plan!=free
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	assert.NoError(t, Validate(cfg))
+	assert.Equal(t, "region=eu,plan=enterprise", cfg.DefaultSelector("production"))
+	assert.Equal(t, "plan!=free", cfg.DefaultSelector("staging"))
+	assert.Empty(t, cfg.DefaultSelector("dev"))
+	assert.Empty(t, cfg.DefaultSelector(""))
+
+	cfg.Tenancy.Selectors["dev"] = "region"
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenancy.selectors.dev: invalid selector requirement 'region'")
+}
+
+func TestLoadSchemaPerTenant(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+
+tenancy:
+  enabled: true
+  mode: schema_per_tenant
+  tenant_source: file
+  create_schema: true
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSchema, cfg.Tenancy.Schema)
+	assert.True(t, cfg.Tenancy.CreateSchema)
+	assert.NoError(t, Validate(cfg))
+
+	cfg.Tenancy.Schema = "shared"
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenancy.schema must contain {id}")
+
+	cfg.Tenancy.Mode = TenancyModeDatabase
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "require mode 'schema_per_tenant'")
+}
+
+func TestValidateSQLSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+
+tenancy:
+  enabled: true
+  source:
+    type: sql
+    sql:
+      driver: postgres
+      dsn_env: CONTROL_PLANE_DSN
+      query: SELECT slug, database_url, region FROM tenants WHERE active
+      connection:
+        DATABASE_URL: database_url
+      labels:
+        region: region
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Tenancy.Source.SQL)
+	assert.Equal(t, DefaultIDColumn, cfg.Tenancy.Source.SQL.IDColumn)
+	assert.Equal(t, map[string]string{"DATABASE_URL": "database_url"}, cfg.Tenancy.Source.SQL.Connection)
+	assert.NoError(t, Validate(cfg))
+
+	cfg.Tenancy.Source.SQL = &SQLSourceConfig{Driver: "oracle"}
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenancy.source.sql.driver must be 'postgres', 'mysql', or 'sqlite'")
+	assert.Contains(t, err.Error(), "tenancy.source.sql.dsn or dsn_env is required")
+	assert.Contains(t, err.Error(), "tenancy.source.sql.query is required")
+
+	cfg.Tenancy.Source.SQL = nil
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenancy.source.sql is required")
+}
+
+func TestValidateHTTPSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+
+tenancy:
+  enabled: true
+  source:
+    type: http
+    http:
+      url: https://control-plane.internal/api/tenants
+      path: data.tenants
+      auth:
+        type: bearer
+        token_env: CONTROL_PLANE_TOKEN
+      pagination:
+        type: cursor
+        cursor_path: meta.next
+      fields:
+        connection:
+          DATABASE_URL: database.url
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	source := cfg.Tenancy.Source.HTTP
+	require.NotNil(t, source)
+	assert.Equal(t, DefaultHTTPTimeout, source.Timeout)
+	assert.Equal(t, DefaultIDColumn, source.Fields.ID)
+	assert.Equal(t, DefaultCursorParam, source.Pagination.CursorParam)
+	assert.Equal(t, DefaultMaxPages, source.Pagination.MaxPages)
+	assert.NoError(t, Validate(cfg))
+
+	cfg.Tenancy.Source.HTTP = &HTTPSourceConfig{
+		URL:        "ftp://control-plane",
+		Auth:       &HTTPAuthConfig{Type: HTTPAuthBasic, UsernameEnv: "USER"},
+		Pagination: &PaginationConfig{Type: PaginationCursor, MaxPages: 10},
+	}
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenancy.source.http.url must be an http or https URL")
+	assert.Contains(t, err.Error(), "username_env and password_env are required for basic auth")
+	assert.Contains(t, err.Error(), "tenancy.source.http.pagination.cursor_path is required")
+
+	cfg.Tenancy.Source.HTTP = &HTTPSourceConfig{
+		URL:        "http://localhost:8080/tenants",
+		Auth:       &HTTPAuthConfig{Type: "oauth"},
+		Pagination: &PaginationConfig{Type: "offset", MaxPages: 10},
+	}
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenancy.source.http.auth.type must be 'bearer' or 'basic'")
+	assert.Contains(t, err.Error(), "tenancy.source.http.pagination.type must be 'cursor' or 'page'")
+
+	cfg.Tenancy.Source.HTTP = nil
+	err = Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenancy.source.http is required")
+}
+
+func TestLoadTenantSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "migra.yaml")
+
+	cfgContent := `
+services:
+  - name: test-service
+    type: django
+    path: .
+
+tenancy:
+  enabled: true
+  source:
+    type: command
+    command: ["./scripts/tenants.sh", "--active", "--format=json"]
+    dir: ops
+`
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(cfgContent), 0644))
+
+	cfg, err := LoadFromFile(cfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, TenantSourceCommand, cfg.Tenancy.Source.Type)
+	assert.Equal(t, []string{"./scripts/tenants.sh", "--active", "--format=json"}, cfg.Tenancy.Source.Command)
+	assert.Equal(t, "ops", cfg.Tenancy.Source.Dir)
+
+	t.Run("moves the deprecated tenant_source to source.type", func(t *testing.T) {
+		cfg.Tenancy.TenantSource = TenantSourceFile
+		cfg.Tenancy.Source = nil
+		ApplyDefaults(cfg)
+		assert.Equal(t, TenantSourceFile, cfg.Tenancy.Source.Type)
+		assert.Empty(t, cfg.Tenancy.TenantSource)
+		assert.NoError(t, Validate(cfg))
+
+		cfg.Tenancy.TenantSource = TenantSourceFile
+		cfg.Tenancy.Source = &TenantSourceConfig{File: "tenants.yaml"}
+		ApplyDefaults(cfg)
+		assert.Equal(t, TenantSourceFile, cfg.Tenancy.Source.Type)
+		assert.NoError(t, Validate(cfg))
+	})
+
+	t.Run("requires a type", func(t *testing.T) {
+		cfg.Tenancy.TenantSource = ""
+		cfg.Tenancy.Source = &TenantSourceConfig{}
+		err := Validate(cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tenancy.source.type is required")
+	})
+
+	t.Run("rejects conflicting types", func(t *testing.T) {
+		cfg.Tenancy.TenantSource = TenantSourceFile
+		cfg.Tenancy.Source = &TenantSourceConfig{Type: TenantSourceEnv}
+		ApplyDefaults(cfg)
+		err := Validate(cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tenancy.source.type 'env' conflicts with the deprecated tenancy.tenant_source 'file'")
+	})
+
+	t.Run("rejects options of other types", func(t *testing.T) {
+		cfg.Tenancy.TenantSource = ""
+		cfg.Tenancy.Source = &TenantSourceConfig{
+			Type: TenantSourceEnv,
+			File: "tenants.json",
+			Dir:  "ops",
+			HTTP: &HTTPSourceConfig{URL: "https://control-plane/tenants"},
+		}
+		err := Validate(cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tenancy.source.http requires type 'http'")
+		assert.Contains(t, err.Error(), "tenancy.source.file requires type 'file'")
+		assert.Contains(t, err.Error(), "tenancy.source.command, shell and dir require type 'command'")
+	})
+
+	t.Run("allows at most one script in shell mode", func(t *testing.T) {
+		cfg.Tenancy.TenantSource = ""
+		cfg.Tenancy.Source = &TenantSourceConfig{Type: TenantSourceCommand, Command: []string{"curl", "-s"}, Shell: true}
+		err := Validate(cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be a single script in shell mode, got 2 entries")
+
+		cfg.Tenancy.Source.Command = []string{"curl -s $TENANT_API | jq .items"}
+		assert.NoError(t, Validate(cfg))
+
+		// MIGRA_TENANTS_COMMAND is used as the script
+		cfg.Tenancy.Source.Command = nil
+		assert.NoError(t, Validate(cfg))
+	})
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/migra/migra/pkg/migra"
 )
@@ -99,9 +101,135 @@ func (d *Discoverer) detectFramework(path string) string {
 		}
 	}
 
+	// Check for Alembic
+	if fileExists(filepath.Join(path, "alembic.ini")) {
+		return FrameworkAlembic
+	}
+
+	// Check for Rails
+	if fileExists(filepath.Join(path, "bin", "rails")) && dirExists(filepath.Join(path, "db", "migrate")) {
+		return FrameworkRails
+	}
+
+	// Check for Node.js migration tools
+	if hasFileMatching(path, "knexfile.*") {
+		return FrameworkKnex
+	}
+	if hasFileMatching(path, "ormconfig.*") ||
+		fileExists(filepath.Join(path, "data-source.ts")) ||
+		fileExists(filepath.Join(path, "src", "data-source.ts")) {
+		return FrameworkTypeORM
+	}
+	if fileExists(filepath.Join(path, ".sequelizerc")) {
+		return FrameworkSequelize
+	}
+
+	// Check for Entity Framework Core
+	if hasFileMatching(path, "*.csproj") && dirExists(filepath.Join(path, "Migrations")) {
+		return FrameworkEFCore
+	}
+
+	// Check for dbmate
+	if hasAnnotatedSQL(filepath.Join(path, "db", "migrations"), "-- migrate:up") {
+		return FrameworkDbmate
+	}
+
+	// Check for Flyway
+	if fileExists(filepath.Join(path, "flyway.conf")) || fileExists(filepath.Join(path, "flyway.toml")) {
+		return FrameworkFlyway
+	}
+
+	// Check for Liquibase
+	if fileExists(filepath.Join(path, "liquibase.properties")) || hasLiquibaseChangelog(path) {
+		return FrameworkLiquibase
+	}
+
+	// Check for golang-migrate and goose SQL migrations
+	if framework := detectGoMigrations(filepath.Join(path, "migrations")); framework != "" {
+		return framework
+	}
+
 	return ""
 }
 
+// hasFileMatching checks if a file matching a glob pattern exists in path
+func hasFileMatching(path, pattern string) bool {
+	matches, err := filepath.Glob(filepath.Join(path, pattern))
+	if err != nil {
+		return false
+	}
+	for _, match := range matches {
+		if fileExists(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLiquibaseChangelog checks for a Liquibase changelog file such as
+// changelog.xml or db.changelog-master.yaml
+func hasLiquibaseChangelog(path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if entry.IsDir() || !strings.Contains(name, "changelog") {
+			continue
+		}
+		switch filepath.Ext(name) {
+		case ".xml", ".yaml", ".yml", ".json", ".sql":
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectGoMigrations recognises golang-migrate (*.up.sql) and goose
+// (-- +goose Up annotated) SQL files in a migrations directory
+func detectGoMigrations(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".up.sql") {
+			return FrameworkGolangMigrate
+		}
+	}
+
+	if hasAnnotatedSQL(dir, "+goose Up") {
+		return FrameworkGoose
+	}
+
+	return ""
+}
+
+// hasAnnotatedSQL checks if a SQL file in dir contains the annotation
+func hasAnnotatedSQL(dir, annotation string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err == nil && bytes.Contains(data, []byte(annotation)) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // fileExists checks if a file exists
 func fileExists(path string) bool {
 	info, err := os.Stat(path)
@@ -46,16 +46,27 @@ func (l *Loader) Load() (*Config, error) {
 
 // applyDefaults sets default values for missing configuration
 func (l *Loader) applyDefaults(config *Config) {
-	// Execution defaults
+	// Execution defaults - services with dependencies imply the dag strategy
 	if config.Execution.Strategy == "" {
 		config.Execution.Strategy = DefaultStrategy
+		for _, svc := range config.Services {
+			if len(svc.DependsOn) > 0 {
+				config.Execution.Strategy = StrategyDAG
+				break
+			}
+		}
 	}
-	if config.Execution.ParallelLimit == 0 && config.Execution.Strategy == StrategyParallel {
+	concurrent := config.Execution.Strategy == StrategyParallel || config.Execution.Strategy == StrategyDAG
+	if config.Execution.ParallelLimit == 0 && concurrent {
 		config.Execution.ParallelLimit = DefaultParallelLimit
 	}
 
+	if config.Execution.GracePeriod == 0 {
+		config.Execution.GracePeriod = DefaultGracePeriod
+	}
+
 	// Global parallel limit
-	if config.ParallelLimit == 0 && config.Execution.Strategy == StrategyParallel {
+	if config.ParallelLimit == 0 && concurrent {
 		config.ParallelLimit = DefaultParallelLimit
 	}
 
@@ -75,9 +86,65 @@ func (l *Loader) applyDefaults(config *Config) {
 		if config.Tenancy.MaxParallel == 0 {
 			config.Tenancy.MaxParallel = DefaultParallelLimit
 		}
+		if config.Tenancy.Mode == TenancyModeSchema && config.Tenancy.Schema == "" {
+			config.Tenancy.Schema = DefaultSchema
+		}
+		if config.Tenancy.Source == nil {
+			config.Tenancy.Source = &TenantSourceConfig{}
+		}
+		// Move the deprecated tenant_source to source.type, keeping it only
+		// when the two conflict so validation can report it
+		source := config.Tenancy.Source
+		if source.Type == "" {
+			source.Type = config.Tenancy.TenantSource
+		}
+		if config.Tenancy.TenantSource == source.Type {
+			config.Tenancy.TenantSource = ""
+		}
+		if source.SQL != nil && source.SQL.IDColumn == "" {
+			source.SQL.IDColumn = DefaultIDColumn
+		}
+		if api := source.HTTP; api != nil {
+			if api.Timeout == 0 {
+				api.Timeout = DefaultHTTPTimeout
+			}
+			if api.Fields != nil && api.Fields.ID == "" {
+				api.Fields.ID = DefaultIDColumn
+			}
+			if p := api.Pagination; p != nil {
+				if p.CursorParam == "" {
+					p.CursorParam = DefaultCursorParam
+				}
+				if p.PageParam == "" {
+					p.PageParam = DefaultPageParam
+				}
+				if p.MaxPages == 0 {
+					p.MaxPages = DefaultMaxPages
+				}
+			}
+		}
+	}
+
+	// Lock defaults
+	if config.Lock != nil && config.Lock.Enabled {
+		if config.Lock.Backend == "" {
+			config.Lock.Backend = DefaultLockBackend
+		}
+		if config.Lock.Name == "" {
+			config.Lock.Name = DefaultLockName
+		}
+		if config.Lock.TTL == 0 {
+			config.Lock.TTL = DefaultLockTTL
+		}
 	}
 
-	// Service defaults - merge global env and set working directory
+	// Retry defaults
+	if config.Retry != nil && config.Retry.Backoff == 0 {
+		config.Retry.Backoff = DefaultRetryBackoff
+	}
+
+	// Service defaults - merge global env, inherit the global retry policy
+	// and set working directory
 	for i := range config.Services {
 		if config.Services[i].Env == nil {
 			config.Services[i].Env = make(map[string]string)
@@ -87,7 +154,13 @@ func (l *Loader) applyDefaults(config *Config) {
 				config.Services[i].Env[k] = v
 			}
 		}
-		
+
+		if config.Services[i].Retry == nil {
+			config.Services[i].Retry = config.Retry
+		} else if config.Services[i].Retry.Backoff == 0 {
+			config.Services[i].Retry.Backoff = DefaultRetryBackoff
+		}
+
 		// Set working directory to path if not specified
 		if config.Services[i].WorkingDir == "" {
 			config.Services[i].WorkingDir = config.Services[i].Path
@@ -125,6 +198,7 @@ func (l *Loader) discoverServices(config *Config) error {
 					svc.Env[k] = v
 				}
 			}
+			svc.Retry = config.Retry
 			config.Services = append(config.Services, svc)
 		}
 	}
@@ -132,6 +206,13 @@ func (l *Loader) discoverServices(config *Config) error {
 	return nil
 }
 
+// ApplyDefaults sets default values on a configuration built in code
+// rather than loaded from a file. It is safe to call more than once.
+func ApplyDefaults(config *Config) {
+	loader := &Loader{}
+	loader.applyDefaults(config)
+}
+
 // LoadFromFile is a convenience function to load config from a file path
 func LoadFromFile(path string) (*Config, error) {
 	loader := NewLoader(path)
@@ -2,15 +2,51 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"text/template"
+	"time"
+
+	"github.com/migra/migra/pkg/migra"
 )
 
+// supportedTypes lists the service types known to the validator, sorted
+var supportedTypes = []string{
+	FrameworkAlembic,
+	FrameworkCommand,
+	FrameworkDbmate,
+	FrameworkDjango,
+	FrameworkEFCore,
+	FrameworkFlyway,
+	FrameworkGolangMigrate,
+	FrameworkGoose,
+	FrameworkKnex,
+	FrameworkLaravel,
+	FrameworkLiquibase,
+	FrameworkPrisma,
+	FrameworkRails,
+	FrameworkSequelize,
+	FrameworkTypeORM,
+}
+
+// isSupportedType reports whether a service type is supported
+func isSupportedType(serviceType string) bool {
+	for _, t := range supportedTypes {
+		if t == serviceType {
+			return true
+		}
+	}
+	return false
+}
+
 // Validator validates configuration
 type Validator struct {
-	config *Config
-	errors []string
+	config  *Config
+	errors  []string
+	allowed map[string]bool
 }
 
 // NewValidator creates a new configuration validator
@@ -21,11 +57,24 @@ func NewValidator(config *Config) *Validator {
 	}
 }
 
+// AllowTypes accepts additional service types, such as adapters
+// registered programmatically
+func (v *Validator) AllowTypes(types ...string) {
+	if v.allowed == nil {
+		v.allowed = make(map[string]bool)
+	}
+	for _, t := range types {
+		v.allowed[t] = true
+	}
+}
+
 // Validate performs comprehensive validation of the configuration
 func (v *Validator) Validate() error {
 	v.validateServices()
+	v.validateDependencies()
 	v.validateExecution()
 	v.validateTenancy()
+	v.validateLock()
 	v.validateLogging()
 
 	if len(v.errors) > 0 {
@@ -44,12 +93,6 @@ func (v *Validator) validateServices() {
 	}
 
 	seenNames := make(map[string]bool)
-	supportedTypes := map[string]bool{
-		FrameworkDjango:  true,
-		FrameworkLaravel: true,
-		FrameworkPrisma:  true,
-	}
-
 	for i, service := range v.config.Services {
 		// Validate name
 		if service.Name == "" {
@@ -63,8 +106,21 @@ func (v *Validator) validateServices() {
 		// Validate type
 		if service.Type == "" {
 			v.addError(fmt.Sprintf("services[%d] (%s): type is required", i, service.Name))
-		} else if !supportedTypes[service.Type] {
-			v.addError(fmt.Sprintf("services[%d] (%s): unsupported type '%s' (supported: django, laravel, prisma)", i, service.Name, service.Type))
+		} else if !isSupportedType(service.Type) && !v.allowed[service.Type] {
+			if _, ok := migra.LookupPlugin(service.Type, v.config.PluginDirs()); !ok {
+				v.addError(fmt.Sprintf("services[%d] (%s): unsupported type '%s' (supported: %s, or a %s%s plugin)", i, service.Name, service.Type, strings.Join(supportedTypes, ", "), migra.PluginPrefix, service.Type))
+			}
+		}
+
+		v.validateCommand(i, &service)
+
+		if service.Timeout < 0 {
+			v.addError(fmt.Sprintf("services[%d] (%s): timeout cannot be negative", i, service.Name))
+		}
+
+		// Services inheriting the global policy are validated once
+		if service.Retry != nil && service.Retry != v.config.Retry {
+			v.validateRetry(fmt.Sprintf("services[%d] (%s): retry", i, service.Name), service.Retry)
 		}
 
 		// Validate path
@@ -84,23 +140,160 @@ func (v *Validator) validateServices() {
 	}
 }
 
+// validateCommand validates the command declaration of a command service
+func (v *Validator) validateCommand(i int, service *migra.Service) {
+	if service.Type != FrameworkCommand {
+		if service.Command != nil {
+			v.addError(fmt.Sprintf("services[%d] (%s): command is only supported for type '%s'", i, service.Name, FrameworkCommand))
+		}
+		return
+	}
+
+	prefix := fmt.Sprintf("services[%d] (%s)", i, service.Name)
+	if service.Command == nil || len(service.Command.Deploy) == 0 {
+		v.addError(fmt.Sprintf("%s: command.deploy is required for type '%s'", prefix, FrameworkCommand))
+		return
+	}
+
+	commands := map[string][]string{
+		"deploy":   service.Command.Deploy,
+		"rollback": service.Command.Rollback,
+		"status":   service.Command.Status,
+	}
+	for _, name := range []string{"deploy", "rollback", "status"} {
+		for _, arg := range commands[name] {
+			if _, err := template.New(name).Parse(arg); err != nil {
+				v.addError(fmt.Sprintf("%s: invalid template in command.%s: %v", prefix, name, err))
+			}
+		}
+	}
+
+	parser := service.Command.StatusParser
+	if parser == nil {
+		return
+	}
+
+	switch parser.Format {
+	case migra.StatusFormatRegex:
+		for field, pattern := range map[string]string{"applied": parser.Applied, "pending": parser.Pending} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				v.addError(fmt.Sprintf("%s: invalid command.status_parser.%s pattern: %v", prefix, field, err))
+			}
+		}
+	case migra.StatusFormatJSON:
+	default:
+		v.addError(fmt.Sprintf("%s: command.status_parser.format must be '%s' or '%s'", prefix, migra.StatusFormatRegex, migra.StatusFormatJSON))
+	}
+
+	if parser.Applied == "" && parser.Pending == "" {
+		v.addError(fmt.Sprintf("%s: command.status_parser needs an applied or pending expression", prefix))
+	}
+	if len(service.Command.Status) == 0 {
+		v.addError(fmt.Sprintf("%s: command.status_parser requires command.status", prefix))
+	}
+}
+
+// validateDependencies validates service dependencies and detects cycles
+func (v *Validator) validateDependencies() {
+	byName := make(map[string]*migra.Service, len(v.config.Services))
+	for i := range v.config.Services {
+		byName[v.config.Services[i].Name] = &v.config.Services[i]
+	}
+
+	for i, service := range v.config.Services {
+		for _, dep := range service.DependsOn {
+			if dep == service.Name {
+				v.addError(fmt.Sprintf("services[%d] (%s): service cannot depend on itself", i, service.Name))
+			} else if _, ok := byName[dep]; !ok {
+				v.addError(fmt.Sprintf("services[%d] (%s): depends on unknown service '%s'", i, service.Name, dep))
+			}
+		}
+		// Only the dag strategy waits for dependencies
+		if len(service.DependsOn) > 0 && v.config.Execution.Strategy != StrategyDAG {
+			v.addError(fmt.Sprintf("services[%d] (%s): depends_on requires execution.strategy '%s', got '%s'", i, service.Name, StrategyDAG, v.config.Execution.Strategy))
+		}
+	}
+
+	// Depth-first search, tracking the current path to report the cycle
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(byName))
+	path := make([]string, 0)
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch marks[name] {
+		case visiting:
+			start := 0
+			for i, n := range path {
+				if n == name {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			v.addError(fmt.Sprintf("dependency cycle detected: %s", strings.Join(cycle, " -> ")))
+			return true
+		case visited:
+			return false
+		}
+
+		marks[name] = visiting
+		path = append(path, name)
+		for _, dep := range byName[name].DependsOn {
+			if dep == name {
+				continue
+			}
+			if _, ok := byName[dep]; ok && visit(dep) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		marks[name] = visited
+		return false
+	}
+
+	for _, service := range v.config.Services {
+		if marks[service.Name] == unvisited && visit(service.Name) {
+			// Report a single cycle; the rest are usually the same loop
+			return
+		}
+	}
+}
+
 // validateExecution validates execution configuration
 func (v *Validator) validateExecution() {
 	// Validate strategy
-	if v.config.Execution.Strategy != StrategySequential && v.config.Execution.Strategy != StrategyParallel {
-		v.addError(fmt.Sprintf("execution.strategy must be 'sequential' or 'parallel', got '%s'", v.config.Execution.Strategy))
+	strategy := v.config.Execution.Strategy
+	if strategy != StrategySequential && strategy != StrategyParallel && strategy != StrategyDAG {
+		v.addError(fmt.Sprintf("execution.strategy must be 'sequential', 'parallel', or 'dag', got '%s'", strategy))
 	}
 
 	// Validate parallel limit
-	if v.config.Execution.Strategy == StrategyParallel {
+	if strategy == StrategyParallel || strategy == StrategyDAG {
 		if v.config.Execution.ParallelLimit < 1 {
-			v.addError("execution.parallel_limit must be at least 1 for parallel execution")
+			v.addError(fmt.Sprintf("execution.parallel_limit must be at least 1 for %s execution", strategy))
 		}
 		if v.config.Execution.ParallelLimit > 100 {
 			v.addError("execution.parallel_limit should not exceed 100")
 		}
 	}
 
+	// Timeouts
+	if v.config.Execution.Timeout < 0 {
+		v.addError("execution.timeout cannot be negative")
+	}
+	if v.config.Execution.GracePeriod < 0 {
+		v.addError("execution.grace_period cannot be negative")
+	}
+
+	if v.config.Retry != nil {
+		v.validateRetry("retry", v.config.Retry)
+	}
+
 	// Global parallel limit
 	if v.config.ParallelLimit < 0 {
 		v.addError("parallel_limit cannot be negative")
@@ -110,6 +303,27 @@ func (v *Validator) validateExecution() {
 	}
 }
 
+// validateRetry validates a global or service retry policy
+func (v *Validator) validateRetry(prefix string, policy *migra.RetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		v.addError(fmt.Sprintf("%s.max_attempts must be at least 1", prefix))
+	}
+	if policy.Backoff < 0 {
+		v.addError(fmt.Sprintf("%s.backoff cannot be negative", prefix))
+	}
+	if policy.MaxBackoff < 0 {
+		v.addError(fmt.Sprintf("%s.max_backoff cannot be negative", prefix))
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		v.addError(fmt.Sprintf("%s.jitter must be between 0 and 1", prefix))
+	}
+	for _, pattern := range policy.RetryOn {
+		if _, err := regexp.Compile(pattern); err != nil {
+			v.addError(fmt.Sprintf("%s: invalid retry_on pattern: %v", prefix, err))
+		}
+	}
+}
+
 // validateTenancy validates tenancy configuration
 func (v *Validator) validateTenancy() {
 	if v.config.Tenancy == nil || !v.config.Tenancy.Enabled {
@@ -123,15 +337,17 @@ func (v *Validator) validateTenancy() {
 		v.addError(fmt.Sprintf("tenancy.mode must be 'database_per_tenant' or 'schema_per_tenant', got '%s'", tenancy.Mode))
 	}
 
-	// Validate tenant source
-	if tenancy.TenantSource == "" {
-		v.addError("tenancy.tenant_source is required when tenancy is enabled")
-	} else if tenancy.TenantSource != TenantSourceEnv && 
-		tenancy.TenantSource != TenantSourceFile && 
-		tenancy.TenantSource != TenantSourceCommand {
-		v.addError(fmt.Sprintf("tenancy.tenant_source must be 'env', 'file', or 'command', got '%s'", tenancy.TenantSource))
+	if tenancy.Mode == TenancyModeSchema {
+		if !strings.Contains(tenancy.Schema, "{id}") {
+			v.addError("tenancy.schema must contain {id} so every tenant gets its own schema")
+		}
+	} else if tenancy.Schema != "" || tenancy.CreateSchema {
+		v.addError("tenancy.schema and tenancy.create_schema require mode 'schema_per_tenant'")
 	}
 
+	// Validate tenant source
+	v.validateTenantSource(tenancy)
+
 	// Validate max parallel
 	if tenancy.MaxParallel < 1 {
 		v.addError("tenancy.max_parallel must be at least 1")
@@ -139,6 +355,224 @@ func (v *Validator) validateTenancy() {
 	if tenancy.MaxParallel > 1000 {
 		v.addError("tenancy.max_parallel should not exceed 1000")
 	}
+
+	if tenancy.Timeout < 0 {
+		v.addError("tenancy.timeout cannot be negative")
+	}
+
+	if tenancy.Rollout != nil {
+		v.validateRollout(tenancy.Rollout)
+	}
+
+	for env, selector := range tenancy.Selectors {
+		if _, err := migra.ParseSelector(selector); err != nil {
+			v.addError(fmt.Sprintf("tenancy.selectors.%s: %v", env, err))
+		}
+	}
+}
+
+// validateSQLSource validates the query tenant source
+func (v *Validator) validateSQLSource(source *SQLSourceConfig) {
+	if source == nil {
+		v.addError("tenancy.source.sql is required for the sql tenant source")
+		return
+	}
+
+	switch source.Driver {
+	case SQLDriverPostgres, SQLDriverMySQL, SQLDriverSQLite:
+	default:
+		v.addError(fmt.Sprintf("tenancy.source.sql.driver must be 'postgres', 'mysql', or 'sqlite', got '%s'", source.Driver))
+	}
+	if source.DSN == "" && source.DSNEnv == "" {
+		v.addError("tenancy.source.sql.dsn or dsn_env is required")
+	}
+	if strings.TrimSpace(source.Query) == "" {
+		v.addError("tenancy.source.sql.query is required")
+	}
+	for name, column := range source.Connection {
+		if column == "" {
+			v.addError(fmt.Sprintf("tenancy.source.sql.connection.%s must name a column", name))
+		}
+	}
+	for name, column := range source.Labels {
+		if column == "" {
+			v.addError(fmt.Sprintf("tenancy.source.sql.labels.%s must name a column", name))
+		}
+	}
+}
+
+// validateTenantSource validates the type and options of the
+// tenancy.source block
+func (v *Validator) validateTenantSource(tenancy *TenancyConfig) {
+	source := tenancy.Source
+	if source == nil {
+		source = &TenantSourceConfig{}
+	}
+	sourceType := source.Type
+	if sourceType == "" {
+		sourceType = tenancy.TenantSource
+	} else if tenancy.TenantSource != "" && tenancy.TenantSource != sourceType {
+		v.addError(fmt.Sprintf("tenancy.source.type '%s' conflicts with the deprecated tenancy.tenant_source '%s'", sourceType, tenancy.TenantSource))
+		return
+	}
+
+	switch sourceType {
+	case "":
+		v.addError("tenancy.source.type is required when tenancy is enabled")
+		return
+	case TenantSourceEnv, TenantSourceFile, TenantSourceCommand:
+	case TenantSourceSQL:
+		v.validateSQLSource(source.SQL)
+	case TenantSourceHTTP:
+		v.validateHTTPSource(source.HTTP)
+	default:
+		v.addError(fmt.Sprintf("tenancy.source.type must be 'env', 'file', 'command', 'sql', or 'http', got '%s'", sourceType))
+		return
+	}
+
+	if source.SQL != nil && sourceType != TenantSourceSQL {
+		v.addError("tenancy.source.sql requires type 'sql'")
+	}
+	if source.HTTP != nil && sourceType != TenantSourceHTTP {
+		v.addError("tenancy.source.http requires type 'http'")
+	}
+	if source.Env != "" && sourceType != TenantSourceEnv {
+		v.addError("tenancy.source.env requires type 'env'")
+	}
+	if source.File != "" && sourceType != TenantSourceFile {
+		v.addError("tenancy.source.file requires type 'file'")
+	}
+	if (len(source.Command) > 0 || source.Shell || source.Dir != "") && sourceType != TenantSourceCommand {
+		v.addError("tenancy.source.command, shell and dir require type 'command'")
+		return
+	}
+
+	if len(source.Command) > 0 && strings.TrimSpace(source.Command[0]) == "" {
+		v.addError("tenancy.source.command cannot start with an empty program")
+	}
+	if source.Shell && len(source.Command) > 1 {
+		v.addError(fmt.Sprintf("tenancy.source.command must be a single script in shell mode, got %d entries", len(source.Command)))
+	}
+}
+
+// validateHTTPSource validates the JSON API tenant source
+func (v *Validator) validateHTTPSource(source *HTTPSourceConfig) {
+	if source == nil {
+		v.addError("tenancy.source.http is required for the http tenant source")
+		return
+	}
+
+	if u, err := url.Parse(source.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addError(fmt.Sprintf("tenancy.source.http.url must be an http or https URL, got '%s'", source.URL))
+	}
+	if source.Timeout < 0 {
+		v.addError("tenancy.source.http.timeout cannot be negative")
+	}
+
+	if auth := source.Auth; auth != nil {
+		switch auth.Type {
+		case HTTPAuthBearer:
+			if auth.TokenEnv == "" {
+				v.addError("tenancy.source.http.auth.token_env is required for bearer auth")
+			}
+		case HTTPAuthBasic:
+			if auth.UsernameEnv == "" || auth.PasswordEnv == "" {
+				v.addError("tenancy.source.http.auth.username_env and password_env are required for basic auth")
+			}
+		default:
+			v.addError(fmt.Sprintf("tenancy.source.http.auth.type must be 'bearer' or 'basic', got '%s'", auth.Type))
+		}
+	}
+
+	if p := source.Pagination; p != nil {
+		switch p.Type {
+		case PaginationCursor:
+			if p.CursorPath == "" {
+				v.addError("tenancy.source.http.pagination.cursor_path is required for cursor pagination")
+			}
+		case PaginationPage:
+		default:
+			v.addError(fmt.Sprintf("tenancy.source.http.pagination.type must be 'cursor' or 'page', got '%s'", p.Type))
+		}
+		if p.Size < 0 {
+			v.addError("tenancy.source.http.pagination.size cannot be negative")
+		}
+		if p.MaxPages < 1 {
+			v.addError("tenancy.source.http.pagination.max_pages must be at least 1")
+		}
+	}
+}
+
+// validateRollout validates the waves of a staged tenant rollout
+func (v *Validator) validateRollout(rollout *RolloutConfig) {
+	if len(rollout.Waves) == 0 {
+		v.addError("tenancy.rollout.waves must define at least one wave")
+	}
+	if rollout.MaxFailurePercent < 0 || rollout.MaxFailurePercent > 100 {
+		v.addError("tenancy.rollout.max_failure_percent must be between 0 and 100")
+	}
+	if rollout.Pause < 0 {
+		v.addError("tenancy.rollout.pause cannot be negative")
+	}
+
+	lastPercent := 0.0
+	seenTenants := make(map[string]bool)
+	for i, wave := range rollout.Waves {
+		prefix := fmt.Sprintf("tenancy.rollout.waves[%d]", i)
+		if wave.Name != "" {
+			prefix = fmt.Sprintf("%s (%s)", prefix, wave.Name)
+		}
+
+		switch {
+		case len(wave.Tenants) > 0 && wave.Percent != 0:
+			v.addError(fmt.Sprintf("%s: set either tenants or percent, not both", prefix))
+		case len(wave.Tenants) > 0:
+			for _, id := range wave.Tenants {
+				if seenTenants[id] {
+					v.addError(fmt.Sprintf("%s: tenant '%s' is already in an earlier wave", prefix, id))
+				}
+				seenTenants[id] = true
+			}
+		case wave.Percent != 0:
+			if wave.Percent < 0 || wave.Percent > 100 {
+				v.addError(fmt.Sprintf("%s: percent must be between 0 and 100", prefix))
+			} else if wave.Percent <= lastPercent {
+				v.addError(fmt.Sprintf("%s: percent must be greater than the previous wave's", prefix))
+			}
+			lastPercent = wave.Percent
+		default:
+			if i != len(rollout.Waves)-1 {
+				v.addError(fmt.Sprintf("%s: only the last wave may omit tenants and percent", prefix))
+			}
+		}
+
+		if wave.MaxFailurePercent != nil && (*wave.MaxFailurePercent < 0 || *wave.MaxFailurePercent > 100) {
+			v.addError(fmt.Sprintf("%s: max_failure_percent must be between 0 and 100", prefix))
+		}
+	}
+}
+
+// validateLock validates run locking configuration
+func (v *Validator) validateLock() {
+	if v.config.Lock == nil || !v.config.Lock.Enabled {
+		return
+	}
+
+	lock := v.config.Lock
+
+	switch lock.Backend {
+	case LockBackendFile:
+	case LockBackendPostgres:
+		if lock.DSN == "" && lock.DSNEnv == "" {
+			v.addError("lock.dsn or lock.dsn_env is required for the postgres lock backend")
+		}
+	default:
+		v.addError(fmt.Sprintf("lock.backend must be 'file' or 'postgres', got '%s'", lock.Backend))
+	}
+
+	if lock.TTL < time.Second {
+		v.addError("lock.ttl must be at least 1s")
+	}
 }
 
 // validateLogging validates logging configuration
@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/state"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// DAGEngine executes migrations following the service dependency graph.
+// Independent services run in parallel, but a service is never started
+// until all of its dependencies have succeeded.
+type DAGEngine struct {
+	*serviceRunner
+	stopOnFailure bool
+	maxParallel   int
+}
+
+// NewDAGEngine creates a new dependency graph execution engine
+func NewDAGEngine(registry *adapter.Registry, stateManager *state.Manager, log logger.Logger, stopOnFailure, dryRun bool, maxParallel int) *DAGEngine {
+	if maxParallel <= 0 {
+		maxParallel = 5
+	}
+	return &DAGEngine{
+		serviceRunner: newServiceRunner(registry, stateManager, log, dryRun),
+		stopOnFailure: stopOnFailure,
+		maxParallel:   maxParallel,
+	}
+}
+
+// Execute executes migrations in dependency order.
+// For rollbacks the graph is reversed so dependents are rolled back first.
+func (e *DAGEngine) Execute(ctx context.Context, services []migra.Service, operation migra.Operation) ([]migra.ServiceResult, error) {
+	deps, err := buildDependencies(services, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]migra.ServiceResult, len(services))
+
+	// done[i] is closed once service i has finished, failed or been skipped
+	done := make([]chan struct{}, len(services))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	// Create semaphore for limiting concurrency
+	semaphore := make(chan struct{}, e.maxParallel)
+	var wg sync.WaitGroup
+
+	// Context for cancellation on failure
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i, service := range services {
+		wg.Add(1)
+
+		go func(idx int, svc migra.Service) {
+			defer wg.Done()
+			defer close(done[idx])
+
+			// Wait for dependencies to finish
+			for _, dep := range deps[idx] {
+				select {
+				case <-done[dep]:
+				case <-execCtx.Done():
+					return
+				}
+
+				depResult := results[dep]
+				if depResult.ServiceName == "" {
+					// Dependency never ran because execution was stopped
+					return
+				}
+				if !depResult.Success {
+					results[idx] = skippedResult(svc.Name, depResult)
+					e.finished(operation, results[idx])
+					e.logger.Warn(fmt.Sprintf("Skipping service %s: %s", svc.Name, results[idx].Error),
+						logger.F("service", svc.Name),
+						logger.F("dependency", depResult.ServiceName),
+					)
+					return
+				}
+			}
+
+			// Acquire semaphore
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-execCtx.Done():
+				return
+			}
+
+			e.logger.Info(fmt.Sprintf("Executing %s for service: %s", operation, svc.Name),
+				logger.F("service", svc.Name),
+				logger.F("type", svc.Type),
+			)
+
+			result := e.executeService(execCtx, &svc, operation)
+			results[idx] = result
+
+			if !result.Success {
+				e.logger.Error(fmt.Sprintf("Service %s failed", svc.Name),
+					logger.F("service", svc.Name),
+					logger.F("error", result.Error),
+				)
+
+				if e.stopOnFailure {
+					cancel()
+				}
+			} else {
+				e.logger.Info(fmt.Sprintf("Service %s completed successfully", svc.Name),
+					logger.F("service", svc.Name),
+					logger.F("duration", result.Duration.String()),
+				)
+			}
+		}(i, service)
+	}
+
+	wg.Wait()
+
+	// Filter out empty results (from stopped executions)
+	filteredResults := make([]migra.ServiceResult, 0, len(results))
+	for _, r := range results {
+		if r.ServiceName != "" {
+			filteredResults = append(filteredResults, r)
+		}
+	}
+
+	return filteredResults, nil
+}
+
+// skippedResult builds the result for a service whose dependency did not succeed
+func skippedResult(serviceName string, dep migra.ServiceResult) migra.ServiceResult {
+	reason := "failed"
+	if dep.Skipped {
+		reason = "was skipped"
+	}
+	return migra.ServiceResult{
+		ServiceName: serviceName,
+		Success:     false,
+		Skipped:     true,
+		Error:       fmt.Sprintf("skipped: dependency %s %s", dep.ServiceName, reason),
+	}
+}
+
+// Order returns services in an order that runs every service after its
+// dependencies, or before its dependents for rollbacks. Services keep
+// their listed order (reversed for rollbacks) where the graph allows.
+func Order(services []migra.Service, operation migra.Operation) ([]migra.Service, error) {
+	deps, err := buildDependencies(services, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, len(services))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if operation == migra.OperationRollback {
+		for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		}
+	}
+
+	placed := make([]bool, len(services))
+	ready := func(i int) bool {
+		for _, j := range deps[i] {
+			if !placed[j] {
+				return false
+			}
+		}
+		return true
+	}
+
+	ordered := make([]migra.Service, 0, len(services))
+	for len(ordered) < len(services) {
+		for _, i := range indexes {
+			if !placed[i] && ready(i) {
+				placed[i] = true
+				ordered = append(ordered, services[i])
+				break
+			}
+		}
+	}
+	return ordered, nil
+}
+
+// buildDependencies returns, for each service index, the indexes of the
+// services that must finish first. Dependencies outside the given set
+// (e.g. when running a single filtered service) are ignored.
+func buildDependencies(services []migra.Service, operation migra.Operation) ([][]int, error) {
+	index := make(map[string]int, len(services))
+	for i, svc := range services {
+		index[svc.Name] = i
+	}
+
+	deps := make([][]int, len(services))
+	for i, svc := range services {
+		for _, name := range svc.DependsOn {
+			j, ok := index[name]
+			if !ok {
+				continue
+			}
+			if operation == migra.OperationRollback {
+				deps[j] = append(deps[j], i)
+			} else {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	// Kahn's algorithm - anything left unvisited is part of a cycle
+	pending := make([]int, len(services))
+	dependents := make([][]int, len(services))
+	for i, d := range deps {
+		pending[i] = len(d)
+		for _, j := range d {
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	queue := make([]int, 0, len(services))
+	for i, n := range pending {
+		if n == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	visited := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, j := range dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	if visited != len(services) {
+		cyclic := make([]string, 0)
+		for i, n := range pending {
+			if n > 0 {
+				cyclic = append(cyclic, services[i].Name)
+			}
+		}
+		sort.Strings(cyclic)
+		return nil, fmt.Errorf("dependency cycle detected between services: %s", strings.Join(cyclic, ", "))
+	}
+
+	return deps, nil
+}
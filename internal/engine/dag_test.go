@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/state"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAdapter records the order services were deployed in, delays
+// the services listed in delay and fails the services listed in fail
+type recordingAdapter struct {
+	mu       sync.Mutex
+	order    []string
+	finished []string
+	delay    map[string]time.Duration
+	fail     map[string]bool
+}
+
+func (a *recordingAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	a.mu.Lock()
+	a.order = append(a.order, service.Name)
+	a.mu.Unlock()
+
+	time.Sleep(a.delay[service.Name])
+
+	a.mu.Lock()
+	a.finished = append(a.finished, service.Name)
+	a.mu.Unlock()
+
+	if a.fail[service.Name] {
+		return &migra.Result{Success: false, Error: "boom", Timestamp: time.Now()}, nil
+	}
+	return &migra.Result{Success: true, Timestamp: time.Now()}, nil
+}
+
+func (a *recordingAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	return a.Deploy(ctx, service, tenant)
+}
+
+func (a *recordingAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	return &migra.StatusResult{}, nil
+}
+
+func (a *recordingAdapter) Name() string {
+	return "fake"
+}
+
+func (a *recordingAdapter) position(name string) int {
+	for i, n := range a.order {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func newTestDAGEngine(t *testing.T, adp migra.Adapter, stopOnFailure bool) *DAGEngine {
+	registry := adapter.NewRegistry()
+	registry.Register("fake", adp)
+
+	stateManager := state.NewManager(t.TempDir())
+	require.NoError(t, stateManager.Load())
+
+	log := logger.NewLogger("console", logger.LevelError, false, true)
+	return NewDAGEngine(registry, stateManager, log, stopOnFailure, false, 4)
+}
+
+func resultsByName(results []migra.ServiceResult) map[string]migra.ServiceResult {
+	byName := make(map[string]migra.ServiceResult, len(results))
+	for _, r := range results {
+		byName[r.ServiceName] = r
+	}
+	return byName
+}
+
+func TestDAGEngine(t *testing.T) {
+	services := []migra.Service{
+		{Name: "api", Type: "fake", DependsOn: []string{"auth", "billing"}},
+		{Name: "auth", Type: "fake"},
+		{Name: "billing", Type: "fake", DependsOn: []string{"auth"}},
+		{Name: "search", Type: "fake"},
+	}
+
+	t.Run("runs dependencies first", func(t *testing.T) {
+		adp := &recordingAdapter{}
+		eng := newTestDAGEngine(t, adp, true)
+
+		results, err := eng.Execute(context.Background(), services, migra.OperationDeploy)
+		require.NoError(t, err)
+		assert.Len(t, results, 4)
+
+		assert.Less(t, adp.position("auth"), adp.position("billing"))
+		assert.Less(t, adp.position("billing"), adp.position("api"))
+		for _, r := range results {
+			assert.True(t, r.Success, r.ServiceName)
+		}
+	})
+
+	t.Run("rollback runs dependents first", func(t *testing.T) {
+		adp := &recordingAdapter{}
+		eng := newTestDAGEngine(t, adp, true)
+
+		_, err := eng.Execute(context.Background(), services, migra.OperationRollback)
+		require.NoError(t, err)
+
+		assert.Less(t, adp.position("api"), adp.position("billing"))
+		assert.Less(t, adp.position("billing"), adp.position("auth"))
+	})
+
+	t.Run("skips dependents of failed service", func(t *testing.T) {
+		adp := &recordingAdapter{fail: map[string]bool{"auth": true}}
+		eng := newTestDAGEngine(t, adp, false)
+
+		results, err := eng.Execute(context.Background(), services, migra.OperationDeploy)
+		require.NoError(t, err)
+
+		byName := resultsByName(results)
+		assert.False(t, byName["auth"].Success)
+		assert.False(t, byName["auth"].Skipped)
+
+		assert.True(t, byName["billing"].Skipped)
+		assert.Equal(t, "skipped: dependency auth failed", byName["billing"].Error)
+		assert.True(t, byName["api"].Skipped)
+		assert.Contains(t, byName["api"].Error, "skipped: dependency")
+
+		// Independent services still run
+		assert.True(t, byName["search"].Success)
+		assert.Equal(t, -1, adp.position("api"))
+		assert.Equal(t, -1, adp.position("billing"))
+
+		summary := SummarizeResults(results, time.Second)
+		assert.Equal(t, 1, summary.TotalSuccess)
+		assert.Equal(t, 1, summary.TotalFailure)
+		assert.Equal(t, 2, summary.TotalSkipped)
+	})
+
+	t.Run("ignores dependencies outside the service set", func(t *testing.T) {
+		adp := &recordingAdapter{}
+		eng := newTestDAGEngine(t, adp, true)
+
+		results, err := eng.Execute(context.Background(), services[:1], migra.OperationDeploy)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Success)
+	})
+
+	t.Run("rejects cycles", func(t *testing.T) {
+		adp := &recordingAdapter{}
+		eng := newTestDAGEngine(t, adp, true)
+
+		cyclic := []migra.Service{
+			{Name: "a", Type: "fake", DependsOn: []string{"b"}},
+			{Name: "b", Type: "fake", DependsOn: []string{"a"}},
+		}
+		_, err := eng.Execute(context.Background(), cyclic, migra.OperationDeploy)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "a, b")
+	})
+}
+
+func TestResolveStrategy(t *testing.T) {
+	services := []migra.Service{
+		{Name: "api", Type: "fake", DependsOn: []string{"auth"}},
+		{Name: "auth", Type: "fake"},
+	}
+
+	assert.Equal(t, config.StrategyDAG, ResolveStrategy(config.StrategyParallel, services))
+	assert.Equal(t, config.StrategyDAG, ResolveStrategy(config.StrategySequential, services))
+	assert.Equal(t, config.StrategyParallel, ResolveStrategy(config.StrategyParallel, services[1:]))
+
+	t.Run("parallel still waits for dependencies", func(t *testing.T) {
+		adp := &recordingAdapter{delay: map[string]time.Duration{"auth": 50 * time.Millisecond}}
+		registry := adapter.NewRegistry()
+		registry.Register("fake", adp)
+
+		stateManager := state.NewManager(t.TempDir())
+		require.NoError(t, stateManager.Load())
+		log := logger.NewLogger("console", logger.LevelError, false, true)
+
+		strategy := ResolveStrategy(config.StrategyParallel, services)
+		eng := New(strategy, registry, stateManager, log, true, false, 4)
+
+		results, err := eng.Execute(context.Background(), services, migra.OperationDeploy)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		// api only starts once auth has finished
+		assert.Equal(t, []string{"auth", "api"}, adp.order)
+		assert.Equal(t, []string{"auth", "api"}, adp.finished)
+	})
+}
+
+func TestOrder(t *testing.T) {
+	services := []migra.Service{
+		{Name: "api", Type: "fake", DependsOn: []string{"auth", "billing"}},
+		{Name: "auth", Type: "fake"},
+		{Name: "billing", Type: "fake", DependsOn: []string{"auth"}},
+		{Name: "search", Type: "fake"},
+	}
+
+	names := func(services []migra.Service) []string {
+		result := make([]string, len(services))
+		for i, svc := range services {
+			result[i] = svc.Name
+		}
+		return result
+	}
+
+	t.Run("deploy", func(t *testing.T) {
+		ordered, err := Order(services, migra.OperationDeploy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"auth", "billing", "api", "search"}, names(ordered))
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		ordered, err := Order(services, migra.OperationRollback)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"search", "api", "billing", "auth"}, names(ordered))
+	})
+
+	t.Run("keeps listed order without dependencies", func(t *testing.T) {
+		plain := []migra.Service{{Name: "b"}, {Name: "a"}}
+		ordered, err := Order(plain, migra.OperationDeploy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, names(ordered))
+	})
+}
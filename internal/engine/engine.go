@@ -4,12 +4,44 @@ import (
 	"context"
 	"time"
 
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/state"
 	"github.com/migra/migra/pkg/migra"
 )
 
 // Engine defines the interface for migration execution
 type Engine interface {
 	Execute(ctx context.Context, services []migra.Service, operation migra.Operation) ([]migra.ServiceResult, error)
+	SetRollback(steps int, target string)
+	SetEventHandler(handler migra.EventHandler)
+	SetResume(point *state.ResumePoint)
+}
+
+// New creates the execution engine for a strategy. Unknown strategies
+// fall back to sequential execution.
+func New(strategy string, registry *adapter.Registry, stateManager *state.Manager, log logger.Logger, stopOnFailure, dryRun bool, parallelLimit int) Engine {
+	switch strategy {
+	case config.StrategyParallel:
+		return NewParallelEngine(registry, stateManager, log, stopOnFailure, dryRun, parallelLimit)
+	case config.StrategyDAG:
+		return NewDAGEngine(registry, stateManager, log, stopOnFailure, dryRun, parallelLimit)
+	default:
+		return NewSequentialEngine(registry, stateManager, log, stopOnFailure, dryRun)
+	}
+}
+
+// ResolveStrategy returns the strategy to run services with. Only the
+// dag strategy honours depends_on, so it is used whenever a service
+// declares dependencies.
+func ResolveStrategy(strategy string, services []migra.Service) string {
+	for _, svc := range services {
+		if len(svc.DependsOn) > 0 {
+			return config.StrategyDAG
+		}
+	}
+	return strategy
 }
 
 // ExecutionOptions contains options for execution
@@ -24,5 +56,9 @@ type Result struct {
 	Services     []migra.ServiceResult
 	TotalSuccess int
 	TotalFailure int
+	TotalSkipped int
+	TotalTimeout int
+	TotalRetried int
+	TotalResumed int
 	Duration     time.Duration
 }
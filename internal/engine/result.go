@@ -14,10 +14,21 @@ func SummarizeResults(results []migra.ServiceResult, totalDuration time.Duration
 	}
 
 	for _, r := range results {
-		if r.Success {
+		if len(r.Attempts) > 1 {
+			summary.TotalRetried++
+		}
+		if r.Resumed {
+			summary.TotalResumed++
+		}
+		if r.Skipped {
+			summary.TotalSkipped++
+		} else if r.Success {
 			summary.TotalSuccess++
 		} else {
 			summary.TotalFailure++
+			if r.TimedOut {
+				summary.TotalTimeout++
+			}
 		}
 	}
 
@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/process"
+	"github.com/migra/migra/internal/retry"
+	"github.com/migra/migra/internal/state"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// serviceRunner executes a single operation for a service and records the
+// outcome. It is shared by all execution engines.
+type serviceRunner struct {
+	registry       *adapter.Registry
+	stateManager   *state.Manager
+	logger         logger.Logger
+	dryRun         bool
+	rollbackSteps  int
+	rollbackTarget string
+	resume         *state.ResumePoint
+	onEvent        migra.EventHandler
+}
+
+// newServiceRunner creates a new service runner
+func newServiceRunner(registry *adapter.Registry, stateManager *state.Manager, log logger.Logger, dryRun bool) *serviceRunner {
+	return &serviceRunner{
+		registry:      registry,
+		stateManager:  stateManager,
+		logger:        log,
+		dryRun:        dryRun,
+		rollbackSteps: 1,
+	}
+}
+
+// SetRollback configures how far rollback operations go back.
+// A non-empty target migration takes precedence over steps.
+func (r *serviceRunner) SetRollback(steps int, target string) {
+	r.rollbackSteps = steps
+	r.rollbackTarget = target
+}
+
+// SetResume skips the services that succeeded in the resumed run
+func (r *serviceRunner) SetResume(point *state.ResumePoint) {
+	r.resume = point
+}
+
+// SetEventHandler registers a handler for service started and finished events
+func (r *serviceRunner) SetEventHandler(handler migra.EventHandler) {
+	r.onEvent = handler
+}
+
+// emit sends an event to the registered handler, if any
+func (r *serviceRunner) emit(event migra.Event) {
+	if r.onEvent == nil {
+		return
+	}
+	event.Time = time.Now()
+	r.onEvent(event)
+}
+
+// finished emits the finished event for a service result
+func (r *serviceRunner) finished(operation migra.Operation, result migra.ServiceResult) {
+	r.emit(migra.Event{
+		Type:      migra.EventServiceFinished,
+		Operation: operation,
+		Service:   result.ServiceName,
+		Success:   result.Success,
+		Error:     result.Error,
+		Duration:  result.Duration,
+		Result:    &result,
+	})
+}
+
+// executeService executes migration for a single service under its retry
+// policy, emitting started and finished events around it
+func (r *serviceRunner) executeService(ctx context.Context, service *migra.Service, operation migra.Operation) migra.ServiceResult {
+	if r.resume.Done("", service.Name) {
+		result := r.resumed(service)
+		r.finished(operation, result)
+		return result
+	}
+
+	r.emit(migra.Event{Type: migra.EventServiceStarted, Operation: operation, Service: service.Name})
+
+	result := retry.Do(ctx, retry.ForOperation(service.Retry, operation), func(ctx context.Context) migra.ServiceResult {
+		return r.attemptService(ctx, service, operation)
+	}, func(failed migra.ServiceResult, next int, delay time.Duration) {
+		r.retrying(operation, service, failed, next, delay)
+	})
+
+	r.finished(operation, result)
+	return result
+}
+
+// resumed builds the result of a service that succeeded in the resumed
+// run, carrying it over to the current run
+func (r *serviceRunner) resumed(service *migra.Service) migra.ServiceResult {
+	r.logger.Info(fmt.Sprintf("Skipping %s: already succeeded in run %s", service.Name, r.resume.RunID),
+		logger.F("service", service.Name),
+	)
+	if !r.dryRun {
+		r.stateManager.RecordResumed("", service.Name)
+	}
+	return migra.ServiceResult{
+		ServiceName: service.Name,
+		Success:     true,
+		Resumed:     true,
+		Output:      fmt.Sprintf("already succeeded in run %s", r.resume.RunID),
+	}
+}
+
+// attemptService runs one attempt of a service operation within the
+// service timeout
+func (r *serviceRunner) attemptService(ctx context.Context, service *migra.Service, operation migra.Operation) migra.ServiceResult {
+	opCtx, cancel := process.WithTimeout(ctx, service.Timeout)
+	defer cancel()
+	result := r.runService(opCtx, service, operation)
+
+	// A deadline of the service or of the whole run stopped the operation
+	if process.TimedOut(opCtx) && !result.Success {
+		result.TimedOut = true
+		result.Error = process.TimeoutError(result.Duration)
+	}
+	return result
+}
+
+// retrying logs and emits an upcoming retry of a failed service
+func (r *serviceRunner) retrying(operation migra.Operation, service *migra.Service, failed migra.ServiceResult, next int, delay time.Duration) {
+	r.logger.Warn(fmt.Sprintf("Retrying %s in %s (attempt %d/%d)", service.Name, delay.Round(time.Millisecond), next, service.Retry.MaxAttempts),
+		logger.F("service", service.Name),
+		logger.F("error", failed.Error),
+	)
+	r.emit(migra.Event{
+		Type:      migra.EventServiceRetry,
+		Operation: operation,
+		Service:   service.Name,
+		Error:     failed.Error,
+		Attempt:   next,
+	})
+}
+
+// runService executes migration for a single service
+func (r *serviceRunner) runService(ctx context.Context, service *migra.Service, operation migra.Operation) migra.ServiceResult {
+	start := time.Now()
+
+	result := migra.ServiceResult{
+		ServiceName: service.Name,
+	}
+
+	if r.dryRun {
+		r.logger.Info("[DRY RUN] Would execute migration", logger.F("service", service.Name))
+		result.Success = true
+		result.Duration = time.Since(start)
+		result.Output = "Dry run - no actual execution"
+		return result
+	}
+
+	// Get adapter for service
+	adp, err := r.registry.GetForService(service)
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		result.Duration = time.Since(start)
+		r.stateManager.RecordServiceExecution(service.Name, false, result.Duration, err)
+		return result
+	}
+
+	// Execute operation
+	var opResult *migra.Result
+	switch operation {
+	case migra.OperationDeploy:
+		opResult, err = adp.Deploy(ctx, service, nil)
+	case migra.OperationRollback:
+		opResult, err = r.rollback(ctx, adp, service)
+	case migra.OperationStatus:
+		statusResult, statusErr := adp.Status(ctx, service, nil)
+		if statusErr != nil {
+			err = statusErr
+		}
+		opResult = &migra.Result{
+			Success:   statusErr == nil,
+			Output:    fmt.Sprintf("Applied: %d, Pending: %d", len(statusResult.Applied), len(statusResult.Pending)),
+			Timestamp: time.Now(),
+		}
+	default:
+		err = fmt.Errorf("unsupported operation: %s", operation)
+	}
+
+	result.Duration = time.Since(start)
+
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		if opResult != nil {
+			result.Output = opResult.Output
+			result.LogFile = opResult.LogFile
+		}
+		r.stateManager.RecordServiceExecution(service.Name, false, result.Duration, err)
+		return result
+	}
+
+	result.Success = opResult.Success
+	result.Output = opResult.Output
+	result.LogFile = opResult.LogFile
+	if !opResult.Success {
+		result.Error = opResult.Error
+	}
+
+	r.stateManager.RecordServiceExecution(service.Name, result.Success, result.Duration, nil)
+	return result
+}
+
+// rollback rolls back a service either to the configured target or by steps
+func (r *serviceRunner) rollback(ctx context.Context, adp migra.Adapter, service *migra.Service) (*migra.Result, error) {
+	if r.rollbackTarget == "" {
+		return adp.Rollback(ctx, service, nil, r.rollbackSteps)
+	}
+
+	targeted, ok := adp.(migra.TargetRollbacker)
+	if !ok {
+		return nil, fmt.Errorf("adapter '%s' does not support rollback to a target migration", adp.Name())
+	}
+	return targeted.RollbackTo(ctx, service, nil, r.rollbackTarget)
+}
@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/state"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingAdapter blocks until its context is done, like a hung migration
+type hangingAdapter struct {
+	recordingAdapter
+	hang map[string]bool
+}
+
+func (a *hangingAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	if a.hang[service.Name] {
+		<-ctx.Done()
+		return &migra.Result{Success: false, Error: "signal: terminated", Timestamp: time.Now()}, nil
+	}
+	return a.recordingAdapter.Deploy(ctx, service, tenant)
+}
+
+func newTestSequentialEngine(t *testing.T, adp migra.Adapter) *SequentialEngine {
+	registry := adapter.NewRegistry()
+	registry.Register("fake", adp)
+
+	stateManager := state.NewManager(t.TempDir())
+	require.NoError(t, stateManager.Load())
+
+	log := logger.NewLogger("console", logger.LevelError, false, true)
+	return NewSequentialEngine(registry, stateManager, log, false, false)
+}
+
+func TestServiceTimeout(t *testing.T) {
+	adp := &hangingAdapter{hang: map[string]bool{"billing": true}}
+	eng := newTestSequentialEngine(t, adp)
+
+	services := []migra.Service{
+		{Name: "billing", Type: "fake", Timeout: 50 * time.Millisecond},
+		{Name: "users", Type: "fake", Timeout: time.Minute},
+	}
+
+	results, err := eng.Execute(context.Background(), services, migra.OperationDeploy)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.False(t, results[0].Success)
+	assert.True(t, results[0].TimedOut)
+	assert.Contains(t, results[0].Error, "timed out after")
+
+	// The next service runs with its own timeout
+	assert.True(t, results[1].Success)
+	assert.False(t, results[1].TimedOut)
+
+	summary := SummarizeResults(results, time.Second)
+	assert.Equal(t, 1, summary.TotalFailure)
+	assert.Equal(t, 1, summary.TotalTimeout)
+}
+
+func TestExecutionTimeout(t *testing.T) {
+	adp := &hangingAdapter{hang: map[string]bool{"billing": true}}
+	eng := newTestSequentialEngine(t, adp)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	results, err := eng.Execute(ctx, []migra.Service{{Name: "billing", Type: "fake"}}, migra.OperationDeploy)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].TimedOut)
+
+	// A cancelled run is a failure, not a timeout
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	results, err = eng.Execute(ctx, []migra.Service{{Name: "billing", Type: "fake"}}, migra.OperationDeploy)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.False(t, results[0].TimedOut)
+}
+
+// flakyAdapter fails each service a number of times before succeeding
+type flakyAdapter struct {
+	recordingAdapter
+	failures map[string]int
+}
+
+func (a *flakyAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.order = append(a.order, service.Name)
+
+	if a.failures[service.Name] > 0 {
+		a.failures[service.Name]--
+		return &migra.Result{Success: false, Output: "could not connect to server", Error: "exit status 1", Timestamp: time.Now()}, nil
+	}
+	return &migra.Result{Success: true, Timestamp: time.Now()}, nil
+}
+
+func TestServiceRetry(t *testing.T) {
+	adp := &flakyAdapter{failures: map[string]int{"billing": 2, "users": 5}}
+	eng := newTestSequentialEngine(t, adp)
+	eng.stopOnFailure = false
+
+	events := make([]migra.Event, 0)
+	eng.SetEventHandler(func(e migra.Event) {
+		if e.Type == migra.EventServiceRetry {
+			events = append(events, e)
+		}
+	})
+
+	policy := &migra.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, RetryOn: []string{"could not connect"}}
+	services := []migra.Service{
+		{Name: "billing", Type: "fake", Retry: policy},
+		{Name: "users", Type: "fake", Retry: policy},
+		{Name: "orders", Type: "fake"},
+	}
+
+	results, err := eng.Execute(context.Background(), services, migra.OperationDeploy)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.True(t, results[0].Success)
+	require.Len(t, results[0].Attempts, 3)
+	assert.False(t, results[0].Attempts[0].Success)
+	assert.True(t, results[0].Attempts[2].Success)
+
+	assert.False(t, results[1].Success)
+	assert.Len(t, results[1].Attempts, 3)
+
+	assert.True(t, results[2].Success)
+	assert.Empty(t, results[2].Attempts)
+
+	require.Len(t, events, 4)
+	assert.Equal(t, "billing", events[0].Service)
+	assert.Equal(t, 2, events[0].Attempt)
+
+	// Every attempt is recorded in state
+	billing := eng.stateManager.GetState().Services["billing"]
+	assert.Equal(t, 2, billing.FailureCount)
+	assert.Equal(t, 1, billing.SuccessCount)
+
+	summary := SummarizeResults(results, time.Second)
+	assert.Equal(t, 2, summary.TotalRetried)
+}
+
+// stepAdapter rolls back one migration per step. Its first rollback fails
+// after failAfter steps, like a multi-command rollback hitting a
+// connection blip; later ones succeed.
+type stepAdapter struct {
+	recordingAdapter
+	applied   int
+	failAfter int
+	calls     int
+}
+
+func (a *stepAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	a.calls++
+	for i := 0; i < steps; i++ {
+		if a.calls == 1 && i == a.failAfter {
+			return &migra.Result{Success: false, Output: "could not connect to server", Error: "exit status 1", Timestamp: time.Now()}, nil
+		}
+		a.applied--
+	}
+	return &migra.Result{Success: true, Timestamp: time.Now()}, nil
+}
+
+func TestRollbackNotRetried(t *testing.T) {
+	adp := &stepAdapter{applied: 5, failAfter: 1}
+	eng := newTestSequentialEngine(t, adp)
+	eng.SetRollback(3, "")
+
+	policy := &migra.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	services := []migra.Service{{Name: "billing", Type: "fake", Retry: policy}}
+
+	results, err := eng.Execute(context.Background(), services, migra.OperationRollback)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.False(t, results[0].Success)
+	assert.Empty(t, results[0].Attempts)
+	assert.Equal(t, 1, adp.calls)
+	assert.Equal(t, 4, adp.applied, "only the step before the failure is undone")
+}
@@ -0,0 +1,237 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultLockDir  = ".migra"
+	defaultLockFile = "lock"
+)
+
+// errWouldBlock is returned by tryLockFile when another process holds the lock
+var errWouldBlock = errors.New("lock would block")
+
+// FileBackend implements Backend with an flock on .migra/lock.
+// The lock file holds the holder information as JSON.
+type FileBackend struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// NewFileBackend creates a new file lock backend for the working directory
+func NewFileBackend(workDir string) *FileBackend {
+	return &FileBackend{
+		path: filepath.Join(workDir, defaultLockDir, defaultLockFile),
+	}
+}
+
+// Name returns the backend name
+func (b *FileBackend) Name() string {
+	return "file"
+}
+
+// Close is a no-op for the file backend
+func (b *FileBackend) Close() error {
+	return nil
+}
+
+// Path returns the lock file path
+func (b *FileBackend) Path() string {
+	return b.path
+}
+
+// Acquire takes the file lock. A holder whose TTL has expired is treated
+// as stale and its lock file is replaced.
+func (b *FileBackend) Acquire(ctx context.Context, info *Info) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file != nil {
+		return fmt.Errorf("lock is already held by this process")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := b.tryAcquire()
+		if err == nil {
+			if err := writeInfo(f, info); err != nil {
+				unlockFile(f)
+				f.Close()
+				return err
+			}
+			b.file = f
+			return nil
+		}
+
+		if !errors.Is(err, errWouldBlock) {
+			return err
+		}
+
+		holder, _ := b.readInfo()
+		if holder == nil || !holder.Expired() || attempt > 0 {
+			return &HeldError{Holder: holder}
+		}
+
+		// The holder missed its heartbeat - break the stale lock and retry
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return &HeldError{Holder: holder}
+		}
+	}
+
+	return &HeldError{}
+}
+
+// tryAcquire opens and locks the lock file, making sure the locked file is
+// still the one at the lock path (it may have been force-unlocked)
+func (b *FileBackend) tryAcquire() (*os.File, error) {
+	for {
+		f, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+
+		if err := tryLockFile(f); err != nil {
+			f.Close()
+			if errors.Is(err, errWouldBlock) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to lock %s: %w", b.path, err)
+		}
+
+		locked, err := f.Stat()
+		if err != nil {
+			unlockFile(f)
+			f.Close()
+			return nil, fmt.Errorf("failed to stat lock file: %w", err)
+		}
+		current, err := os.Stat(b.path)
+		if err == nil && os.SameFile(locked, current) {
+			return f, nil
+		}
+
+		// The file was replaced while we were locking it; try again
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// Refresh rewrites the holder information with the new heartbeat
+func (b *FileBackend) Refresh(ctx context.Context, info *Info) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file == nil {
+		return fmt.Errorf("lock is not held")
+	}
+	return writeInfo(b.file, info)
+}
+
+// Release removes the lock file and releases the flock
+func (b *FileBackend) Release(ctx context.Context, info *Info) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file == nil {
+		return nil
+	}
+
+	// Only remove the lock file if it is still ours
+	locked, statErr := b.file.Stat()
+	if current, err := os.Stat(b.path); statErr == nil && err == nil && os.SameFile(locked, current) {
+		os.Remove(b.path)
+	}
+
+	err := unlockFile(b.file)
+	b.file.Close()
+	b.file = nil
+
+	if err != nil {
+		return fmt.Errorf("failed to unlock %s: %w", b.path, err)
+	}
+	return nil
+}
+
+// Status returns the current holder, or nil if the lock is free
+func (b *FileBackend) Status(ctx context.Context) (*Info, error) {
+	f, err := os.OpenFile(b.path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer f.Close()
+
+	if err := tryLockFile(f); err == nil {
+		// Nobody holds the lock
+		unlockFile(f)
+		return nil, nil
+	} else if !errors.Is(err, errWouldBlock) {
+		return nil, fmt.Errorf("failed to check lock %s: %w", b.path, err)
+	}
+
+	holder, err := b.readInfo()
+	if err != nil {
+		return nil, err
+	}
+	if holder == nil {
+		holder = &Info{Holder: "unknown"}
+	}
+	return holder, nil
+}
+
+// ForceUnlock removes the lock file so the next run can acquire a fresh lock.
+// The previous holder is not notified and keeps running.
+func (b *FileBackend) ForceUnlock(ctx context.Context) error {
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// readInfo reads the holder information from the lock file
+func (b *FileBackend) readInfo() (*Info, error) {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file: %w", err)
+	}
+	return &info, nil
+}
+
+// writeInfo replaces the contents of the lock file with the holder information
+func writeInfo(f *os.File, info *Info) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock info: %w", err)
+	}
+
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return f.Sync()
+}
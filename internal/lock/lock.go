@@ -0,0 +1,201 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/migra/migra/internal/config"
+)
+
+// ErrLocked is returned when the lock is held by someone else
+var ErrLocked = errors.New("lock is held by another run")
+
+// Info describes the current holder of a lock
+type Info struct {
+	Holder      string    `json:"holder"`
+	Operation   string    `json:"operation"`
+	PID         int       `json:"pid"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the holder has missed its heartbeat
+func (i *Info) Expired() bool {
+	return !i.ExpiresAt.IsZero() && time.Now().After(i.ExpiresAt)
+}
+
+// String formats the holder for error messages
+func (i *Info) String() string {
+	return fmt.Sprintf("%s (%s, pid %d) since %s, expires %s",
+		i.Holder, i.Operation, i.PID,
+		i.AcquiredAt.Format(time.RFC3339), i.ExpiresAt.Format(time.RFC3339))
+}
+
+// Backend defines the interface for lock storage
+type Backend interface {
+	// Acquire takes the lock or returns a *HeldError wrapping ErrLocked
+	Acquire(ctx context.Context, info *Info) error
+	// Refresh records a heartbeat and extends the expiry of a held lock
+	Refresh(ctx context.Context, info *Info) error
+	// Release releases a held lock
+	Release(ctx context.Context, info *Info) error
+	// Status returns the current holder, or nil if the lock is free
+	Status(ctx context.Context) (*Info, error)
+	// ForceUnlock breaks the lock regardless of who holds it
+	ForceUnlock(ctx context.Context) error
+	// Close releases any resources held by the backend
+	Close() error
+	Name() string
+}
+
+// NewBackend creates the lock backend described by the configuration
+func NewBackend(cfg *config.LockConfig, workDir string) (Backend, error) {
+	switch cfg.Backend {
+	case config.LockBackendFile:
+		return NewFileBackend(workDir), nil
+	case config.LockBackendPostgres:
+		dsn := cfg.DSN
+		if cfg.DSNEnv != "" {
+			dsn = os.Getenv(cfg.DSNEnv)
+			if dsn == "" {
+				return nil, fmt.Errorf("%s environment variable not set", cfg.DSNEnv)
+			}
+		}
+		return NewPostgresBackend(dsn, cfg.Name)
+	default:
+		return nil, fmt.Errorf("unsupported lock backend: %s", cfg.Backend)
+	}
+}
+
+// HeldError reports who holds a lock
+type HeldError struct {
+	Holder *Info
+}
+
+func (e *HeldError) Error() string {
+	if e.Holder == nil {
+		return ErrLocked.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrLocked.Error(), e.Holder)
+}
+
+// Unwrap allows errors.Is(err, ErrLocked)
+func (e *HeldError) Unwrap() error {
+	return ErrLocked
+}
+
+// Lock is a held lock that keeps itself alive with heartbeats
+type Lock struct {
+	backend  Backend
+	info     *Info
+	ttl      time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	mu       sync.Mutex
+	released bool
+}
+
+// Acquire takes the lock on the backend and starts a heartbeat that
+// refreshes it every third of the TTL until Release is called
+func Acquire(ctx context.Context, backend Backend, operation string, ttl time.Duration) (*Lock, error) {
+	now := time.Now()
+	info := &Info{
+		Holder:      CurrentHolder(),
+		Operation:   operation,
+		PID:         os.Getpid(),
+		AcquiredAt:  now,
+		HeartbeatAt: now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	if err := backend.Acquire(ctx, info); err != nil {
+		return nil, err
+	}
+
+	l := &Lock{
+		backend: backend,
+		info:    info,
+		ttl:     ttl,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.heartbeat()
+
+	return l, nil
+}
+
+// Info returns the lock holder information
+func (l *Lock) Info() *Info {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	copied := *l.info
+	return &copied
+}
+
+// heartbeat refreshes the lock until stopped
+func (l *Lock) heartbeat() {
+	defer close(l.done)
+
+	interval := l.ttl / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			now := time.Now()
+			l.info.HeartbeatAt = now
+			l.info.ExpiresAt = now.Add(l.ttl)
+			// A failed heartbeat is retried on the next tick; the lock only
+			// becomes stealable once the TTL has passed
+			_ = l.backend.Refresh(context.Background(), l.info)
+			l.mu.Unlock()
+		}
+	}
+}
+
+// Release stops the heartbeat and releases the lock
+func (l *Lock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return nil
+	}
+	l.released = true
+	l.mu.Unlock()
+
+	close(l.stop)
+	<-l.done
+
+	return l.backend.Release(ctx, l.info)
+}
+
+// CurrentHolder identifies this process as user@host
+func CurrentHolder() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	if name == "" {
+		name = "unknown"
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s@%s", name, host)
+}
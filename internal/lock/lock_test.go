@@ -0,0 +1,139 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBackend(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("acquire and release", func(t *testing.T) {
+		dir := t.TempDir()
+		backend := NewFileBackend(dir)
+
+		holder, err := backend.Status(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, holder)
+
+		l, err := Acquire(ctx, backend, "deploy", time.Minute)
+		require.NoError(t, err)
+
+		holder, err = NewFileBackend(dir).Status(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, holder)
+		assert.Equal(t, "deploy", holder.Operation)
+		assert.Equal(t, os.Getpid(), holder.PID)
+		assert.Equal(t, CurrentHolder(), holder.Holder)
+
+		require.NoError(t, l.Release(ctx))
+		require.NoError(t, l.Release(ctx))
+
+		holder, err = backend.Status(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, holder)
+	})
+
+	t.Run("second acquire is rejected", func(t *testing.T) {
+		dir := t.TempDir()
+
+		l, err := Acquire(ctx, NewFileBackend(dir), "deploy", time.Minute)
+		require.NoError(t, err)
+		defer l.Release(ctx)
+
+		_, err = Acquire(ctx, NewFileBackend(dir), "rollback", time.Minute)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrLocked))
+
+		var heldErr *HeldError
+		require.True(t, errors.As(err, &heldErr))
+		assert.Equal(t, "deploy", heldErr.Holder.Operation)
+	})
+
+	t.Run("acquire after release", func(t *testing.T) {
+		dir := t.TempDir()
+
+		l, err := Acquire(ctx, NewFileBackend(dir), "deploy", time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, l.Release(ctx))
+
+		l, err = Acquire(ctx, NewFileBackend(dir), "deploy", time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, l.Release(ctx))
+	})
+
+	t.Run("stale holder is replaced", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("open lock files cannot be removed on windows")
+		}
+		dir := t.TempDir()
+
+		// Hold the lock without a heartbeat and let it expire
+		stale := NewFileBackend(dir)
+		past := time.Now().Add(-time.Hour)
+		require.NoError(t, stale.Acquire(ctx, &Info{Holder: "ghost", Operation: "deploy", AcquiredAt: past, ExpiresAt: past}))
+
+		l, err := Acquire(ctx, NewFileBackend(dir), "deploy", time.Minute)
+		require.NoError(t, err)
+		defer l.Release(ctx)
+
+		holder, err := NewFileBackend(dir).Status(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, holder)
+		assert.Equal(t, CurrentHolder(), holder.Holder)
+
+		// Releasing the stale handle must not remove the new holder's file
+		require.NoError(t, stale.Release(ctx, nil))
+		holder, err = NewFileBackend(dir).Status(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, holder)
+	})
+
+	t.Run("force unlock", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("open lock files cannot be removed on windows")
+		}
+		dir := t.TempDir()
+
+		l, err := Acquire(ctx, NewFileBackend(dir), "deploy", time.Minute)
+		require.NoError(t, err)
+		defer l.Release(ctx)
+
+		require.NoError(t, NewFileBackend(dir).ForceUnlock(ctx))
+
+		other, err := Acquire(ctx, NewFileBackend(dir), "deploy", time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, other.Release(ctx))
+	})
+
+	t.Run("heartbeat extends expiry", func(t *testing.T) {
+		backend := NewFileBackend(t.TempDir())
+
+		l, err := Acquire(ctx, backend, "deploy", 300*time.Millisecond)
+		require.NoError(t, err)
+		defer l.Release(ctx)
+
+		first := l.Info().ExpiresAt
+		time.Sleep(250 * time.Millisecond)
+
+		data, err := os.ReadFile(backend.Path())
+		require.NoError(t, err)
+		var info Info
+		require.NoError(t, json.Unmarshal(data, &info))
+		assert.True(t, info.ExpiresAt.After(first))
+		assert.False(t, info.Expired())
+	})
+}
+
+func TestAdvisoryKey(t *testing.T) {
+	assert.Equal(t, advisoryKey("migra"), advisoryKey("migra"))
+	assert.NotEqual(t, advisoryKey("migra"), advisoryKey("other"))
+}
@@ -0,0 +1,281 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	// Register the postgres database/sql driver
+	_ "github.com/lib/pq"
+)
+
+const postgresLockTable = "migra_locks"
+
+// PostgresBackend implements Backend with a session-level advisory lock.
+// The lock is held by a dedicated connection, so it is released by the
+// server if migra dies. Holder information is kept in the migra_locks
+// table so other runs can see who holds the lock.
+type PostgresBackend struct {
+	db   *sql.DB
+	name string
+	key  int64
+	conn *sql.Conn
+	mu   sync.Mutex
+}
+
+// NewPostgresBackend creates a new Postgres advisory lock backend
+func NewPostgresBackend(dsn, name string) (*PostgresBackend, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection: %w", err)
+	}
+
+	return &PostgresBackend{
+		db:   db,
+		name: name,
+		key:  advisoryKey(name),
+	}, nil
+}
+
+// Name returns the backend name
+func (b *PostgresBackend) Name() string {
+	return "postgres"
+}
+
+// Close closes the database connection pool
+func (b *PostgresBackend) Close() error {
+	return b.db.Close()
+}
+
+// Acquire takes the advisory lock. A holder whose TTL has expired has its
+// session terminated and the lock is retried once.
+func (b *PostgresBackend) Acquire(ctx context.Context, info *Info) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn != nil {
+		return fmt.Errorf("lock is already held by this process")
+	}
+
+	if err := b.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", b.key).Scan(&acquired); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to acquire advisory lock: %w", err)
+		}
+
+		if acquired {
+			_, err := conn.ExecContext(ctx, `
+				INSERT INTO `+postgresLockTable+` (name, holder, operation, pid, backend_pid, acquired_at, heartbeat_at, expires_at)
+				VALUES ($1, $2, $3, $4, pg_backend_pid(), $5, $6, $7)
+				ON CONFLICT (name) DO UPDATE SET
+					holder = EXCLUDED.holder,
+					operation = EXCLUDED.operation,
+					pid = EXCLUDED.pid,
+					backend_pid = EXCLUDED.backend_pid,
+					acquired_at = EXCLUDED.acquired_at,
+					heartbeat_at = EXCLUDED.heartbeat_at,
+					expires_at = EXCLUDED.expires_at`,
+				b.name, info.Holder, info.Operation, info.PID, info.AcquiredAt, info.HeartbeatAt, info.ExpiresAt)
+			if err != nil {
+				conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", b.key)
+				conn.Close()
+				return fmt.Errorf("failed to record lock holder: %w", err)
+			}
+
+			b.conn = conn
+			return nil
+		}
+
+		holder, _ := b.readInfo(ctx)
+		if holder == nil || !holder.Expired() || attempt > 0 {
+			conn.Close()
+			return &HeldError{Holder: holder}
+		}
+
+		// The holder missed its heartbeat - terminate its session and retry
+		if err := b.terminateHolder(ctx); err != nil {
+			conn.Close()
+			return &HeldError{Holder: holder}
+		}
+	}
+
+	conn.Close()
+	return &HeldError{}
+}
+
+// Refresh records a heartbeat for the held lock
+func (b *PostgresBackend) Refresh(ctx context.Context, info *Info) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return fmt.Errorf("lock is not held")
+	}
+
+	_, err := b.conn.ExecContext(ctx,
+		"UPDATE "+postgresLockTable+" SET heartbeat_at = $2, expires_at = $3 WHERE name = $1",
+		b.name, info.HeartbeatAt, info.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to refresh lock: %w", err)
+	}
+	return nil
+}
+
+// Release releases the advisory lock and removes the holder information
+func (b *PostgresBackend) Release(ctx context.Context, info *Info) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return nil
+	}
+	defer func() {
+		b.conn.Close()
+		b.conn = nil
+	}()
+
+	if _, err := b.conn.ExecContext(ctx, "DELETE FROM "+postgresLockTable+" WHERE name = $1 AND backend_pid = pg_backend_pid()", b.name); err != nil {
+		return fmt.Errorf("failed to clear lock holder: %w", err)
+	}
+	if _, err := b.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", b.key); err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
+
+// Status returns the current holder, or nil if the lock is free
+func (b *PostgresBackend) Status(ctx context.Context) (*Info, error) {
+	if err := b.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	pid, err := b.holderBackendPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pid == 0 {
+		return nil, nil
+	}
+
+	holder, err := b.readInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if holder == nil {
+		holder = &Info{Holder: "unknown"}
+	}
+	return holder, nil
+}
+
+// ForceUnlock terminates the session holding the advisory lock and removes
+// the holder information. This requires permission to terminate the
+// holder's backend.
+func (b *PostgresBackend) ForceUnlock(ctx context.Context) error {
+	if err := b.ensureTable(ctx); err != nil {
+		return err
+	}
+	return b.terminateHolder(ctx)
+}
+
+// terminateHolder terminates the backend holding the lock and clears the row
+func (b *PostgresBackend) terminateHolder(ctx context.Context) error {
+	pid, err := b.holderBackendPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pid != 0 {
+		var terminated bool
+		if err := b.db.QueryRowContext(ctx, "SELECT pg_terminate_backend($1)", pid).Scan(&terminated); err != nil {
+			return fmt.Errorf("failed to terminate lock holder session: %w", err)
+		}
+		if !terminated {
+			return fmt.Errorf("failed to terminate lock holder session %d", pid)
+		}
+
+		// Termination is asynchronous; wait for the lock to be released
+		for i := 0; i < 50; i++ {
+			if pid, err = b.holderBackendPID(ctx); err != nil || pid == 0 {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	if _, err := b.db.ExecContext(ctx, "DELETE FROM "+postgresLockTable+" WHERE name = $1", b.name); err != nil {
+		return fmt.Errorf("failed to clear lock holder: %w", err)
+	}
+	return nil
+}
+
+// holderBackendPID returns the backend PID holding the advisory lock, or 0
+func (b *PostgresBackend) holderBackendPID(ctx context.Context) (int, error) {
+	// Bigint advisory keys are split into classid (high) and objid (low)
+	var pid int
+	err := b.db.QueryRowContext(ctx, `
+		SELECT pid FROM pg_locks
+		WHERE locktype = 'advisory' AND granted AND objsubid = 1
+		AND ((classid::bigint << 32) | objid::bigint) = $1
+		LIMIT 1`, b.key).Scan(&pid)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query advisory locks: %w", err)
+	}
+	return pid, nil
+}
+
+// readInfo reads the holder information for the lock
+func (b *PostgresBackend) readInfo(ctx context.Context) (*Info, error) {
+	var info Info
+	err := b.db.QueryRowContext(ctx,
+		"SELECT holder, operation, pid, acquired_at, heartbeat_at, expires_at FROM "+postgresLockTable+" WHERE name = $1",
+		b.name).Scan(&info.Holder, &info.Operation, &info.PID, &info.AcquiredAt, &info.HeartbeatAt, &info.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock holder: %w", err)
+	}
+	return &info, nil
+}
+
+// ensureTable creates the lock holder table if it does not exist
+func (b *PostgresBackend) ensureTable(ctx context.Context) error {
+	_, err := b.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+postgresLockTable+` (
+			name         TEXT PRIMARY KEY,
+			holder       TEXT NOT NULL,
+			operation    TEXT NOT NULL,
+			pid          INTEGER NOT NULL,
+			backend_pid  INTEGER NOT NULL,
+			acquired_at  TIMESTAMPTZ NOT NULL,
+			heartbeat_at TIMESTAMPTZ NOT NULL,
+			expires_at   TIMESTAMPTZ NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create %s table: %w", postgresLockTable, err)
+	}
+	return nil
+}
+
+// advisoryKey derives a stable advisory lock key from the lock name
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("migra:" + name))
+	return int64(h.Sum64())
+}
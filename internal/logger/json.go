@@ -119,14 +119,17 @@ func (l *JSONLogger) WithFields(fields ...Field) Logger {
 
 // NewLogger creates a logger based on format
 func NewLogger(format string, level Level, verbose, quiet bool) Logger {
-	config := Config{
+	return New(Config{
 		Level:   level,
 		Format:  format,
 		Verbose: verbose,
 		Quiet:   quiet,
-	}
+	})
+}
 
-	if format == "json" {
+// New creates a logger from a full configuration
+func New(config Config) Logger {
+	if config.Format == "json" {
 		return NewJSONLogger(config)
 	}
 
@@ -0,0 +1,318 @@
+// Package output streams the output of migration commands to the logger
+// as it is produced, while capturing it for the operation result.
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/pkg/migra"
+)
+
+const (
+	// DefaultMemoryLimit is how much output a command keeps in memory
+	// before it is spilled to a log file
+	DefaultMemoryLimit = 1 << 20
+	// TailSize is how much of a spilled output is kept in the result
+	TailSize = 64 << 10
+)
+
+// Sink receives the output of every command of a run. Lines are logged
+// with the service and tenant as they arrive, and outputs larger than the
+// memory limit are written to files in the run log directory.
+type Sink struct {
+	log         logger.Logger
+	logDir      string
+	memoryLimit int
+	onLine      migra.EventHandler
+}
+
+// NewSink creates a new output sink. An empty logDir keeps all output
+// in memory.
+func NewSink(log logger.Logger, logDir string) *Sink {
+	return &Sink{
+		log:         log,
+		logDir:      logDir,
+		memoryLimit: DefaultMemoryLimit,
+	}
+}
+
+// SetEventHandler registers a handler receiving an output event for
+// every line
+func (s *Sink) SetEventHandler(handler migra.EventHandler) {
+	s.onLine = handler
+}
+
+// sinkKey is the context key of the sink
+type sinkKey struct{}
+
+// WithSink returns a context whose commands stream output to the sink
+func WithSink(ctx context.Context, sink *Sink) context.Context {
+	return context.WithValue(ctx, sinkKey{}, sink)
+}
+
+// FromContext returns the sink of a context, or nil
+func FromContext(ctx context.Context) *Sink {
+	sink, _ := ctx.Value(sinkKey{}).(*Sink)
+	return sink
+}
+
+// Capture collects the stdout and stderr of a single command
+type Capture struct {
+	mu       sync.Mutex
+	sink     *Sink
+	prefix   string
+	service  string
+	tenant   string
+	fields   []logger.Field
+	filter   func(string) string
+	buf      bytes.Buffer
+	file     *os.File
+	path     string
+	spillErr error
+	writers  []*lineWriter
+}
+
+// NewCapture starts capturing a command run for a service and tenant.
+// Lines are passed through filter, when set, before they are captured or
+// logged.
+// Without a sink in the context output is only captured.
+func NewCapture(ctx context.Context, service *migra.Service, tenant *migra.Tenant, filter func(string) string) *Capture {
+	c := &Capture{
+		sink:    FromContext(ctx),
+		prefix:  service.Name,
+		service: service.Name,
+		fields:  []logger.Field{logger.F("service", service.Name)},
+		filter:  filter,
+	}
+	if tenant != nil {
+		c.prefix = service.Name + "/" + tenant.ID
+		c.tenant = tenant.ID
+		c.fields = append(c.fields, logger.F("tenant", tenant.ID))
+	}
+	return c
+}
+
+// Stdout returns the writer for the command stdout
+func (c *Capture) Stdout() io.Writer {
+	return c.writer("stdout")
+}
+
+// Stderr returns the writer for the command stderr
+func (c *Capture) Stderr() io.Writer {
+	return c.writer("stderr")
+}
+
+// writer returns the line writer for a stream, creating it on first use
+func (c *Capture) writer(stream string) io.Writer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range c.writers {
+		if w.stream == stream {
+			return w
+		}
+	}
+	w := &lineWriter{capture: c, stream: stream}
+	c.writers = append(c.writers, w)
+	return w
+}
+
+// Close flushes partial lines and returns the captured output. When the
+// output was spilled to a file, only its tail is returned along with the
+// file path.
+func (c *Capture) Close() (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range c.writers {
+		if w.partial.Len() > 0 {
+			c.emit(w.stream, w.partial.String(), "")
+			w.partial.Reset()
+		}
+	}
+
+	if c.file == nil {
+		return c.buf.String(), ""
+	}
+
+	c.file.Close()
+	c.keepTail()
+	tail := c.buf.String()
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	return fmt.Sprintf("[output truncated, full output in %s]\n%s", c.path, tail), c.path
+}
+
+// store records filtered output, spilling it to a file once it grows past the
+// memory limit. The caller holds the lock.
+func (c *Capture) store(p []byte) {
+	if c.file != nil {
+		if _, err := c.file.Write(p); err != nil && c.spillErr == nil {
+			c.spillErr = err
+			c.logWarn("Failed to write output log", err)
+		}
+		c.buf.Write(p)
+		if c.buf.Len() > 2*TailSize {
+			c.keepTail()
+		}
+		return
+	}
+
+	c.buf.Write(p)
+	if c.sink == nil || c.sink.logDir == "" || c.spillErr != nil || c.buf.Len() <= c.sink.memoryLimit {
+		return
+	}
+
+	if err := c.spill(); err != nil {
+		c.spillErr = err
+		c.logWarn("Failed to create output log, keeping output in memory", err)
+	}
+}
+
+// spill moves the buffered output to a new log file. The caller holds
+// the lock.
+func (c *Capture) spill() error {
+	path := filepath.Join(c.sink.logDir, logFileName(c.service, c.tenant))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(c.buf.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+
+	c.keepTail()
+	c.file = file
+	c.path = path
+	return nil
+}
+
+// keepTail drops all but the last TailSize bytes of the buffer. The
+// caller holds the lock.
+func (c *Capture) keepTail() {
+	if c.buf.Len() <= TailSize {
+		return
+	}
+	tail := append([]byte(nil), c.buf.Bytes()[c.buf.Len()-TailSize:]...)
+	c.buf.Reset()
+	c.buf.Write(tail)
+}
+
+// emit filters a line of output, then stores and logs it. eol is the
+// line ending, empty for partial lines. The caller holds the lock.
+func (c *Capture) emit(stream, line, eol string) {
+	text := strings.TrimRight(line, "\r")
+	eol = line[len(text):] + eol
+	if c.filter != nil {
+		text = c.filter(text)
+	}
+	c.store([]byte(text + eol))
+	c.logLine(stream, text)
+}
+
+// logLine logs a filtered line of output. The caller holds the lock.
+func (c *Capture) logLine(stream, line string) {
+	if c.sink == nil {
+		return
+	}
+
+	if c.sink.log != nil {
+		fields := append([]logger.Field{}, c.fields...)
+		fields = append(fields, logger.F("stream", stream))
+		c.sink.log.Info(fmt.Sprintf("[%s] %s", c.prefix, line), fields...)
+	}
+	if c.sink.onLine != nil {
+		c.sink.onLine(migra.Event{
+			Type:    migra.EventOutput,
+			Service: c.service,
+			Tenant:  c.tenant,
+			Stream:  stream,
+			Line:    line,
+			Time:    time.Now(),
+		})
+	}
+}
+
+// logWarn logs a problem with the output log file
+func (c *Capture) logWarn(msg string, err error) {
+	if c.sink.log == nil {
+		return
+	}
+	fields := append([]logger.Field{}, c.fields...)
+	fields = append(fields, logger.F("error", err.Error()))
+	c.sink.log.Warn(msg, fields...)
+}
+
+// lineWriter splits one stream of a capture into lines
+type lineWriter struct {
+	capture *Capture
+	stream  string
+	partial bytes.Buffer
+}
+
+// Write stores and logs every completed line. Partial lines are held
+// back until they complete, so the filter always sees whole lines.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	c := w.capture
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			w.partial.Write(data)
+			break
+		}
+		w.partial.Write(data[:i])
+		c.emit(w.stream, w.partial.String(), "\n")
+		w.partial.Reset()
+		data = data[i+1:]
+	}
+
+	// Emit overlong lines in pieces rather than buffering them
+	if w.partial.Len() > TailSize {
+		c.emit(w.stream, w.partial.String(), "")
+		w.partial.Reset()
+	}
+
+	return len(p), nil
+}
+
+// unsafeFileChars matches characters not allowed in log file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// logFileName returns the log file path of a service, relative to the run
+// log directory. Tenant logs are nested in a directory per service so that
+// no two service and tenant pairs share a file.
+func logFileName(service, tenant string) string {
+	if tenant == "" {
+		return safeFileName(service) + ".log"
+	}
+	return filepath.Join(safeFileName(service), safeFileName(tenant)+".log")
+}
+
+// safeFileName replaces the characters not allowed in log file names
+func safeFileName(name string) string {
+	name = unsafeFileChars.ReplaceAllString(name, "_")
+	if name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,178 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntries decodes JSON log lines
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	entries := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// redact hides a test password
+func redact(line string) string {
+	return strings.ReplaceAll(line, "hunter2", "[REDACTED]")
+}
+
+func TestCapture(t *testing.T) {
+	service := &migra.Service{Name: "billing"}
+
+	t.Run("streams lines with service and tenant", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := logger.NewJSONLogger(logger.Config{Level: logger.LevelInfo, Output: &buf})
+		ctx := WithSink(context.Background(), NewSink(log, ""))
+
+		capture := NewCapture(ctx, service, &migra.Tenant{ID: "acme"}, nil)
+		fmt.Fprint(capture.Stdout(), "Applying 0001...")
+		fmt.Fprint(capture.Stdout(), " OK\nApplying 0002")
+		fmt.Fprint(capture.Stderr(), "warning: slow\n")
+
+		// Only complete lines are logged before Close
+		entries := logEntries(t, &buf)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "[billing/acme] Applying 0001... OK", entries[0]["message"])
+		assert.Equal(t, "billing", entries[0]["service"])
+		assert.Equal(t, "acme", entries[0]["tenant"])
+		assert.Equal(t, "stdout", entries[0]["stream"])
+		assert.Equal(t, "[billing/acme] warning: slow", entries[1]["message"])
+		assert.Equal(t, "stderr", entries[1]["stream"])
+
+		out, logFile := capture.Close()
+		// Partial lines are captured when they complete or at Close
+		assert.Equal(t, "Applying 0001... OK\nwarning: slow\nApplying 0002", out)
+		assert.Empty(t, logFile)
+
+		entries = logEntries(t, &buf)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "[billing/acme] Applying 0002", entries[2]["message"])
+	})
+
+	t.Run("filters logged lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := logger.NewJSONLogger(logger.Config{Level: logger.LevelInfo, Output: &buf})
+		ctx := WithSink(context.Background(), NewSink(log, ""))
+
+		capture := NewCapture(ctx, service, nil, redact)
+		fmt.Fprint(capture.Stdout(), "password hun")
+		fmt.Fprint(capture.Stdout(), "ter2\r\n")
+		out, _ := capture.Close()
+		assert.Equal(t, "password [REDACTED]\r\n", out)
+
+		entries := logEntries(t, &buf)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "[billing] password [REDACTED]", entries[0]["message"])
+		assert.NotContains(t, entries[0], "tenant")
+	})
+
+	t.Run("emits output events", func(t *testing.T) {
+		var mu sync.Mutex
+		events := make([]migra.Event, 0)
+		sink := NewSink(nil, "")
+		sink.SetEventHandler(func(e migra.Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		})
+
+		capture := NewCapture(WithSink(context.Background(), sink), service, nil, nil)
+		fmt.Fprintln(capture.Stderr(), "migrating")
+		capture.Close()
+
+		require.Len(t, events, 1)
+		assert.Equal(t, migra.EventOutput, events[0].Type)
+		assert.Equal(t, "billing", events[0].Service)
+		assert.Equal(t, "stderr", events[0].Stream)
+		assert.Equal(t, "migrating", events[0].Line)
+	})
+
+	t.Run("captures without a sink", func(t *testing.T) {
+		capture := NewCapture(context.Background(), service, nil, nil)
+		fmt.Fprint(capture.Stdout(), strings.Repeat("x", 2*DefaultMemoryLimit))
+
+		out, logFile := capture.Close()
+		assert.Len(t, out, 2*DefaultMemoryLimit)
+		assert.Empty(t, logFile)
+	})
+
+	t.Run("spills large output to the run log directory", func(t *testing.T) {
+		logDir := filepath.Join(t.TempDir(), ".migra", "logs", "run-1")
+		sink := NewSink(nil, logDir)
+		sink.memoryLimit = 1024
+
+		capture := NewCapture(WithSink(context.Background(), sink), service, &migra.Tenant{ID: "acme corp"}, nil)
+		var expected strings.Builder
+		for i := 0; i < 20000; i++ {
+			line := fmt.Sprintf("line %05d\n", i)
+			expected.WriteString(line)
+			fmt.Fprint(capture.Stdout(), line)
+		}
+
+		out, logFile := capture.Close()
+		assert.Equal(t, filepath.Join(logDir, "billing", "acme_corp.log"), logFile)
+		assert.True(t, strings.HasPrefix(out, "[output truncated, full output in "+logFile+"]\n"))
+		assert.True(t, strings.HasSuffix(out, "line 19999\n"))
+		assert.Less(t, len(out), TailSize+200)
+
+		data, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		assert.Equal(t, expected.String(), string(data))
+	})
+	t.Run("filters spilled output", func(t *testing.T) {
+		logDir := t.TempDir()
+		sink := NewSink(nil, logDir)
+		sink.memoryLimit = 1024
+
+		capture := NewCapture(WithSink(context.Background(), sink), service, nil, redact)
+		for i := 0; i < 200; i++ {
+			fmt.Fprintf(capture.Stdout(), "connecting with password hunter2 (%d)\n", i)
+		}
+
+		out, logFile := capture.Close()
+		require.NotEmpty(t, logFile)
+		assert.NotContains(t, out, "hunter2")
+
+		info, err := os.Stat(logFile)
+		require.NoError(t, err)
+		if runtime.GOOS != "windows" {
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+
+		data, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "hunter2")
+		assert.Equal(t, 200, strings.Count(string(data), "password [REDACTED]"))
+	})
+}
+
+func TestLogFileName(t *testing.T) {
+	assert.Equal(t, "billing.log", logFileName("billing", ""))
+	assert.Equal(t, filepath.Join("billing", "acme_corp.log"), logFileName("billing", "acme corp"))
+	assert.Equal(t, filepath.Join("_", "_.log"), logFileName("..", ".."))
+
+	// Service and tenant names containing the other's separator stay apart
+	assert.NotEqual(t, logFileName("billing", "api-acme"), logFileName("billing-api", "acme"))
+}
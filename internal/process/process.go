@@ -0,0 +1,69 @@
+// Package process runs migration commands so they can be stopped cleanly
+// when their context is cancelled or times out.
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// DefaultGracePeriod is how long a command gets to exit after SIGTERM
+// before it is killed
+const DefaultGracePeriod = 10 * time.Second
+
+// graceKey is the context key of the grace period
+type graceKey struct{}
+
+// WithGracePeriod returns a context whose commands get the given time to
+// exit after SIGTERM. Zero uses the default.
+func WithGracePeriod(ctx context.Context, grace time.Duration) context.Context {
+	return context.WithValue(ctx, graceKey{}, grace)
+}
+
+// GracePeriod returns the grace period of a context
+func GracePeriod(ctx context.Context) time.Duration {
+	if grace, ok := ctx.Value(graceKey{}).(time.Duration); ok && grace > 0 {
+		return grace
+	}
+	return DefaultGracePeriod
+}
+
+// Run runs a command created with exec.CommandContext. When the context
+// is done the command's process group is sent SIGTERM, and SIGKILL if it
+// is still running after the grace period, so children such as those
+// started by npx do not outlive it.
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	grace := GracePeriod(ctx)
+	group := newGroup(cmd, grace)
+
+	// Give up on the output pipes shortly after the group was killed, in
+	// case something outside the group still holds them open
+	cmd.WaitDelay = grace + time.Second
+
+	err := cmd.Run()
+	group.stop(ctx.Err() != nil)
+	return err
+}
+
+// WithTimeout bounds a context by a timeout. A zero timeout returns the
+// context unchanged.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// TimedOut reports whether a context ended because its deadline passed
+func TimedOut(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// TimeoutError describes an operation stopped by a timeout after running
+// for elapsed
+func TimeoutError(elapsed time.Duration) string {
+	return fmt.Sprintf("timed out after %s", elapsed.Round(time.Millisecond))
+}
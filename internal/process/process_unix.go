@@ -0,0 +1,61 @@
+//go:build !windows
+
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// group terminates a command together with its children
+type group struct {
+	cmd   *exec.Cmd
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// newGroup starts the command in its own process group and sends the
+// group SIGTERM, then SIGKILL after the grace period, on cancellation
+func newGroup(cmd *exec.Cmd, grace time.Duration) *group {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	g := &group{cmd: cmd}
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+			if errors.Is(err, syscall.ESRCH) {
+				return os.ErrProcessDone
+			}
+			return err
+		}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.timer = time.AfterFunc(grace, func() {
+			syscall.Kill(-pgid, syscall.SIGKILL)
+		})
+		return nil
+	}
+	return g
+}
+
+// stop is called once the command has returned. After a cancellation
+// anything left in the group is killed.
+func (g *group) stop(cancelled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+	if cancelled && g.cmd.Process != nil {
+		syscall.Kill(-g.cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alive reports whether a process exists
+func alive(pid int) bool {
+	return !errors.Is(syscall.Kill(pid, 0), syscall.ESRCH)
+}
+
+func TestRun(t *testing.T) {
+	t.Run("completes normally", func(t *testing.T) {
+		cmd := exec.CommandContext(context.Background(), "sh", "-c", "exit 0")
+		require.NoError(t, Run(context.Background(), cmd))
+	})
+
+	t.Run("terminates on timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 30")
+		err := Run(WithGracePeriod(ctx, 10*time.Second), cmd)
+		require.Error(t, err)
+		assert.True(t, TimedOut(ctx))
+
+		// SIGTERM was enough, so the grace period was not waited out
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("kills the process group after the grace period", func(t *testing.T) {
+		dir := t.TempDir()
+		pidFile := filepath.Join(dir, "child.pid")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		ctx = WithGracePeriod(ctx, 300*time.Millisecond)
+
+		// The shell and its background child ignore SIGTERM
+		cmd := exec.CommandContext(ctx, "sh", "-c", `trap "" TERM; sleep 30 & echo $! > `+pidFile+`; wait`)
+		start := time.Now()
+		require.Error(t, Run(ctx, cmd))
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		data, err := os.ReadFile(pidFile)
+		require.NoError(t, err)
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return !alive(pid) }, 2*time.Second, 20*time.Millisecond)
+	})
+}
+
+func TestGracePeriod(t *testing.T) {
+	assert.Equal(t, DefaultGracePeriod, GracePeriod(context.Background()))
+	assert.Equal(t, DefaultGracePeriod, GracePeriod(WithGracePeriod(context.Background(), 0)))
+	assert.Equal(t, time.Second, GracePeriod(WithGracePeriod(context.Background(), time.Second)))
+}
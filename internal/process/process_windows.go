@@ -0,0 +1,23 @@
+//go:build windows
+
+package process
+
+import (
+	"os/exec"
+	"time"
+)
+
+// group kills a command on cancellation. Windows has no SIGTERM, so the
+// process is killed right away.
+type group struct{}
+
+// newGroup keeps the default cancellation of the command
+func newGroup(cmd *exec.Cmd, grace time.Duration) *group {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+	return &group{}
+}
+
+// stop is called once the command has returned
+func (g *group) stop(cancelled bool) {}
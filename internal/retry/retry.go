@@ -0,0 +1,139 @@
+// Package retry re-runs failed service operations according to a retry
+// policy.
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"time"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// randFloat returns the random factor applied to jittered delays
+var randFloat = rand.Float64
+
+// Do runs an operation until it succeeds, fails in a way the policy does
+// not retry, or runs out of attempts. onRetry, when set, is called before
+// waiting for each new attempt. A nil policy runs the operation once. The
+// returned result is the last attempt's, with the total duration and,
+// when retries are enabled, every attempt.
+func Do(ctx context.Context, policy *migra.RetryPolicy, run func(ctx context.Context) migra.ServiceResult, onRetry func(failed migra.ServiceResult, next int, delay time.Duration)) migra.ServiceResult {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return run(ctx)
+	}
+
+	patterns := compile(policy.RetryOn)
+	start := time.Now()
+	attempts := make([]migra.Attempt, 0, policy.MaxAttempts)
+
+	var result migra.ServiceResult
+	for n := 1; ; n++ {
+		result = run(ctx)
+		attempts = append(attempts, migra.Attempt{
+			Number:   n,
+			Success:  result.Success,
+			TimedOut: result.TimedOut,
+			Duration: result.Duration,
+			Error:    result.Error,
+		})
+
+		if n >= policy.MaxAttempts || !retryable(ctx, result, patterns) {
+			break
+		}
+
+		delay := Delay(policy, n)
+		if onRetry != nil {
+			onRetry(result, n+1, delay)
+		}
+		if !sleep(ctx, delay) {
+			break
+		}
+	}
+
+	result.Attempts = attempts
+	result.Duration = time.Since(start)
+	return result
+}
+
+// ForOperation returns the policy applying to an operation. Rollbacks are
+// never retried: many adapters undo one migration per command, so a
+// rollback failing part way has already undone some steps, and running it
+// again would undo too many.
+func ForOperation(policy *migra.RetryPolicy, operation migra.Operation) *migra.RetryPolicy {
+	if operation == migra.OperationRollback {
+		return nil
+	}
+	return policy
+}
+
+// Delay returns how long to wait after a failed attempt: the backoff
+// doubled for every earlier attempt, capped at the maximum backoff and
+// randomized by the jitter fraction
+func Delay(policy *migra.RetryPolicy, attempt int) time.Duration {
+	delay := policy.Backoff
+	for i := 1; i < attempt; i++ {
+		if delay > math.MaxInt64/2 || (policy.MaxBackoff > 0 && delay >= policy.MaxBackoff) {
+			break
+		}
+		delay *= 2
+	}
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	if policy.Jitter > 0 {
+		delay += time.Duration(float64(delay) * policy.Jitter * (2*randFloat() - 1))
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// retryable reports whether a failed result may be retried. Cancelled
+// runs are never retried, and with patterns only matching failures are.
+func retryable(ctx context.Context, result migra.ServiceResult, patterns []*regexp.Regexp) bool {
+	if result.Success || result.Skipped || ctx.Err() != nil {
+		return false
+	}
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern.MatchString(result.Error) || pattern.MatchString(result.Output) {
+			return true
+		}
+	}
+	return false
+}
+
+// compile compiles retry_on patterns. They are checked when the
+// configuration is validated, so invalid ones are ignored here.
+func compile(exprs []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		if pattern, err := regexp.Compile(expr); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// sleep waits for a delay, returning false if the context ends first
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
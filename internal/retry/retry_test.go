@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failing returns an operation that fails with output until it has been
+// called failures times
+func failing(failures int, output string) (func(context.Context) migra.ServiceResult, *int) {
+	calls := 0
+	return func(ctx context.Context) migra.ServiceResult {
+		calls++
+		if calls <= failures {
+			return migra.ServiceResult{ServiceName: "billing", Error: "exit status 1", Output: output}
+		}
+		return migra.ServiceResult{ServiceName: "billing", Success: true}
+	}, &calls
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs once without a policy", func(t *testing.T) {
+		run, calls := failing(1, "")
+		result := Do(ctx, nil, run, nil)
+		assert.False(t, result.Success)
+		assert.Equal(t, 1, *calls)
+		assert.Empty(t, result.Attempts)
+	})
+
+	t.Run("retries until success", func(t *testing.T) {
+		run, calls := failing(2, "could not connect to server")
+		retries := make([]int, 0)
+		policy := &migra.RetryPolicy{MaxAttempts: 5}
+
+		result := Do(ctx, policy, run, func(failed migra.ServiceResult, next int, delay time.Duration) {
+			assert.Equal(t, "exit status 1", failed.Error)
+			retries = append(retries, next)
+		})
+		assert.True(t, result.Success)
+		assert.Equal(t, 3, *calls)
+		assert.Equal(t, []int{2, 3}, retries)
+		require.Len(t, result.Attempts, 3)
+		assert.False(t, result.Attempts[0].Success)
+		assert.Equal(t, "exit status 1", result.Attempts[0].Error)
+		assert.True(t, result.Attempts[2].Success)
+		assert.Equal(t, 3, result.Attempts[2].Number)
+	})
+
+	t.Run("stops after max attempts", func(t *testing.T) {
+		run, calls := failing(10, "")
+		result := Do(ctx, &migra.RetryPolicy{MaxAttempts: 3}, run, nil)
+		assert.False(t, result.Success)
+		assert.Equal(t, 3, *calls)
+		assert.Len(t, result.Attempts, 3)
+	})
+
+	t.Run("only retries matching failures", func(t *testing.T) {
+		policy := &migra.RetryPolicy{MaxAttempts: 3, RetryOn: []string{"could not connect", "deadlock detected"}}
+
+		run, calls := failing(1, "ERROR: deadlock detected")
+		assert.True(t, Do(ctx, policy, run, nil).Success)
+		assert.Equal(t, 2, *calls)
+
+		run, calls = failing(1, "ERROR: syntax error at or near")
+		result := Do(ctx, policy, run, nil)
+		assert.False(t, result.Success)
+		assert.Equal(t, 1, *calls)
+		assert.Len(t, result.Attempts, 1)
+	})
+
+	t.Run("stops when the context ends", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		run, calls := failing(10, "")
+		policy := &migra.RetryPolicy{MaxAttempts: 5, Backoff: time.Hour}
+
+		start := time.Now()
+		result := Do(ctx, policy, run, func(migra.ServiceResult, int, time.Duration) { cancel() })
+		assert.False(t, result.Success)
+		assert.Equal(t, 1, *calls)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestDelay(t *testing.T) {
+	policy := &migra.RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, Delay(policy, 1))
+	assert.Equal(t, 2*time.Second, Delay(policy, 2))
+	assert.Equal(t, 4*time.Second, Delay(policy, 3))
+	assert.Equal(t, 5*time.Second, Delay(policy, 4))
+	assert.Equal(t, 5*time.Second, Delay(policy, 100))
+
+	// Uncapped backoff does not overflow
+	assert.Positive(t, Delay(&migra.RetryPolicy{Backoff: time.Second}, 200))
+
+	defer func(orig func() float64) { randFloat = orig }(randFloat)
+	randFloat = func() float64 { return 1 }
+	policy.Jitter = 0.5
+	assert.Equal(t, 3*time.Second, Delay(policy, 2))
+	randFloat = func() float64 { return 0 }
+	assert.Equal(t, time.Second, Delay(policy, 2))
+}
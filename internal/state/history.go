@@ -0,0 +1,346 @@
+package state
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+const (
+	defaultHistoryFile = "history.jsonl"
+	defaultLogsDir     = "logs"
+
+	// MaxRecordedOutput is the number of output bytes kept per history item
+	MaxRecordedOutput = 4096
+)
+
+// RunRecord is a single run in the execution history
+type RunRecord struct {
+	ID          string        `json:"id"`
+	Operation   string        `json:"operation"`
+	ResumedFrom string        `json:"resumed_from,omitempty"`
+	User        string        `json:"user"`
+	Host        string        `json:"host"`
+	GitCommit   string        `json:"git_commit,omitempty"`
+	StartedAt   time.Time     `json:"started_at"`
+	FinishedAt  time.Time     `json:"finished_at"`
+	Duration    time.Duration `json:"duration"`
+	Success     bool          `json:"success"`
+	Services    []string      `json:"services"`
+	Tenants     []string      `json:"tenants,omitempty"`
+	Items       []RunItem     `json:"items"`
+}
+
+// RunItem is the outcome of a single service (and tenant) within a run
+type RunItem struct {
+	Service  string          `json:"service"`
+	Tenant   string          `json:"tenant,omitempty"`
+	Success  bool            `json:"success"`
+	Skipped  bool            `json:"skipped,omitempty"`
+	TimedOut bool            `json:"timed_out,omitempty"`
+	Resumed  bool            `json:"resumed,omitempty"`
+	Duration time.Duration   `json:"duration"`
+	Error    string          `json:"error,omitempty"`
+	Output   string          `json:"output,omitempty"`
+	LogFile  string          `json:"log_file,omitempty"`
+	Attempts []migra.Attempt `json:"attempts,omitempty"`
+}
+
+// NewRunRecord starts a new run record, capturing who ran it, where,
+// and the git commit of the working directory
+func NewRunRecord(operation string) *RunRecord {
+	return &RunRecord{
+		ID:        newRunID(),
+		Operation: operation,
+		User:      currentUser(),
+		Host:      currentHost(),
+		GitCommit: currentGitCommit(),
+		StartedAt: time.Now(),
+		Services:  make([]string, 0),
+		Items:     make([]RunItem, 0),
+	}
+}
+
+// AddItem adds an item to the run, trimming its output and tracking the
+// services and tenants touched
+func (r *RunRecord) AddItem(item RunItem) {
+	item.Output = TrimOutput(item.Output, MaxRecordedOutput)
+	r.Items = append(r.Items, item)
+
+	if !containsString(r.Services, item.Service) {
+		r.Services = append(r.Services, item.Service)
+	}
+	if item.Tenant != "" && !containsString(r.Tenants, item.Tenant) {
+		r.Tenants = append(r.Tenants, item.Tenant)
+	}
+}
+
+// AddServiceResults adds engine results to the run, optionally for a tenant
+func (r *RunRecord) AddServiceResults(tenantID string, results []migra.ServiceResult) {
+	for _, res := range results {
+		r.AddItem(RunItem{
+			Service:  res.ServiceName,
+			Tenant:   tenantID,
+			Success:  res.Success,
+			Skipped:  res.Skipped,
+			TimedOut: res.TimedOut,
+			Resumed:  res.Resumed,
+			Duration: res.Duration,
+			Error:    res.Error,
+			Output:   res.Output,
+			LogFile:  res.LogFile,
+			Attempts: res.Attempts,
+		})
+	}
+}
+
+// Finish marks the run as complete, deriving success from its items
+func (r *RunRecord) Finish() {
+	r.FinishedAt = time.Now()
+	r.Duration = r.FinishedAt.Sub(r.StartedAt)
+	r.Success = true
+	for _, item := range r.Items {
+		if !item.Success {
+			r.Success = false
+			break
+		}
+	}
+}
+
+// HistoryFilter selects runs from the history
+type HistoryFilter struct {
+	Operation  string
+	Service    string
+	Tenant     string
+	User       string
+	Since      time.Time
+	FailedOnly bool
+	Limit      int
+}
+
+// matches reports whether a record passes the filter
+func (f HistoryFilter) matches(r *RunRecord) bool {
+	if f.Operation != "" && r.Operation != f.Operation {
+		return false
+	}
+	if f.Service != "" && !containsString(r.Services, f.Service) {
+		return false
+	}
+	if f.Tenant != "" && !containsString(r.Tenants, f.Tenant) {
+		return false
+	}
+	if f.User != "" && r.User != f.User {
+		return false
+	}
+	if !f.Since.IsZero() && r.StartedAt.Before(f.Since) {
+		return false
+	}
+	if f.FailedOnly && r.Success {
+		return false
+	}
+	return true
+}
+
+// History is an append-only log of runs stored alongside the state file
+type History struct {
+	historyFile string
+	mu          sync.Mutex
+}
+
+// NewHistory creates a new history for the given working directory
+func NewHistory(workDir string) *History {
+	return &History{
+		historyFile: filepath.Join(workDir, defaultStateDir, defaultHistoryFile),
+	}
+}
+
+// RunLogDir returns the directory holding the command output logs of a run
+func RunLogDir(workDir, runID string) string {
+	return filepath.Join(workDir, defaultStateDir, defaultLogsDir, runID)
+}
+
+// Append appends a run record to the history
+func (h *History) Append(record *RunRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.historyFile), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run record: %w", err)
+	}
+
+	f, err := os.OpenFile(h.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the runs matching the filter, newest first
+func (h *History) List(filter HistoryFilter) ([]*RunRecord, error) {
+	records, err := h.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*RunRecord, 0)
+	for i := len(records) - 1; i >= 0; i-- {
+		if filter.matches(records[i]) {
+			matched = append(matched, records[i])
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].StartedAt.After(matched[j].StartedAt)
+	})
+
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+
+	return matched, nil
+}
+
+// Get returns the run with the given ID. A unique ID prefix is accepted.
+func (h *History) Get(id string) (*RunRecord, error) {
+	records, err := h.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var found *RunRecord
+	for _, r := range records {
+		if r.ID == id {
+			return r, nil
+		}
+		if strings.HasPrefix(r.ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("run ID prefix '%s' is ambiguous", id)
+			}
+			found = r
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("run '%s' not found", id)
+	}
+	return found, nil
+}
+
+// readAll reads every record in the history file
+func (h *History) readAll() ([]*RunRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.Open(h.historyFile)
+	if os.IsNotExist(err) {
+		return []*RunRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	records := make([]*RunRecord, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var record RunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse history file line %d: %w", line, err)
+		}
+		records = append(records, &record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	return records, nil
+}
+
+// TrimOutput keeps the last max bytes of output, which is where errors
+// usually are
+func TrimOutput(output string, max int) string {
+	if max <= 0 || len(output) <= max {
+		return output
+	}
+	return "...(truncated)\n" + output[len(output)-max:]
+}
+
+// newRunID generates a sortable, unique run ID
+func newRunID() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return time.Now().Format("20060102-150405.000000")
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// currentUser returns the name of the user running migra
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// currentHost returns the hostname of the machine running migra
+func currentHost() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
+
+// currentGitCommit returns the HEAD commit of the working directory,
+// or an empty string when it is not a git repository
+func currentGitCommit() string {
+	output, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
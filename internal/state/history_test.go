@@ -0,0 +1,105 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	history := NewHistory(tmpDir)
+
+	t.Run("empty history", func(t *testing.T) {
+		records, err := history.List(HistoryFilter{})
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	first := NewRunRecord("deploy")
+	first.StartedAt = time.Now().Add(-48 * time.Hour)
+	first.AddItem(RunItem{Service: "api", Success: true, Duration: time.Second})
+	first.AddItem(RunItem{Service: "billing", Success: true})
+	first.Finish()
+
+	second := NewRunRecord("deploy")
+	second.AddItem(RunItem{Service: "api", Tenant: "acme", Success: true})
+	second.AddItem(RunItem{Service: "api", Tenant: "globex", Success: false, Error: "boom"})
+	second.Finish()
+
+	third := NewRunRecord("rollback")
+	third.AddItem(RunItem{Service: "billing", Success: true})
+	third.Finish()
+
+	t.Run("append records", func(t *testing.T) {
+		require.NoError(t, history.Append(first))
+		require.NoError(t, history.Append(second))
+		require.NoError(t, history.Append(third))
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".migra", "history.jsonl"))
+		require.NoError(t, err)
+		assert.Equal(t, 3, strings.Count(string(data), "\n"))
+	})
+
+	t.Run("list newest first", func(t *testing.T) {
+		records, err := history.List(HistoryFilter{})
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, first.ID, records[2].ID)
+		assert.Equal(t, []string{"api", "billing"}, records[2].Services)
+		assert.Equal(t, []string{"acme", "globex"}, records[1].Tenants)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		records, err := history.List(HistoryFilter{Operation: "rollback"})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, third.ID, records[0].ID)
+
+		records, err = history.List(HistoryFilter{Tenant: "globex"})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.False(t, records[0].Success)
+
+		records, err = history.List(HistoryFilter{FailedOnly: true})
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+
+		records, err = history.List(HistoryFilter{Since: time.Now().Add(-24 * time.Hour)})
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+
+		records, err = history.List(HistoryFilter{Service: "billing", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, third.ID, records[0].ID)
+	})
+
+	t.Run("get by id and prefix", func(t *testing.T) {
+		record, err := history.Get(second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "boom", record.Items[1].Error)
+
+		record, err = history.Get(third.ID[:len(third.ID)-1])
+		require.NoError(t, err)
+		assert.Equal(t, third.ID, record.ID)
+
+		_, err = history.Get("missing")
+		assert.Error(t, err)
+	})
+}
+
+func TestRunRecordTrimsOutput(t *testing.T) {
+	record := NewRunRecord("deploy")
+	record.AddItem(RunItem{Service: "api", Output: strings.Repeat("x", MaxRecordedOutput) + "tail"})
+
+	output := record.Items[0].Output
+	assert.True(t, strings.HasPrefix(output, "...(truncated)"))
+	assert.True(t, strings.HasSuffix(output, "tail"))
+	assert.Less(t, len(output), MaxRecordedOutput+32)
+}
@@ -19,6 +19,7 @@ type Manager struct {
 	stateDir  string
 	stateFile string
 	state     *State
+	runID     string
 	mu        sync.RWMutex
 }
 
@@ -111,10 +112,18 @@ func (m *Manager) UpdateState(fn func(*State)) error {
 	return m.Save()
 }
 
+// SetRunID sets the run that subsequent executions are recorded for
+func (m *Manager) SetRunID(runID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runID = runID
+}
+
 // RecordServiceExecution records a service execution and saves state
 func (m *Manager) RecordServiceExecution(serviceName string, success bool, duration time.Duration, err error) error {
 	return m.UpdateState(func(s *State) {
 		s.RecordServiceExecution(serviceName, success, duration, err)
+		s.GetServiceState(serviceName).RunID = m.runID
 	})
 }
 
@@ -122,6 +131,7 @@ func (m *Manager) RecordServiceExecution(serviceName string, success bool, durat
 func (m *Manager) RecordTenantExecution(tenantID, serviceName string, success bool, duration time.Duration, err error) error {
 	return m.UpdateState(func(s *State) {
 		s.RecordTenantExecution(tenantID, serviceName, success, duration, err)
+		s.GetTenantState(tenantID).Services[serviceName].RunID = m.runID
 	})
 }
 
@@ -20,6 +20,7 @@ type ServiceState struct {
 	SuccessCount int       `json:"success_count"`
 	FailureCount int       `json:"failure_count"`
 	LastDuration string    `json:"last_duration"`
+	RunID        string    `json:"run_id,omitempty"`
 }
 
 // TenantState represents the state of a tenant
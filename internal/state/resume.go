@@ -0,0 +1,126 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// ResumePoint lists the services, or tenant services, that succeeded in an
+// earlier run, so that resuming it only retries failed and not started
+// items
+type ResumePoint struct {
+	RunID    string
+	services map[string]bool
+	tenants  map[string]map[string]bool
+	tenanted bool
+}
+
+// Done reports whether a service succeeded in the resumed run, for a
+// tenant unless tenantID is empty. A nil resume point has nothing done.
+func (p *ResumePoint) Done(tenantID, serviceName string) bool {
+	if p == nil {
+		return false
+	}
+	if tenantID == "" {
+		return p.services[serviceName]
+	}
+	return p.tenants[tenantID][serviceName]
+}
+
+// MultiTenant reports whether the resumed run was a multi-tenant run
+func (p *ResumePoint) MultiTenant() bool {
+	return p.tenanted
+}
+
+// ResumePoint collects what succeeded in a run from the recorded state.
+// Items touched again by a later run count as not done and are rerun.
+func (m *Manager) ResumePoint(runID string) (*ResumePoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	point := &ResumePoint{
+		RunID:    runID,
+		services: make(map[string]bool),
+		tenants:  make(map[string]map[string]bool),
+	}
+	found := false
+
+	for name, svc := range m.state.Services {
+		if svc.RunID != runID {
+			continue
+		}
+		found = true
+		if svc.LastResult == "success" {
+			point.services[name] = true
+		}
+	}
+
+	for tenantID, tenantState := range m.state.Tenants {
+		for name, svc := range tenantState.Services {
+			if svc.RunID != runID {
+				continue
+			}
+			found = true
+			point.tenanted = true
+			if svc.LastResult != "success" {
+				continue
+			}
+			if point.tenants[tenantID] == nil {
+				point.tenants[tenantID] = make(map[string]bool)
+			}
+			point.tenants[tenantID][name] = true
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no recorded state for run %s", runID)
+	}
+	return point, nil
+}
+
+// RecordResumed carries a service that succeeded in a resumed run over to
+// the current run, for a tenant unless tenantID is empty, so the current
+// run can be resumed in turn
+func (m *Manager) RecordResumed(tenantID, serviceName string) error {
+	return m.UpdateState(func(s *State) {
+		if tenantID == "" {
+			s.GetServiceState(serviceName).RunID = m.runID
+			return
+		}
+
+		tenantState := s.GetTenantState(tenantID)
+		if tenantState.Services == nil {
+			tenantState.Services = make(map[string]*ServiceState)
+		}
+		if tenantState.Services[serviceName] == nil {
+			tenantState.Services[serviceName] = &ServiceState{}
+		}
+		tenantState.Services[serviceName].RunID = m.runID
+	})
+}
+
+// LoadResumePoint prepares resuming a deploy run. The run ID may be a
+// unique prefix of a run in the history; runs interrupted before they
+// were recorded there are found by their full ID in the state.
+func LoadResumePoint(workDir string, m *Manager, runID string, multiTenant bool) (*ResumePoint, error) {
+	if record, err := NewHistory(workDir).Get(runID); err == nil {
+		if record.Operation != string(migra.OperationDeploy) {
+			return nil, fmt.Errorf("run %s was a %s; only deploy runs can be resumed", record.ID, record.Operation)
+		}
+		runID = record.ID
+	}
+
+	point, err := m.ResumePoint(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	if point.MultiTenant() != multiTenant {
+		if multiTenant {
+			return nil, fmt.Errorf("run %s was not a multi-tenant deploy", runID)
+		}
+		return nil, fmt.Errorf("run %s was a multi-tenant deploy; resume it as one", runID)
+	}
+	return point, nil
+}
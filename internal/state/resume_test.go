@@ -0,0 +1,99 @@
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumePoint(t *testing.T) {
+	t.Run("collects succeeded tenant services", func(t *testing.T) {
+		manager := NewManager(t.TempDir())
+		require.NoError(t, manager.Load())
+
+		manager.SetRunID("run-1")
+		require.NoError(t, manager.RecordTenantExecution("acme", "users", true, time.Second, nil))
+		require.NoError(t, manager.RecordTenantExecution("acme", "orders", false, time.Second, errors.New("boom")))
+		require.NoError(t, manager.RecordTenantExecution("globex", "users", true, time.Second, nil))
+		require.NoError(t, manager.RecordTenantExecution("initech", "users", true, time.Second, nil))
+
+		// A later run touching a tenant takes it out of the first run
+		manager.SetRunID("run-2")
+		require.NoError(t, manager.RecordTenantExecution("initech", "users", true, time.Second, nil))
+
+		point, err := manager.ResumePoint("run-1")
+		require.NoError(t, err)
+		assert.True(t, point.MultiTenant())
+		assert.True(t, point.Done("acme", "users"))
+		assert.False(t, point.Done("acme", "orders"))
+		assert.True(t, point.Done("globex", "users"))
+		assert.False(t, point.Done("globex", "orders"))
+		assert.False(t, point.Done("initech", "users"))
+		assert.False(t, point.Done("", "users"))
+
+		// Carried over items belong to the resuming run
+		manager.SetRunID("run-3")
+		require.NoError(t, manager.RecordResumed("acme", "users"))
+		point, err = manager.ResumePoint("run-3")
+		require.NoError(t, err)
+		assert.True(t, point.Done("acme", "users"))
+	})
+
+	t.Run("collects succeeded services", func(t *testing.T) {
+		manager := NewManager(t.TempDir())
+		require.NoError(t, manager.Load())
+
+		manager.SetRunID("run-1")
+		require.NoError(t, manager.RecordServiceExecution("users", true, time.Second, nil))
+		require.NoError(t, manager.RecordServiceExecution("orders", false, time.Second, errors.New("boom")))
+
+		point, err := manager.ResumePoint("run-1")
+		require.NoError(t, err)
+		assert.False(t, point.MultiTenant())
+		assert.True(t, point.Done("", "users"))
+		assert.False(t, point.Done("", "orders"))
+	})
+
+	t.Run("rejects unknown runs", func(t *testing.T) {
+		manager := NewManager(t.TempDir())
+		require.NoError(t, manager.Load())
+
+		_, err := manager.ResumePoint("run-1")
+		assert.Error(t, err)
+
+		var point *ResumePoint
+		assert.False(t, point.Done("acme", "users"))
+	})
+}
+
+func TestLoadResumePoint(t *testing.T) {
+	workDir := t.TempDir()
+	manager := NewManager(workDir)
+	require.NoError(t, manager.Load())
+	history := NewHistory(workDir)
+
+	deploy := NewRunRecord("deploy")
+	manager.SetRunID(deploy.ID)
+	require.NoError(t, manager.RecordServiceExecution("users", true, time.Second, nil))
+	require.NoError(t, history.Append(deploy))
+
+	rollback := NewRunRecord("rollback")
+	manager.SetRunID(rollback.ID)
+	require.NoError(t, manager.RecordServiceExecution("orders", true, time.Second, nil))
+	require.NoError(t, history.Append(rollback))
+
+	point, err := LoadResumePoint(workDir, manager, deploy.ID[:len(deploy.ID)-2], false)
+	require.NoError(t, err)
+	assert.Equal(t, deploy.ID, point.RunID)
+
+	_, err = LoadResumePoint(workDir, manager, deploy.ID, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a multi-tenant deploy")
+
+	_, err = LoadResumePoint(workDir, manager, rollback.ID, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only deploy runs can be resumed")
+}
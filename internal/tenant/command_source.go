@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"runtime"
 
 	"github.com/migra/migra/pkg/migra"
 )
@@ -13,6 +14,7 @@ import (
 type CommandSource struct {
 	command string
 	args    []string
+	dir     string
 }
 
 // NewCommandSource creates a new command-based tenant source
@@ -23,10 +25,25 @@ func NewCommandSource(command string, args ...string) *CommandSource {
 	}
 }
 
+// NewShellCommandSource creates a command-based tenant source running a
+// script with the system shell, sh on Unix and cmd on Windows
+func NewShellCommandSource(script string) *CommandSource {
+	if runtime.GOOS == "windows" {
+		return NewCommandSource("cmd", "/C", script)
+	}
+	return NewCommandSource("sh", "-c", script)
+}
+
+// SetDir sets the working directory of the command
+func (s *CommandSource) SetDir(dir string) {
+	s.dir = dir
+}
+
 // LoadTenants loads tenants by executing a command
 // The command should output JSON array of tenants to stdout
 func (s *CommandSource) LoadTenants(ctx context.Context) ([]*migra.Tenant, error) {
 	cmd := exec.CommandContext(ctx, s.command, s.args...)
+	cmd.Dir = s.dir
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/migra/migra/internal/config"
 	"github.com/migra/migra/pkg/migra"
 )
 
@@ -17,7 +18,7 @@ type EnvSource struct {
 // NewEnvSource creates a new environment variable tenant source
 func NewEnvSource(envVar string) *EnvSource {
 	if envVar == "" {
-		envVar = "MIGRA_TENANTS"
+		envVar = config.DefaultTenantsEnv
 	}
 	return &EnvSource{
 		envVar: envVar,
@@ -2,12 +2,18 @@ package tenant
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/engine"
 	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/process"
+	"github.com/migra/migra/internal/retry"
 	"github.com/migra/migra/internal/state"
 	"github.com/migra/migra/pkg/migra"
 )
@@ -20,6 +26,14 @@ type Executor struct {
 	logger        logger.Logger
 	stopOnFailure bool
 	maxParallel   int
+	timeout       time.Duration
+	resume        *state.ResumePoint
+	rollout       *config.RolloutConfig
+	confirm       ConfirmFunc
+	schema        string
+	createSchema  bool
+	steps         int
+	onEvent       migra.EventHandler
 }
 
 // NewExecutor creates a new tenant executor
@@ -34,20 +48,78 @@ func NewExecutor(source Source, registry *adapter.Registry, stateManager *state.
 		logger:        log,
 		stopOnFailure: stopOnFailure,
 		maxParallel:   maxParallel,
+		steps:         1,
 	}
 }
 
+// SetTimeout bounds the time each tenant may take across all services.
+// Zero means no limit.
+func (e *Executor) SetTimeout(timeout time.Duration) {
+	e.timeout = timeout
+}
+
+// SetResume skips the tenant services that succeeded in the resumed run
+func (e *Executor) SetResume(point *state.ResumePoint) {
+	e.resume = point
+}
+
+// SetRollout deploys tenants in the waves of a staged rollout. confirm is
+// asked before each wave after the first when the rollout requires
+// confirmation.
+func (e *Executor) SetRollout(rollout *config.RolloutConfig, confirm ConfirmFunc) {
+	e.rollout = rollout
+	e.confirm = confirm
+}
+
+// SetRollbackSteps sets how many migrations a rollback undoes for each
+// tenant service (default 1)
+func (e *Executor) SetRollbackSteps(steps int) {
+	e.steps = steps
+}
+
+// SetSchemaPerTenant runs every tenant in its own schema, named from the
+// template unless its source sets one. With create, missing schemas are
+// created before deploying.
+func (e *Executor) SetSchemaPerTenant(template string, create bool) {
+	e.schema = template
+	e.createSchema = create
+}
+
+// SetEventHandler registers a handler for tenant and service events
+func (e *Executor) SetEventHandler(handler migra.EventHandler) {
+	e.onEvent = handler
+}
+
+// emit sends an event to the registered handler, if any
+func (e *Executor) emit(event migra.Event) {
+	if e.onEvent == nil {
+		return
+	}
+	event.Time = time.Now()
+	e.onEvent(event)
+}
+
 // TenantResult represents the result of a tenant migration
 type TenantResult struct {
 	TenantID     string
 	Success      bool
+	TimedOut     bool
+	Resumed      bool
+	Skipped      bool
 	Duration     time.Duration
 	Error        string
 	ServiceCount int
+	Services     []migra.ServiceResult
 }
 
-// Execute executes migrations for all tenants
+// Execute executes migrations for all tenants. Each tenant runs its
+// services in dependency order; rollbacks undo them in reverse order.
 func (e *Executor) Execute(ctx context.Context, services []migra.Service, operation migra.Operation) ([]TenantResult, error) {
+	services, err := engine.Order(services, operation)
+	if err != nil {
+		return nil, err
+	}
+
 	// Load tenants
 	e.logger.Info("Loading tenants...")
 	tenants, err := e.source.LoadTenants(ctx)
@@ -57,6 +129,28 @@ func (e *Executor) Execute(ctx context.Context, services []migra.Service, operat
 
 	e.logger.Info(fmt.Sprintf("Found %d tenants", len(tenants)))
 
+	if e.schema != "" {
+		if err := CheckSchemas(e.schema, tenants); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.rollout == nil {
+		return e.executeTenants(ctx, tenants, services, operation), nil
+	}
+
+	waves, ignored, err := PlanWaves(tenants, ExcludedIDs(e.source), e.rollout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan rollout: %w", err)
+	}
+	if len(ignored) > 0 {
+		e.logger.Info(fmt.Sprintf("Skipping wave tenants excluded by the filter: %s", strings.Join(ignored, ", ")))
+	}
+	return e.executeWaves(ctx, waves, services, operation), nil
+}
+
+// executeTenants executes migrations for a set of tenants concurrently
+func (e *Executor) executeTenants(ctx context.Context, tenants []*migra.Tenant, services []migra.Service, operation migra.Operation) []TenantResult {
 	results := make([]TenantResult, len(tenants))
 	resultsMu := sync.Mutex{}
 
@@ -97,7 +191,18 @@ func (e *Executor) Execute(ctx context.Context, services []migra.Service, operat
 				logger.F("tenant", tnt.ID),
 			)
 
-			result := e.executeTenant(execCtx, tnt, services, operation)
+			e.emit(migra.Event{Type: migra.EventTenantStarted, Operation: operation, Tenant: tnt.ID})
+			tenantCtx, cancelTenant := process.WithTimeout(execCtx, e.timeout)
+			result := e.executeTenant(tenantCtx, tnt, services, operation)
+			cancelTenant()
+			e.emit(migra.Event{
+				Type:      migra.EventTenantFinished,
+				Operation: operation,
+				Tenant:    tnt.ID,
+				Success:   result.Success,
+				Error:     result.Error,
+				Duration:  result.Duration,
+			})
 
 			resultsMu.Lock()
 			results[idx] = result
@@ -134,7 +239,122 @@ func (e *Executor) Execute(ctx context.Context, services []migra.Service, operat
 		}
 	}
 
-	return filteredResults, nil
+	return filteredResults
+}
+
+// executeWaves executes the waves of a staged rollout in turn. When a
+// wave fails beyond its threshold, or the rollout is stopped between
+// waves, the tenants of the remaining waves are reported as skipped.
+func (e *Executor) executeWaves(ctx context.Context, waves []Wave, services []migra.Service, operation migra.Operation) []TenantResult {
+	results := make([]TenantResult, 0)
+
+	for i := range waves {
+		wave := &waves[i]
+		if i > 0 {
+			if reason := e.beforeWave(ctx, wave); reason != "" {
+				e.logger.Error(reason, logger.F("wave", wave.Name))
+				return append(results, skipWaves(waves[i:], services, reason)...)
+			}
+		}
+
+		e.logger.Info(fmt.Sprintf("Starting wave %s (%d of %d) with %d tenants", wave.Name, i+1, len(waves), len(wave.Tenants)),
+			logger.F("wave", wave.Name),
+		)
+		e.emit(migra.Event{Type: migra.EventWaveStarted, Operation: operation, Wave: wave.Name})
+
+		waveStart := time.Now()
+		waveResults := e.executeTenants(ctx, wave.Tenants, services, operation)
+		results = append(results, waveResults...)
+
+		failed := 0
+		for _, r := range waveResults {
+			if !r.Success {
+				failed++
+			}
+		}
+
+		var reason string
+		switch {
+		case wave.Breached(failed):
+			reason = fmt.Sprintf("rollout aborted: %d of %d tenants failed in wave %s (limit %g%%)", failed, len(wave.Tenants), wave.Name, wave.MaxFailurePercent)
+		case failed > 0 && e.stopOnFailure:
+			reason = fmt.Sprintf("rollout stopped: %d tenant(s) failed in wave %s", failed, wave.Name)
+		case ctx.Err() != nil:
+			reason = "rollout cancelled"
+		}
+
+		e.emit(migra.Event{
+			Type:      migra.EventWaveFinished,
+			Operation: operation,
+			Wave:      wave.Name,
+			Success:   reason == "",
+			Error:     reason,
+			Duration:  time.Since(waveStart),
+		})
+
+		if reason != "" {
+			e.logger.Error(reason, logger.F("wave", wave.Name))
+			return append(results, skipWaves(waves[i+1:], services, reason)...)
+		}
+		e.logger.Info(fmt.Sprintf("Wave %s completed with %d failure(s)", wave.Name, failed), logger.F("wave", wave.Name))
+	}
+
+	return results
+}
+
+// beforeWave pauses and asks for confirmation before a wave, returning
+// why the rollout stops, if it does
+func (e *Executor) beforeWave(ctx context.Context, wave *Wave) string {
+	if e.rollout.Pause > 0 {
+		e.logger.Info(fmt.Sprintf("Pausing %s before wave %s", e.rollout.Pause, wave.Name), logger.F("wave", wave.Name))
+		timer := time.NewTimer(e.rollout.Pause)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "rollout cancelled"
+		}
+	}
+
+	if !e.rollout.Confirm {
+		return ""
+	}
+	if e.confirm == nil {
+		return fmt.Sprintf("rollout stopped before wave %s: confirmation required", wave.Name)
+	}
+	ok, err := e.confirm(ctx, wave)
+	if err != nil {
+		return fmt.Sprintf("rollout stopped before wave %s: %v", wave.Name, err)
+	}
+	if !ok {
+		return fmt.Sprintf("rollout stopped before wave %s: not confirmed", wave.Name)
+	}
+	return ""
+}
+
+// skipWaves reports the tenants of waves that did not run as skipped
+func skipWaves(waves []Wave, services []migra.Service, reason string) []TenantResult {
+	results := make([]TenantResult, 0)
+	for _, wave := range waves {
+		for _, t := range wave.Tenants {
+			result := TenantResult{
+				TenantID:     t.ID,
+				Skipped:      true,
+				Error:        reason,
+				ServiceCount: len(services),
+				Services:     make([]migra.ServiceResult, 0, len(services)),
+			}
+			for _, service := range services {
+				result.Services = append(result.Services, migra.ServiceResult{
+					ServiceName: service.Name,
+					Skipped:     true,
+					Error:       "skipped: " + reason,
+				})
+			}
+			results = append(results, result)
+		}
+	}
+	return results
 }
 
 // executeTenant executes migrations for a single tenant
@@ -144,49 +364,187 @@ func (e *Executor) executeTenant(ctx context.Context, tenant *migra.Tenant, serv
 	result := TenantResult{
 		TenantID:     tenant.ID,
 		ServiceCount: len(services),
+		Services:     make([]migra.ServiceResult, 0, len(services)),
+	}
+
+	if e.schema != "" {
+		tenant = withSchema(e.schema, tenant)
 	}
 
 	successCount := 0
+	resumedCount := 0
 	for _, service := range services {
+		if e.resume.Done(tenant.ID, service.Name) {
+			serviceResult := e.resumed(tenant, &service)
+			result.Services = append(result.Services, serviceResult)
+			e.serviceFinished(operation, tenant, serviceResult)
+			successCount++
+			resumedCount++
+			continue
+		}
+
+		e.emit(migra.Event{Type: migra.EventServiceStarted, Operation: operation, Service: service.Name, Tenant: tenant.ID})
+
 		// Get adapter
 		adp, err := e.registry.GetForService(&service)
 		if err != nil {
 			result.Success = false
 			result.Error = fmt.Sprintf("failed to get adapter for service %s: %v", service.Name, err)
 			result.Duration = time.Since(start)
+			serviceResult := migra.ServiceResult{
+				ServiceName: service.Name,
+				Error:       err.Error(),
+			}
+			result.Services = append(result.Services, serviceResult)
+			e.serviceFinished(operation, tenant, serviceResult)
 			return result
 		}
 
-		// Execute operation
-		var opResult *migra.Result
-		switch operation {
-		case migra.OperationDeploy:
-			opResult, err = adp.Deploy(ctx, &service, tenant)
-		case migra.OperationRollback:
-			opResult, err = adp.Rollback(ctx, &service, tenant, 1)
-		default:
-			err = fmt.Errorf("unsupported operation: %s", operation)
+		if e.createSchema && operation == migra.OperationDeploy {
+			if serviceResult, ok := e.ensureSchema(ctx, &service, tenant); !ok {
+				result.Success = false
+				result.Error = fmt.Sprintf("service %s failed: %s", service.Name, serviceResult.Error)
+				result.Duration = time.Since(start)
+				result.Services = append(result.Services, serviceResult)
+				e.serviceFinished(operation, tenant, serviceResult)
+				return result
+			}
 		}
 
-		if err != nil || !opResult.Success {
+		target := adapter.TargetSchema(adp, &service, tenant)
+		serviceResult := retry.Do(ctx, retry.ForOperation(service.Retry, operation), func(ctx context.Context) migra.ServiceResult {
+			return e.runService(ctx, adp, &service, target, operation)
+		}, func(failed migra.ServiceResult, next int, delay time.Duration) {
+			e.retrying(operation, &service, tenant, failed, next, delay)
+		})
+		result.Services = append(result.Services, serviceResult)
+		e.serviceFinished(operation, tenant, serviceResult)
+
+		if !serviceResult.Success {
 			result.Success = false
-			if err != nil {
-				result.Error = fmt.Sprintf("service %s failed: %v", service.Name, err)
-			} else {
-				result.Error = fmt.Sprintf("service %s failed: %s", service.Name, opResult.Error)
-			}
+			result.TimedOut = serviceResult.TimedOut
+			result.Error = fmt.Sprintf("service %s failed: %s", service.Name, serviceResult.Error)
 			result.Duration = time.Since(start)
-
-			// Record failure
-			e.stateManager.RecordTenantExecution(tenant.ID, service.Name, false, result.Duration, err)
 			return result
 		}
 
 		successCount++
-		e.stateManager.RecordTenantExecution(tenant.ID, service.Name, true, opResult.Duration, nil)
 	}
 
 	result.Success = successCount == len(services)
+	result.Resumed = resumedCount == len(services)
 	result.Duration = time.Since(start)
 	return result
 }
+
+// runService runs one attempt of a service operation for a tenant within
+// the service timeout, and records it
+func (e *Executor) runService(ctx context.Context, adp migra.Adapter, service *migra.Service, tenant *migra.Tenant, operation migra.Operation) migra.ServiceResult {
+	opStart := time.Now()
+	opCtx, cancel := process.WithTimeout(ctx, service.Timeout)
+	defer cancel()
+
+	var opResult *migra.Result
+	var err error
+	switch operation {
+	case migra.OperationDeploy:
+		opResult, err = adp.Deploy(opCtx, service, tenant)
+	case migra.OperationRollback:
+		opResult, err = adp.Rollback(opCtx, service, tenant, e.steps)
+	default:
+		err = fmt.Errorf("unsupported operation: %s", operation)
+	}
+
+	// A deadline of the service, the tenant or the whole run stopped it
+	if process.TimedOut(opCtx) && (err != nil || !opResult.Success) {
+		err = errors.New(process.TimeoutError(time.Since(opStart)))
+	}
+
+	result := migra.ServiceResult{
+		ServiceName: service.Name,
+		Success:     err == nil && opResult.Success,
+		Duration:    time.Since(opStart),
+	}
+	if opResult != nil {
+		result.Duration = opResult.Duration
+		result.Output = opResult.Output
+		result.LogFile = opResult.LogFile
+		result.Error = opResult.Error
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.TimedOut = process.TimedOut(opCtx)
+	}
+
+	var recordErr error
+	if !result.Success && result.Error != "" {
+		recordErr = errors.New(result.Error)
+	}
+	e.stateManager.RecordTenantExecution(tenant.ID, service.Name, result.Success, result.Duration, recordErr)
+	return result
+}
+
+// ensureSchema creates the tenant schema of a service if it is missing.
+// On failure it returns the failed service result.
+func (e *Executor) ensureSchema(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (migra.ServiceResult, bool) {
+	start := time.Now()
+	err := adapter.CreateSchema(ctx, service, tenant)
+	if err == nil {
+		return migra.ServiceResult{}, true
+	}
+
+	result := migra.ServiceResult{
+		ServiceName: service.Name,
+		Duration:    time.Since(start),
+		Error:       err.Error(),
+	}
+	e.stateManager.RecordTenantExecution(tenant.ID, service.Name, false, result.Duration, err)
+	return result, false
+}
+
+// resumed builds the result of a tenant service that succeeded in the
+// resumed run, carrying it over to the current run
+func (e *Executor) resumed(tenant *migra.Tenant, service *migra.Service) migra.ServiceResult {
+	e.logger.Debug(fmt.Sprintf("Skipping %s for tenant %s: already succeeded in run %s", service.Name, tenant.ID, e.resume.RunID),
+		logger.F("service", service.Name),
+		logger.F("tenant", tenant.ID),
+	)
+	e.stateManager.RecordResumed(tenant.ID, service.Name)
+	return migra.ServiceResult{
+		ServiceName: service.Name,
+		Success:     true,
+		Resumed:     true,
+		Output:      fmt.Sprintf("already succeeded in run %s", e.resume.RunID),
+	}
+}
+
+// retrying logs and emits an upcoming retry of a failed tenant service
+func (e *Executor) retrying(operation migra.Operation, service *migra.Service, tenant *migra.Tenant, failed migra.ServiceResult, next int, delay time.Duration) {
+	e.logger.Warn(fmt.Sprintf("Retrying %s for tenant %s in %s (attempt %d/%d)", service.Name, tenant.ID, delay.Round(time.Millisecond), next, service.Retry.MaxAttempts),
+		logger.F("service", service.Name),
+		logger.F("tenant", tenant.ID),
+		logger.F("error", failed.Error),
+	)
+	e.emit(migra.Event{
+		Type:      migra.EventServiceRetry,
+		Operation: operation,
+		Service:   service.Name,
+		Tenant:    tenant.ID,
+		Error:     failed.Error,
+		Attempt:   next,
+	})
+}
+
+// serviceFinished emits the finished event for a tenant service result
+func (e *Executor) serviceFinished(operation migra.Operation, tenant *migra.Tenant, result migra.ServiceResult) {
+	e.emit(migra.Event{
+		Type:      migra.EventServiceFinished,
+		Operation: operation,
+		Service:   result.ServiceName,
+		Tenant:    tenant.ID,
+		Success:   result.Success,
+		Error:     result.Error,
+		Duration:  result.Duration,
+		Result:    &result,
+	})
+}
@@ -0,0 +1,150 @@
+package tenant
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// Filter selects the tenants of a run by ID and by labels
+type Filter struct {
+	// IDs restricts the run to these tenants, when set
+	IDs []string
+	// Exclude removes tenants from the run
+	Exclude []string
+	// Selector keeps only tenants whose labels match
+	Selector migra.Selector
+}
+
+// Empty reports whether the filter keeps every tenant
+func (f *Filter) Empty() bool {
+	return len(f.IDs) == 0 && len(f.Exclude) == 0 && len(f.Selector) == 0
+}
+
+// Apply returns the tenants kept by the filter, in source order. Naming a
+// tenant the source does not return is an error.
+func (f *Filter) Apply(tenants []*migra.Tenant) ([]*migra.Tenant, error) {
+	known := make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		known[t.ID] = true
+	}
+
+	included := make(map[string]bool, len(f.IDs))
+	for _, id := range f.IDs {
+		if !known[id] {
+			return nil, fmt.Errorf("tenant '%s' not found", id)
+		}
+		included[id] = true
+	}
+	excluded := make(map[string]bool, len(f.Exclude))
+	for _, id := range f.Exclude {
+		excluded[id] = true
+	}
+
+	kept := make([]*migra.Tenant, 0, len(tenants))
+	for _, t := range tenants {
+		if len(included) > 0 && !included[t.ID] {
+			continue
+		}
+		if excluded[t.ID] || !f.Selector.Matches(t.Labels) {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept, nil
+}
+
+// FilteredSource applies a filter to the tenants of another source
+type FilteredSource struct {
+	source   Source
+	filter   Filter
+	mu       sync.Mutex
+	excluded []string
+}
+
+// NewFilteredSource creates a source returning the filtered tenants of
+// source
+func NewFilteredSource(source Source, filter Filter) *FilteredSource {
+	return &FilteredSource{
+		source: source,
+		filter: filter,
+	}
+}
+
+// LoadTenants loads and filters tenants. Selecting no tenant is an error.
+func (s *FilteredSource) LoadTenants(ctx context.Context) ([]*migra.Tenant, error) {
+	tenants, err := s.source.LoadTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	kept, err := s.filter.Apply(tenants)
+	if err != nil {
+		return nil, err
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("no tenants match the filter (%d loaded)", len(tenants))
+	}
+
+	isKept := make(map[string]bool, len(kept))
+	for _, t := range kept {
+		isKept[t.ID] = true
+	}
+	excluded := make([]string, 0, len(tenants)-len(kept))
+	for _, t := range tenants {
+		if !isKept[t.ID] {
+			excluded = append(excluded, t.ID)
+		}
+	}
+
+	s.mu.Lock()
+	s.excluded = excluded
+	s.mu.Unlock()
+	return kept, nil
+}
+
+// Excluded returns the IDs of the tenants the filter left out of the last
+// load
+func (s *FilteredSource) Excluded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.excluded...)
+}
+
+// ExcludedIDs returns the tenants a filtered source left out of its last
+// load, or nil for other sources
+func ExcludedIDs(source Source) []string {
+	if filtered, ok := source.(*FilteredSource); ok {
+		return filtered.Excluded()
+	}
+	return nil
+}
+
+// ReadTenantIDs reads tenant IDs from a file, one per line. Blank lines
+// and lines starting with # are ignored.
+func ReadTenantIDs(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tenants file: %w", err)
+	}
+	defer file.Close()
+
+	ids := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,130 @@
+package tenant
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labeledTenants returns tenants in several regions and plans
+func labeledTenants() staticSource {
+	return staticSource{
+		{ID: "acme", Labels: map[string]string{"region": "eu", "plan": "enterprise"}},
+		{ID: "globex", Labels: map[string]string{"region": "us", "plan": "enterprise"}},
+		{ID: "initech", Labels: map[string]string{"region": "eu", "plan": "free"}},
+		{ID: "umbrella", Labels: map[string]string{"region": "eu", "plan": "enterprise"}},
+		{ID: "hooli"},
+	}
+}
+
+// ids returns the IDs of tenants
+func ids(tenants []*migra.Tenant) []string {
+	result := make([]string, len(tenants))
+	for i, t := range tenants {
+		result[i] = t.ID
+	}
+	return result
+}
+
+func TestFilter(t *testing.T) {
+	tenants := labeledTenants()
+
+	t.Run("empty filter keeps every tenant", func(t *testing.T) {
+		filter := Filter{}
+		assert.True(t, filter.Empty())
+
+		kept, err := filter.Apply(tenants)
+		require.NoError(t, err)
+		assert.Len(t, kept, 5)
+	})
+
+	t.Run("selector", func(t *testing.T) {
+		selector, err := migra.ParseSelector("region=eu,plan=enterprise")
+		require.NoError(t, err)
+
+		kept, err := (&Filter{Selector: selector}).Apply(tenants)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme", "umbrella"}, ids(kept))
+	})
+
+	t.Run("IDs keep source order", func(t *testing.T) {
+		kept, err := (&Filter{IDs: []string{"hooli", "acme"}}).Apply(tenants)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme", "hooli"}, ids(kept))
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		selector, err := migra.ParseSelector("region=eu")
+		require.NoError(t, err)
+
+		kept, err := (&Filter{Exclude: []string{"initech"}, Selector: selector}).Apply(tenants)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme", "umbrella"}, ids(kept))
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		_, err := (&Filter{IDs: []string{"acme", "missing"}}).Apply(tenants)
+		assert.ErrorContains(t, err, "tenant 'missing' not found")
+	})
+}
+
+func TestFilteredSource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("filters loaded tenants", func(t *testing.T) {
+		source := NewFilteredSource(labeledTenants(), Filter{Exclude: []string{"acme", "globex"}})
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"initech", "umbrella", "hooli"}, ids(tenants))
+		assert.Equal(t, []string{"acme", "globex"}, ExcludedIDs(source))
+	})
+
+	t.Run("unfiltered sources exclude nothing", func(t *testing.T) {
+		assert.Nil(t, ExcludedIDs(labeledTenants()))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		selector, err := migra.ParseSelector("region=apac")
+		require.NoError(t, err)
+
+		source := NewFilteredSource(labeledTenants(), Filter{Selector: selector})
+		_, err = source.LoadTenants(ctx)
+		assert.ErrorContains(t, err, "no tenants match the filter (5 loaded)")
+	})
+}
+
+func TestReadTenantIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# canaries\nacme\n\n  globex  \r\n"), 0644))
+
+	tenantIDs, err := ReadTenantIDs(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme", "globex"}, tenantIDs)
+
+	_, err = ReadTenantIDs(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.ErrorContains(t, err, "failed to open tenants file")
+}
+
+func TestFileSourceLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	content := `
+- id: acme
+  connection:
+    DATABASE_URL: postgres://localhost/acme
+  labels:
+    region: eu
+- id: globex
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	tenants, err := NewFileSource(path).LoadTenants(context.Background())
+	require.NoError(t, err)
+	require.Len(t, tenants, 2)
+	assert.Equal(t, map[string]string{"region": "eu"}, tenants[0].Labels)
+	assert.Nil(t, tenants[1].Labels)
+}
@@ -0,0 +1,353 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// maxErrorBody is how much of an error response is quoted in errors
+const maxErrorBody = 512
+
+// HTTPSource loads tenants from a JSON API. All pages are fetched on the
+// first load and the tenants are kept for the life of the source, so a run
+// asks the API only once.
+type HTTPSource struct {
+	cfg     *config.HTTPSourceConfig
+	client  *http.Client
+	token   string
+	user    string
+	pass    string
+	mu      sync.Mutex
+	tenants []*migra.Tenant
+}
+
+// NewHTTPSource creates a new API-based tenant source
+func NewHTTPSource(cfg *config.HTTPSourceConfig) (*HTTPSource, error) {
+	s := &HTTPSource{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+
+	if auth := cfg.Auth; auth != nil {
+		var err error
+		switch auth.Type {
+		case config.HTTPAuthBearer:
+			s.token, err = requireEnv(auth.TokenEnv)
+		case config.HTTPAuthBasic:
+			if s.user, err = requireEnv(auth.UsernameEnv); err == nil {
+				s.pass, err = requireEnv(auth.PasswordEnv)
+			}
+		default:
+			err = fmt.Errorf("unsupported auth type: %s", auth.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// requireEnv reads an environment variable that must be set
+func requireEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%s environment variable not set", name)
+	}
+	return value, nil
+}
+
+// LoadTenants fetches every page of tenants, or returns the tenants of an
+// earlier load
+func (s *HTTPSource) LoadTenants(ctx context.Context) ([]*migra.Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tenants == nil {
+		items, err := s.fetchAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tenants, err := s.mapTenants(items)
+		if err != nil {
+			return nil, err
+		}
+		s.tenants = tenants
+	}
+
+	return append([]*migra.Tenant(nil), s.tenants...), nil
+}
+
+// fetchAll requests pages until the pagination reports no more, returning
+// the tenant items of all pages
+func (s *HTTPSource) fetchAll(ctx context.Context) ([]interface{}, error) {
+	p := s.cfg.Pagination
+	if p == nil {
+		doc, err := s.fetch(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return s.items(doc)
+	}
+
+	items := make([]interface{}, 0)
+	seen := make(map[string]bool)
+	cursor := ""
+	for page := 1; ; page++ {
+		if p.MaxPages > 0 && page > p.MaxPages {
+			return nil, fmt.Errorf("tenant API returned more than %d pages", p.MaxPages)
+		}
+
+		query := url.Values{}
+		switch p.Type {
+		case config.PaginationCursor:
+			if cursor != "" {
+				query.Set(p.CursorParam, cursor)
+			}
+		case config.PaginationPage:
+			query.Set(p.PageParam, strconv.Itoa(page))
+			if p.SizeParam != "" && p.Size > 0 {
+				query.Set(p.SizeParam, strconv.Itoa(p.Size))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported pagination type: %s", p.Type)
+		}
+
+		doc, err := s.fetch(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		pageItems, err := s.items(doc)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		items = append(items, pageItems...)
+
+		if p.Type == config.PaginationPage {
+			if len(pageItems) == 0 || (p.Size > 0 && len(pageItems) < p.Size) {
+				return items, nil
+			}
+			continue
+		}
+
+		next, ok, err := scalar(lookupPath(doc, p.CursorPath))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: cursor at '%s': %w", page, p.CursorPath, err)
+		}
+		if !ok || next == "" {
+			return items, nil
+		}
+		if seen[next] {
+			return nil, fmt.Errorf("page %d: tenant API repeated cursor '%s'", page, next)
+		}
+		seen[next] = true
+		cursor = next
+	}
+}
+
+// fetch requests one page of the API and decodes its JSON body
+func (s *HTTPSource) fetch(ctx context.Context, query url.Values) (interface{}, error) {
+	u, err := url.Parse(s.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant API URL: %w", err)
+	}
+	if len(query) > 0 {
+		values := u.Query()
+		for key := range query {
+			values.Set(key, query.Get(key))
+		}
+		u.RawQuery = values.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tenant API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range s.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	} else if s.user != "" {
+		req.SetBasicAuth(s.user, s.pass)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request tenants: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant API response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message := strings.TrimSpace(string(body))
+		if len(message) > maxErrorBody {
+			message = message[:maxErrorBody] + "..."
+		}
+		return nil, fmt.Errorf("tenant API returned %s: %s", resp.Status, message)
+	}
+
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse tenant API response as JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// items returns the tenant array of a response
+func (s *HTTPSource) items(doc interface{}) ([]interface{}, error) {
+	value := lookupPath(doc, s.cfg.Path)
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no tenant array at '%s' in tenant API response", s.cfg.Path)
+	}
+	return items, nil
+}
+
+// mapTenants converts API items to tenants, either through the field
+// mapping or by reading them like the file source
+func (s *HTTPSource) mapTenants(items []interface{}) ([]*migra.Tenant, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("tenant API returned no tenants")
+	}
+
+	tenants := make([]*migra.Tenant, 0, len(items))
+	seen := make(map[string]bool)
+	for i, item := range items {
+		tenant, err := s.mapTenant(item)
+		if err != nil {
+			return nil, fmt.Errorf("tenant at index %d: %w", i, err)
+		}
+		if tenant.ID == "" {
+			return nil, fmt.Errorf("tenant at index %d has no ID", i)
+		}
+		if seen[tenant.ID] {
+			return nil, fmt.Errorf("duplicate tenant ID '%s'", tenant.ID)
+		}
+		seen[tenant.ID] = true
+		if tenant.Connection == nil {
+			tenant.Connection = make(map[string]string)
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+// mapTenant converts a single API item to a tenant
+func (s *HTTPSource) mapTenant(item interface{}) (*migra.Tenant, error) {
+	fields := s.cfg.Fields
+	if fields == nil {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var tenant migra.Tenant
+		if err := json.Unmarshal(data, &tenant); err != nil {
+			return nil, fmt.Errorf("failed to read tenant: %w", err)
+		}
+		return &tenant, nil
+	}
+
+	if _, ok := item.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("expected an object")
+	}
+
+	tenant := &migra.Tenant{}
+	var err error
+	if tenant.ID, _, err = mapField(item, fields.ID); err != nil {
+		return nil, err
+	}
+	if fields.Schema != "" {
+		if tenant.Schema, _, err = mapField(item, fields.Schema); err != nil {
+			return nil, err
+		}
+	}
+	if tenant.Connection, err = mapFields(item, fields.Connection); err != nil {
+		return nil, err
+	}
+	labels, err := mapFields(item, fields.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(labels) > 0 {
+		tenant.Labels = labels
+	}
+	return tenant, nil
+}
+
+// mapFields reads the set values of mapped fields
+func mapFields(item interface{}, mapping map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(mapping))
+	for name, path := range mapping {
+		value, ok, err := mapField(item, path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result[name] = value
+		}
+	}
+	return result, nil
+}
+
+// mapField reads a scalar field of an item
+func mapField(item interface{}, path string) (string, bool, error) {
+	value, ok, err := scalar(lookupPath(item, path))
+	if err != nil {
+		return "", false, fmt.Errorf("field '%s': %w", path, err)
+	}
+	return value, ok, nil
+}
+
+// scalar converts a JSON scalar to a string. Missing and null values are
+// reported as unset.
+func scalar(value interface{}) (string, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	case json.Number:
+		return v.String(), true, nil
+	case bool:
+		return strconv.FormatBool(v), true, nil
+	default:
+		return "", false, fmt.Errorf("expected a string, number or boolean")
+	}
+}
+
+// lookupPath walks a dotted path through JSON objects. An empty path or
+// "." is the value itself.
+func lookupPath(doc interface{}, path string) interface{} {
+	if path == "" || path == "." {
+		return doc
+	}
+
+	current := doc
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[key]
+	}
+	return current
+}
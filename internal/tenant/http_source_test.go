@@ -0,0 +1,333 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantAPI starts a test server answering with handler and counting
+// requests
+func tenantAPI(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestHTTPSource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reads tenants like the file source", func(t *testing.T) {
+		server, _ := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Accept"))
+			fmt.Fprint(w, `[{"id":"acme","connection":{"DATABASE_URL":"postgres://db/acme"},"labels":{"region":"eu"}},{"id":"globex"}]`)
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{URL: server.URL})
+		require.NoError(t, err)
+
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		require.Len(t, tenants, 2)
+		assert.Equal(t, "acme", tenants[0].ID)
+		assert.Equal(t, "postgres://db/acme", tenants[0].Connection["DATABASE_URL"])
+		assert.Equal(t, map[string]string{"region": "eu"}, tenants[0].Labels)
+		assert.NotNil(t, tenants[1].Connection)
+	})
+
+	t.Run("maps fields at a path", func(t *testing.T) {
+		server, _ := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data":{"items":[
+				{"slug":"acme","db":{"url":"postgres://db/acme"},"region":"eu","shard":3,"schema":"tenant_acme"},
+				{"slug":42,"db":{"url":"postgres://db/42"},"region":null,"shard":1}
+			]}}`)
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL:  server.URL,
+			Path: "data.items",
+			Fields: &config.TenantFields{
+				ID:         "slug",
+				Schema:     "schema",
+				Connection: map[string]string{"DATABASE_URL": "db.url"},
+				Labels:     map[string]string{"region": "region", "shard": "shard"},
+			},
+		})
+		require.NoError(t, err)
+
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		require.Len(t, tenants, 2)
+		assert.Equal(t, "acme", tenants[0].ID)
+		assert.Equal(t, "tenant_acme", tenants[0].Schema)
+		assert.Equal(t, "postgres://db/acme", tenants[0].Connection["DATABASE_URL"])
+		assert.Equal(t, map[string]string{"region": "eu", "shard": "3"}, tenants[0].Labels)
+		assert.Equal(t, "42", tenants[1].ID)
+		assert.Empty(t, tenants[1].Schema)
+		assert.Equal(t, map[string]string{"shard": "1"}, tenants[1].Labels)
+	})
+
+	t.Run("sends bearer token from env", func(t *testing.T) {
+		t.Setenv("TENANT_API_TOKEN", "s3cret")
+		server, _ := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "migra", r.Header.Get("X-Client"))
+			fmt.Fprint(w, `[{"id":"acme"}]`)
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL:     server.URL,
+			Headers: map[string]string{"X-Client": "migra"},
+			Auth:    &config.HTTPAuthConfig{Type: config.HTTPAuthBearer, TokenEnv: "TENANT_API_TOKEN"},
+		})
+		require.NoError(t, err)
+
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tenants, 1)
+	})
+
+	t.Run("sends basic auth from env", func(t *testing.T) {
+		t.Setenv("TENANT_API_USER", "deploy")
+		t.Setenv("TENANT_API_PASSWORD", "hunter2")
+		server, _ := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "deploy" || pass != "hunter2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `[{"id":"acme"}]`)
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL: server.URL,
+			Auth: &config.HTTPAuthConfig{
+				Type:        config.HTTPAuthBasic,
+				UsernameEnv: "TENANT_API_USER",
+				PasswordEnv: "TENANT_API_PASSWORD",
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = source.LoadTenants(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("requires auth env vars", func(t *testing.T) {
+		_, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL:  "http://localhost",
+			Auth: &config.HTTPAuthConfig{Type: config.HTTPAuthBearer, TokenEnv: "MIGRA_TEST_UNSET_TOKEN"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "MIGRA_TEST_UNSET_TOKEN environment variable not set")
+	})
+
+	t.Run("follows cursors", func(t *testing.T) {
+		pages := map[string]string{
+			"":   `{"tenants":[{"id":"t001"},{"id":"t002"}],"next":"c2"}`,
+			"c2": `{"tenants":[{"id":"t003"}],"next":"c3"}`,
+			"c3": `{"tenants":[{"id":"t004"}],"next":null}`,
+		}
+		server, requests := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "active", r.URL.Query().Get("status"))
+			fmt.Fprint(w, pages[r.URL.Query().Get("after")])
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL:  server.URL + "?status=active",
+			Path: "tenants",
+			Pagination: &config.PaginationConfig{
+				Type:        config.PaginationCursor,
+				CursorParam: "after",
+				CursorPath:  "next",
+				MaxPages:    10,
+			},
+		})
+		require.NoError(t, err)
+
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t001", "t002", "t003", "t004"}, ids(tenants))
+		assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+	})
+
+	t.Run("stops on a repeated cursor", func(t *testing.T) {
+		server, _ := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"tenants":[{"id":"t001"}],"next":"same"}`)
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL:  server.URL,
+			Path: "tenants",
+			Pagination: &config.PaginationConfig{
+				Type:        config.PaginationCursor,
+				CursorParam: "cursor",
+				CursorPath:  "next",
+				MaxPages:    10,
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = source.LoadTenants(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "repeated cursor 'same'")
+	})
+
+	t.Run("counts pages", func(t *testing.T) {
+		server, requests := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			assert.Equal(t, "2", r.URL.Query().Get("per_page"))
+			switch page {
+			case 1:
+				fmt.Fprint(w, `[{"id":"t001"},{"id":"t002"}]`)
+			case 2:
+				fmt.Fprint(w, `[{"id":"t003"},{"id":"t004"}]`)
+			case 3:
+				fmt.Fprint(w, `[{"id":"t005"}]`)
+			default:
+				t.Errorf("unexpected page %d", page)
+			}
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL: server.URL,
+			Pagination: &config.PaginationConfig{
+				Type:      config.PaginationPage,
+				PageParam: "page",
+				SizeParam: "per_page",
+				Size:      2,
+				MaxPages:  10,
+			},
+		})
+		require.NoError(t, err)
+
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t001", "t002", "t003", "t004", "t005"}, ids(tenants))
+		assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+	})
+
+	t.Run("limits pages", func(t *testing.T) {
+		server, _ := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `[{"id":"t%s"}]`, r.URL.Query().Get("page"))
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{
+			URL:        server.URL,
+			Pagination: &config.PaginationConfig{Type: config.PaginationPage, PageParam: "page", MaxPages: 3},
+		})
+		require.NoError(t, err)
+
+		_, err = source.LoadTenants(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than 3 pages")
+	})
+
+	t.Run("caches tenants for the run", func(t *testing.T) {
+		server, requests := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"id":"acme"},{"id":"globex"}]`)
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{URL: server.URL})
+		require.NoError(t, err)
+
+		first, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		first[0] = nil
+
+		second, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme", "globex"}, ids(second))
+		assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	})
+
+	t.Run("does not cache failures", func(t *testing.T) {
+		var fail int32 = 1
+		server, requests := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.CompareAndSwapInt32(&fail, 1, 0) {
+				w.WriteHeader(http.StatusBadGateway)
+				fmt.Fprint(w, `{"error":"upstream unavailable"}`)
+				return
+			}
+			fmt.Fprint(w, `[{"id":"acme"}]`)
+		})
+
+		source, err := NewHTTPSource(&config.HTTPSourceConfig{URL: server.URL})
+		require.NoError(t, err)
+
+		_, err = source.LoadTenants(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "502 Bad Gateway")
+		assert.Contains(t, err.Error(), "upstream unavailable")
+
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tenants, 1)
+		assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+	})
+
+	t.Run("rejects invalid responses", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			path   string
+			fields *config.TenantFields
+			errMsg string
+		}{
+			{name: "not JSON", body: `<html>`, errMsg: "failed to parse tenant API response as JSON"},
+			{name: "no array", body: `{"data":{}}`, path: "data.items", errMsg: "no tenant array at 'data.items'"},
+			{name: "empty", body: `[]`, errMsg: "tenant API returned no tenants"},
+			{name: "missing ID", body: `[{"name":"acme"}]`, errMsg: "tenant at index 0 has no ID"},
+			{name: "duplicate ID", body: `[{"id":"acme"},{"id":"acme"}]`, errMsg: "duplicate tenant ID 'acme'"},
+			{
+				name:   "object field",
+				body:   `[{"id":{"value":"acme"}}]`,
+				fields: &config.TenantFields{ID: "id"},
+				errMsg: "field 'id': expected a string, number or boolean",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server, _ := tenantAPI(t, func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprint(w, tt.body)
+				})
+
+				source, err := NewHTTPSource(&config.HTTPSourceConfig{URL: server.URL, Path: tt.path, Fields: tt.fields})
+				require.NoError(t, err)
+
+				_, err = source.LoadTenants(ctx)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			})
+		}
+	})
+
+	t.Run("is created by NewSource", func(t *testing.T) {
+		source, err := NewSource(&config.TenancyConfig{
+			Source: &config.TenantSourceConfig{
+				Type: config.TenantSourceHTTP,
+				HTTP: &config.HTTPSourceConfig{URL: "http://localhost"},
+			},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, &HTTPSource{}, source)
+	})
+}
@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// restWaveName names the implicit wave of tenants not covered by any
+// configured wave
+const restWaveName = "rest"
+
+// Wave is a group of tenants deployed together in a staged rollout
+type Wave struct {
+	Name              string
+	Tenants           []*migra.Tenant
+	MaxFailurePercent float64
+}
+
+// TenantIDs returns the IDs of the wave's tenants
+func (w *Wave) TenantIDs() []string {
+	ids := make([]string, len(w.Tenants))
+	for i, t := range w.Tenants {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+// Breached reports whether the failed tenants exceed the wave's threshold
+func (w *Wave) Breached(failed int) bool {
+	if len(w.Tenants) == 0 || failed == 0 {
+		return false
+	}
+	return float64(failed)*100/float64(len(w.Tenants)) > w.MaxFailurePercent
+}
+
+// ConfirmFunc is asked before a wave starts and stops the rollout when it
+// returns false
+type ConfirmFunc func(ctx context.Context, next *Wave) (bool, error)
+
+// PlanWaves splits tenants into the waves of a rollout, keeping the
+// source order. Percentage waves fill up to their share of the run's
+// tenants, and tenants left over after the last wave form a final wave.
+// Waves that end up empty are dropped. Listed tenants that the filter
+// excluded from the run are skipped and returned as ignored; any other
+// listed tenant must be part of the run.
+func PlanWaves(tenants []*migra.Tenant, excluded []string, rollout *config.RolloutConfig) ([]Wave, []string, error) {
+	if rollout == nil {
+		return []Wave{{Name: "all", Tenants: tenants, MaxFailurePercent: 100}}, nil, nil
+	}
+
+	byID := make(map[string]*migra.Tenant, len(tenants))
+	for _, t := range tenants {
+		byID[t.ID] = t
+	}
+	filtered := make(map[string]bool, len(excluded))
+	for _, id := range excluded {
+		filtered[id] = true
+	}
+	ignored := make([]string, 0)
+
+	assigned := make(map[string]bool, len(tenants))
+	waves := make([]Wave, 0, len(rollout.Waves)+1)
+	for i, wc := range rollout.Waves {
+		wave := Wave{
+			Name:              wc.Name,
+			Tenants:           make([]*migra.Tenant, 0),
+			MaxFailurePercent: rollout.MaxFailurePercent,
+		}
+		if wave.Name == "" {
+			wave.Name = fmt.Sprintf("wave %d", i+1)
+		}
+		if wc.MaxFailurePercent != nil {
+			wave.MaxFailurePercent = *wc.MaxFailurePercent
+		}
+
+		switch {
+		case len(wc.Tenants) > 0:
+			for _, id := range wc.Tenants {
+				t, ok := byID[id]
+				switch {
+				case ok:
+					if !assigned[id] {
+						wave.Tenants = append(wave.Tenants, t)
+						assigned[id] = true
+					}
+				case filtered[id]:
+					ignored = append(ignored, id)
+				default:
+					return nil, nil, fmt.Errorf("wave %s: tenant '%s' not found", wave.Name, id)
+				}
+			}
+		case wc.Percent > 0:
+			target := int(math.Ceil(wc.Percent * float64(len(tenants)) / 100))
+			for _, t := range tenants {
+				if len(assigned) >= target {
+					break
+				}
+				if !assigned[t.ID] {
+					wave.Tenants = append(wave.Tenants, t)
+					assigned[t.ID] = true
+				}
+			}
+		default:
+			wave.Tenants = unassigned(tenants, assigned)
+		}
+
+		if len(wave.Tenants) > 0 {
+			waves = append(waves, wave)
+		}
+	}
+
+	if rest := unassigned(tenants, assigned); len(rest) > 0 {
+		waves = append(waves, Wave{
+			Name:              restWaveName,
+			Tenants:           rest,
+			MaxFailurePercent: rollout.MaxFailurePercent,
+		})
+	}
+	return waves, ignored, nil
+}
+
+// unassigned returns the tenants not yet in a wave, marking them assigned
+func unassigned(tenants []*migra.Tenant, assigned map[string]bool) []*migra.Tenant {
+	rest := make([]*migra.Tenant, 0)
+	for _, t := range tenants {
+		if !assigned[t.ID] {
+			rest = append(rest, t)
+			assigned[t.ID] = true
+		}
+	}
+	return rest
+}
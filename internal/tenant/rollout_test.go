@@ -0,0 +1,258 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/state"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantAdapter fails deploys for the listed tenants
+type tenantAdapter struct {
+	mu       sync.Mutex
+	fail     map[string]bool
+	deployed []string
+}
+
+func (a *tenantAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.deployed = append(a.deployed, tenant.ID)
+
+	if a.fail[tenant.ID] {
+		return &migra.Result{Success: false, Error: "boom", Timestamp: time.Now()}, nil
+	}
+	return &migra.Result{Success: true, Timestamp: time.Now()}, nil
+}
+
+func (a *tenantAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	return a.Deploy(ctx, service, tenant)
+}
+
+func (a *tenantAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	return &migra.StatusResult{}, nil
+}
+
+func (a *tenantAdapter) Name() string {
+	return "fake"
+}
+
+// staticSource returns a fixed list of tenants
+type staticSource []*migra.Tenant
+
+func (s staticSource) LoadTenants(ctx context.Context) ([]*migra.Tenant, error) {
+	return s, nil
+}
+
+func makeTenants(n int) staticSource {
+	tenants := make(staticSource, n)
+	for i := range tenants {
+		tenants[i] = &migra.Tenant{ID: fmt.Sprintf("t%03d", i+1)}
+	}
+	return tenants
+}
+
+func newTestExecutor(t *testing.T, source Source, adp migra.Adapter) *Executor {
+	registry := adapter.NewRegistry()
+	registry.Register("fake", adp)
+
+	stateManager := state.NewManager(t.TempDir())
+	require.NoError(t, stateManager.Load())
+
+	log := logger.NewLogger("console", logger.LevelError, false, true)
+	return NewExecutor(source, registry, stateManager, log, false, 4)
+}
+
+func percent(p float64) *float64 {
+	return &p
+}
+
+func TestPlanWaves(t *testing.T) {
+	tenants := makeTenants(40)
+
+	t.Run("canary, percentages and the rest", func(t *testing.T) {
+		rollout := &config.RolloutConfig{
+			MaxFailurePercent: 1,
+			Waves: []config.WaveConfig{
+				{Name: "canary", Tenants: []string{"t010", "t020"}, MaxFailurePercent: percent(0)},
+				{Percent: 10},
+				{Name: "quarter", Percent: 25},
+			},
+		}
+
+		waves, _, err := PlanWaves(tenants, nil, rollout)
+		require.NoError(t, err)
+		require.Len(t, waves, 4)
+
+		assert.Equal(t, "canary", waves[0].Name)
+		assert.Equal(t, []string{"t010", "t020"}, waves[0].TenantIDs())
+		assert.Equal(t, 0.0, waves[0].MaxFailurePercent)
+
+		// 10% of 40 is 4 tenants in total, including the canaries
+		assert.Equal(t, "wave 2", waves[1].Name)
+		assert.Equal(t, []string{"t001", "t002"}, waves[1].TenantIDs())
+		assert.Equal(t, 1.0, waves[1].MaxFailurePercent)
+
+		assert.Equal(t, "quarter", waves[2].Name)
+		assert.Len(t, waves[2].Tenants, 6)
+
+		assert.Equal(t, "rest", waves[3].Name)
+		assert.Len(t, waves[3].Tenants, 30)
+	})
+
+	t.Run("explicit final wave", func(t *testing.T) {
+		waves, _, err := PlanWaves(tenants, nil, &config.RolloutConfig{
+			Waves: []config.WaveConfig{{Percent: 50}, {Name: "everyone else"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, waves, 2)
+		assert.Equal(t, "everyone else", waves[1].Name)
+		assert.Len(t, waves[1].Tenants, 20)
+	})
+
+	t.Run("unknown canary", func(t *testing.T) {
+		_, _, err := PlanWaves(tenants, nil, &config.RolloutConfig{
+			Waves: []config.WaveConfig{{Name: "canary", Tenants: []string{"t005", "t05"}}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "wave canary: tenant 't05' not found")
+	})
+
+	t.Run("canary filtered out of the run", func(t *testing.T) {
+		waves, ignored, err := PlanWaves(tenants, []string{"t041", "t042"}, &config.RolloutConfig{
+			Waves: []config.WaveConfig{
+				{Name: "canary", Tenants: []string{"t041", "t005"}},
+				{Name: "internal", Tenants: []string{"t042"}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t041", "t042"}, ignored)
+		require.Len(t, waves, 2)
+		assert.Equal(t, []string{"t005"}, waves[0].TenantIDs())
+		assert.Equal(t, "rest", waves[1].Name)
+		assert.Len(t, waves[1].Tenants, 39)
+	})
+
+	t.Run("no rollout", func(t *testing.T) {
+		waves, _, err := PlanWaves(tenants, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, waves, 1)
+		assert.Len(t, waves[0].Tenants, 40)
+	})
+}
+
+func TestExecuteWaves(t *testing.T) {
+	services := []migra.Service{{Name: "api", Type: "fake"}}
+	rollout := &config.RolloutConfig{
+		MaxFailurePercent: 10,
+		Waves: []config.WaveConfig{
+			{Name: "canary", Tenants: []string{"t001"}},
+			{Name: "half", Percent: 50},
+		},
+	}
+
+	t.Run("runs every wave", func(t *testing.T) {
+		adp := &tenantAdapter{fail: map[string]bool{}}
+		executor := newTestExecutor(t, makeTenants(20), adp)
+		executor.SetRollout(rollout, nil)
+
+		events := make([]migra.Event, 0)
+		var mu sync.Mutex
+		executor.SetEventHandler(func(e migra.Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			if e.Type == migra.EventWaveFinished {
+				events = append(events, e)
+			}
+		})
+
+		results, err := executor.Execute(context.Background(), services, migra.OperationDeploy)
+		require.NoError(t, err)
+		assert.Len(t, results, 20)
+		for _, r := range results {
+			assert.True(t, r.Success)
+		}
+		require.Len(t, events, 3)
+		assert.Equal(t, []string{"canary", "half", "rest"}, []string{events[0].Wave, events[1].Wave, events[2].Wave})
+
+		// The canary runs before anything else
+		assert.Equal(t, "t001", adp.deployed[0])
+	})
+
+	t.Run("aborts when a wave breaches its threshold", func(t *testing.T) {
+		// 2 of the 9 tenants of the second wave fail, above 10%
+		adp := &tenantAdapter{fail: map[string]bool{"t002": true, "t003": true}}
+		executor := newTestExecutor(t, makeTenants(20), adp)
+		executor.SetRollout(rollout, nil)
+
+		results, err := executor.Execute(context.Background(), services, migra.OperationDeploy)
+		require.NoError(t, err)
+		require.Len(t, results, 20)
+
+		skipped := 0
+		for _, r := range results {
+			if r.Skipped {
+				skipped++
+				assert.Contains(t, r.Error, "rollout aborted: 2 of 9 tenants failed in wave half")
+				require.Len(t, r.Services, 1)
+				assert.True(t, r.Services[0].Skipped)
+			}
+		}
+		assert.Equal(t, 10, skipped)
+		assert.Len(t, adp.deployed, 10)
+	})
+
+	t.Run("stops when a wave is not confirmed", func(t *testing.T) {
+		confirmed := make([]string, 0)
+		confirmRollout := *rollout
+		confirmRollout.Confirm = true
+
+		adp := &tenantAdapter{fail: map[string]bool{}}
+		executor := newTestExecutor(t, makeTenants(20), adp)
+		executor.SetRollout(&confirmRollout, func(ctx context.Context, next *Wave) (bool, error) {
+			confirmed = append(confirmed, next.Name)
+			return next.Name != "rest", nil
+		})
+
+		results, err := executor.Execute(context.Background(), services, migra.OperationDeploy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"half", "rest"}, confirmed)
+		assert.Len(t, adp.deployed, 10)
+		assert.True(t, results[len(results)-1].Skipped)
+		assert.Contains(t, results[len(results)-1].Error, "not confirmed")
+	})
+
+	t.Run("skips canaries excluded by the filter", func(t *testing.T) {
+		adp := &tenantAdapter{fail: map[string]bool{}}
+		source := NewFilteredSource(makeTenants(20), Filter{Exclude: []string{"t001"}})
+		executor := newTestExecutor(t, source, adp)
+		executor.SetRollout(rollout, nil)
+
+		results, err := executor.Execute(context.Background(), services, migra.OperationDeploy)
+		require.NoError(t, err)
+		assert.Len(t, results, 19)
+		assert.NotContains(t, adp.deployed, "t001")
+	})
+
+	t.Run("fails on unknown wave tenants", func(t *testing.T) {
+		adp := &tenantAdapter{fail: map[string]bool{}}
+		executor := newTestExecutor(t, makeTenants(20), adp)
+		executor.SetRollout(&config.RolloutConfig{
+			Waves: []config.WaveConfig{{Name: "canary", Tenants: []string{"t0001"}}},
+		}, nil)
+
+		_, err := executor.Execute(context.Background(), services, migra.OperationDeploy)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "wave canary: tenant 't0001' not found")
+		assert.Empty(t, adp.deployed)
+	})
+}
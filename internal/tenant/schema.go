@@ -0,0 +1,52 @@
+package tenant
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/migra/migra/pkg/migra"
+)
+
+// maxSchemaLength is the length PostgreSQL truncates identifiers to
+const maxSchemaLength = 63
+
+// invalidSchemaChars matches characters not kept in derived schema names
+var invalidSchemaChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// SchemaName returns the schema of a tenant: the schema set by its source,
+// used as it is, or the template with {id} replaced by the tenant ID.
+// Template names are lowercased and other characters than letters, digits
+// and underscores are replaced, so they need no quoting.
+func SchemaName(template string, t *migra.Tenant) string {
+	if t.Schema != "" {
+		return t.Schema
+	}
+	name := strings.ReplaceAll(template, "{id}", t.ID)
+	return invalidSchemaChars.ReplaceAllString(strings.ToLower(name), "_")
+}
+
+// CheckSchemas fails when two tenants resolve to the same schema, such as
+// IDs differing only in case or punctuation, since they would migrate it
+// concurrently. Names are compared as PostgreSQL truncates them.
+func CheckSchemas(template string, tenants []*migra.Tenant) error {
+	owners := make(map[string]string, len(tenants))
+	for _, t := range tenants {
+		name := SchemaName(template, t)
+		if len(name) > maxSchemaLength {
+			name = name[:maxSchemaLength]
+		}
+		if owner, ok := owners[name]; ok {
+			return fmt.Errorf("tenants '%s' and '%s' both use schema '%s'", owner, t.ID, name)
+		}
+		owners[name] = t.ID
+	}
+	return nil
+}
+
+// withSchema returns a copy of the tenant with its schema resolved
+func withSchema(template string, t *migra.Tenant) *migra.Tenant {
+	copied := *t
+	copied.Schema = SchemaName(template, t)
+	return &copied
+}
@@ -0,0 +1,113 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaAdapter records the schema each tenant was deployed to
+type schemaAdapter struct {
+	tenantAdapter
+	mu      sync.Mutex
+	schemas map[string]string
+}
+
+func (a *schemaAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.schemas[tenant.ID] = tenant.Connection[adapter.SchemaEnvVar]
+	return &migra.Result{Success: true, Timestamp: time.Now()}, nil
+}
+
+func TestSchemaName(t *testing.T) {
+	assert.Equal(t, "acme", SchemaName("{id}", &migra.Tenant{ID: "acme"}))
+	assert.Equal(t, "tenant_acme_corp", SchemaName("tenant_{id}", &migra.Tenant{ID: "Acme-Corp"}))
+	assert.Equal(t, "custom", SchemaName("tenant_{id}", &migra.Tenant{ID: "acme", Schema: "custom"}))
+	assert.Equal(t, "TenantA", SchemaName("tenant_{id}", &migra.Tenant{ID: "acme", Schema: "TenantA"}), "source schemas are used as they are")
+}
+
+func TestCheckSchemas(t *testing.T) {
+	assert.NoError(t, CheckSchemas("tenant_{id}", []*migra.Tenant{
+		{ID: "acme"},
+		{ID: "globex"},
+		{ID: "legacy", Schema: "Tenant_Acme"},
+	}))
+
+	tests := []struct {
+		name    string
+		tenants []*migra.Tenant
+		errMsg  string
+	}{
+		{
+			name:    "IDs differing in case",
+			tenants: []*migra.Tenant{{ID: "Acme"}, {ID: "acme"}},
+			errMsg:  "tenants 'Acme' and 'acme' both use schema 'tenant_acme'",
+		},
+		{
+			name:    "IDs differing in punctuation",
+			tenants: []*migra.Tenant{{ID: "a-b"}, {ID: "a.b"}},
+			errMsg:  "tenants 'a-b' and 'a.b' both use schema 'tenant_a_b'",
+		},
+		{
+			name:    "source schema matching a derived one",
+			tenants: []*migra.Tenant{{ID: "acme"}, {ID: "legacy", Schema: "tenant_acme"}},
+			errMsg:  "tenants 'acme' and 'legacy' both use schema 'tenant_acme'",
+		},
+		{
+			name: "names equal once truncated",
+			tenants: []*migra.Tenant{
+				{ID: "a", Schema: strings.Repeat("x", 63) + "1"},
+				{ID: "b", Schema: strings.Repeat("x", 63) + "2"},
+			},
+			errMsg: "tenants 'a' and 'b' both use schema",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSchemas("tenant_{id}", tt.tenants)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestExecuteSchemaPerTenant(t *testing.T) {
+	adp := &schemaAdapter{schemas: make(map[string]string)}
+	source := staticSource{{ID: "acme"}, {ID: "globex", Schema: "legacy_globex"}}
+	executor := newTestExecutor(t, source, adp)
+	executor.SetSchemaPerTenant("tenant_{id}", false)
+
+	services := []migra.Service{{Name: "api", Type: "fake"}}
+	results, err := executor.Execute(context.Background(), services, migra.OperationDeploy)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, map[string]string{"acme": "tenant_acme", "globex": "legacy_globex"}, adp.schemas)
+
+	// The tenants of the source are left untouched
+	assert.Empty(t, source[0].Schema)
+	assert.Nil(t, source[0].Connection)
+}
+
+func TestExecuteSchemaCollision(t *testing.T) {
+	adp := &schemaAdapter{schemas: make(map[string]string)}
+	executor := newTestExecutor(t, staticSource{{ID: "Acme"}, {ID: "acme"}}, adp)
+	executor.SetSchemaPerTenant("tenant_{id}", false)
+
+	services := []migra.Service{{Name: "api", Type: "fake"}}
+	_, err := executor.Execute(context.Background(), services, migra.OperationDeploy)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "both use schema 'tenant_acme'")
+	assert.Empty(t, adp.schemas)
+
+	_, err = executor.Status(context.Background(), services)
+	assert.Error(t, err)
+}
@@ -2,7 +2,11 @@ package tenant
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 
+	"github.com/migra/migra/internal/config"
 	"github.com/migra/migra/pkg/migra"
 )
 
@@ -10,3 +14,69 @@ import (
 type Source interface {
 	LoadTenants(ctx context.Context) ([]*migra.Tenant, error)
 }
+
+// NewSource creates the tenant source of the tenancy.source block. Options
+// left out of the block fall back to the MIGRA_TENANTS_FILE and
+// MIGRA_TENANTS_COMMAND environment variables.
+func NewSource(cfg *config.TenancyConfig) (Source, error) {
+	options := cfg.Source
+	if options == nil {
+		return nil, fmt.Errorf("tenancy.source is required when tenancy is enabled")
+	}
+
+	switch options.Type {
+	case config.TenantSourceEnv:
+		return NewEnvSource(options.Env), nil
+	case config.TenantSourceFile:
+		filePath := options.File
+		if filePath == "" {
+			filePath = os.Getenv("MIGRA_TENANTS_FILE")
+		}
+		if filePath == "" {
+			filePath = config.DefaultTenantsFile
+		}
+		return NewFileSource(filePath), nil
+	case config.TenantSourceCommand:
+		return newCommandSource(options)
+	case config.TenantSourceSQL:
+		if options.SQL == nil {
+			return nil, fmt.Errorf("tenancy.source.sql is required for the sql tenant source")
+		}
+		return NewSQLSource(options.SQL)
+	case config.TenantSourceHTTP:
+		if options.HTTP == nil {
+			return nil, fmt.Errorf("tenancy.source.http is required for the http tenant source")
+		}
+		return NewHTTPSource(options.HTTP)
+	default:
+		return nil, fmt.Errorf("unsupported tenant source: %s", options.Type)
+	}
+}
+
+// newCommandSource creates the command source of the source options.
+// MIGRA_TENANTS_COMMAND is run by the shell in shell mode; otherwise it is
+// split on whitespace, as before the source block existed, so its
+// arguments cannot contain spaces.
+func newCommandSource(options *config.TenantSourceConfig) (*CommandSource, error) {
+	command := options.Command
+	if len(command) == 0 {
+		if env := strings.TrimSpace(os.Getenv("MIGRA_TENANTS_COMMAND")); env != "" {
+			command = []string{env}
+			if !options.Shell {
+				command = strings.Fields(env)
+			}
+		}
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("tenancy.source.command or MIGRA_TENANTS_COMMAND is required for the command tenant source")
+	}
+
+	var source *CommandSource
+	if options.Shell {
+		source = NewShellCommandSource(strings.Join(command, " "))
+	} else {
+		source = NewCommandSource(command[0], command[1:]...)
+	}
+	source.SetDir(options.Dir)
+	return source, nil
+}
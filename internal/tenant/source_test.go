@@ -0,0 +1,108 @@
+package tenant
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	ctx := context.Background()
+
+	load := func(t *testing.T, cfg *config.TenancyConfig) []string {
+		t.Helper()
+		source, err := NewSource(cfg)
+		require.NoError(t, err)
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		return ids(tenants)
+	}
+
+	t.Run("reads the configured env var", func(t *testing.T) {
+		t.Setenv("BILLING_TENANTS", "acme:postgres://db/acme,globex:postgres://db/globex")
+		assert.Equal(t, []string{"acme", "globex"}, load(t, &config.TenancyConfig{
+			Source: &config.TenantSourceConfig{Type: config.TenantSourceEnv, Env: "BILLING_TENANTS"},
+		}))
+	})
+
+	t.Run("reads the configured file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "fleet.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("- id: acme\n- id: globex\n"), 0644))
+		t.Setenv("MIGRA_TENANTS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+
+		assert.Equal(t, []string{"acme", "globex"}, load(t, &config.TenancyConfig{
+			Source: &config.TenantSourceConfig{Type: config.TenantSourceFile, File: path},
+		}))
+	})
+
+	t.Run("falls back to the legacy env vars", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tenants.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[{"id":"initech"}]`), 0644))
+		t.Setenv("MIGRA_TENANTS_FILE", path)
+
+		assert.Equal(t, []string{"initech"}, load(t, &config.TenancyConfig{Source: &config.TenantSourceConfig{Type: config.TenantSourceFile}}))
+	})
+
+	t.Run("requires a command", func(t *testing.T) {
+		t.Setenv("MIGRA_TENANTS_COMMAND", "")
+		_, err := NewSource(&config.TenancyConfig{Source: &config.TenantSourceConfig{Type: config.TenantSourceCommand}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tenancy.source.command or MIGRA_TENANTS_COMMAND is required")
+	})
+
+	if runtime.GOOS == "windows" {
+		t.Skip("command tests use a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tenants.json"), []byte(`[{"id":"acme"},{"id":"globex"}]`), 0644))
+
+	t.Run("runs the command with arguments in its directory", func(t *testing.T) {
+		assert.Equal(t, []string{"acme", "globex"}, load(t, &config.TenancyConfig{
+			Source: &config.TenantSourceConfig{
+				Type:    config.TenantSourceCommand,
+				Command: []string{"sed", "-n", "p", "tenants.json"},
+				Dir:     dir,
+			},
+		}))
+	})
+
+	t.Run("passes arguments with spaces unsplit", func(t *testing.T) {
+		assert.Equal(t, []string{"acme corp"}, load(t, &config.TenancyConfig{
+			Source: &config.TenantSourceConfig{
+				Type:    config.TenantSourceCommand,
+				Command: []string{"echo", `[{"id": "acme corp"}]`},
+			},
+		}))
+	})
+
+	t.Run("runs a script in shell mode", func(t *testing.T) {
+		assert.Equal(t, []string{"acme", "globex"}, load(t, &config.TenancyConfig{
+			Source: &config.TenantSourceConfig{
+				Type:    config.TenantSourceCommand,
+				Command: []string{"cat tenants.json | tr -d ' '"},
+				Shell:   true,
+				Dir:     dir,
+			},
+		}))
+	})
+	t.Run("runs MIGRA_TENANTS_COMMAND in shell mode", func(t *testing.T) {
+		t.Setenv("MIGRA_TENANTS_COMMAND", "cat tenants.json | tr -d ' '")
+		assert.Equal(t, []string{"acme", "globex"}, load(t, &config.TenancyConfig{
+			Source: &config.TenantSourceConfig{Type: config.TenantSourceCommand, Shell: true, Dir: dir},
+		}))
+	})
+
+	t.Run("splits MIGRA_TENANTS_COMMAND on whitespace", func(t *testing.T) {
+		t.Setenv("MIGRA_TENANTS_COMMAND", "sed -n p tenants.json")
+		assert.Equal(t, []string{"acme", "globex"}, load(t, &config.TenancyConfig{
+			Source: &config.TenantSourceConfig{Type: config.TenantSourceCommand, Dir: dir},
+		}))
+	})
+}
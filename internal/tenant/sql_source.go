@@ -0,0 +1,146 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/pkg/migra"
+
+	// Register the database/sql drivers of the supported databases
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// SQLSource loads tenants by running a query. Every row is a tenant whose
+// ID, schema, connection variables and labels are read from the mapped
+// columns. NULL values are left out.
+type SQLSource struct {
+	driver string
+	dsn    string
+	cfg    *config.SQLSourceConfig
+}
+
+// NewSQLSource creates a new query-based tenant source
+func NewSQLSource(cfg *config.SQLSourceConfig) (*SQLSource, error) {
+	dsn := cfg.DSN
+	if cfg.DSNEnv != "" {
+		dsn = os.Getenv(cfg.DSNEnv)
+		if dsn == "" {
+			return nil, fmt.Errorf("%s environment variable not set", cfg.DSNEnv)
+		}
+	}
+
+	return &SQLSource{
+		driver: cfg.Driver,
+		dsn:    dsn,
+		cfg:    cfg,
+	}, nil
+}
+
+// LoadTenants runs the query and maps its rows to tenants
+func (s *SQLSource) LoadTenants(ctx context.Context) ([]*migra.Tenant, error) {
+	db, err := sql.Open(s.driver, s.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s connection: %w", s.driver, err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, s.cfg.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenants: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant columns: %w", err)
+	}
+	index, err := s.columnIndex(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := make([]*migra.Tenant, 0)
+	seen := make(map[string]bool)
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read tenant row: %w", err)
+		}
+
+		id := values[index[s.cfg.IDColumn]]
+		if !id.Valid || id.String == "" {
+			return nil, fmt.Errorf("tenant at row %d has no ID", len(tenants)+1)
+		}
+		if seen[id.String] {
+			return nil, fmt.Errorf("duplicate tenant ID '%s'", id.String)
+		}
+		seen[id.String] = true
+
+		tenant := &migra.Tenant{
+			ID:         id.String,
+			Connection: mapColumns(s.cfg.Connection, index, values),
+		}
+		if labels := mapColumns(s.cfg.Labels, index, values); len(labels) > 0 {
+			tenant.Labels = labels
+		}
+		if s.cfg.SchemaColumn != "" {
+			tenant.Schema = values[index[s.cfg.SchemaColumn]].String
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tenants: %w", err)
+	}
+
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("query returned no tenants")
+	}
+	return tenants, nil
+}
+
+// columnIndex locates the mapped columns in the query result
+func (s *SQLSource) columnIndex(columns []string) (map[string]int, error) {
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		index[column] = i
+	}
+
+	required := []string{s.cfg.IDColumn}
+	if s.cfg.SchemaColumn != "" {
+		required = append(required, s.cfg.SchemaColumn)
+	}
+	for _, column := range s.cfg.Connection {
+		required = append(required, column)
+	}
+	for _, column := range s.cfg.Labels {
+		required = append(required, column)
+	}
+
+	for _, column := range required {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("tenant query returns no column '%s'", column)
+		}
+	}
+	return index, nil
+}
+
+// mapColumns reads the non-NULL values of mapped columns
+func mapColumns(mapping map[string]string, index map[string]int, values []sql.NullString) map[string]string {
+	result := make(map[string]string, len(mapping))
+	for name, column := range mapping {
+		if value := values[index[column]]; value.Valid {
+			result[name] = value.String
+		}
+	}
+	return result
+}
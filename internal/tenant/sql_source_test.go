@@ -0,0 +1,125 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/migra/migra/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantDB creates a SQLite tenant registry and returns its DSN
+func tenantDB(t *testing.T, statements ...string) string {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "tenants.db")
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, stmt := range statements {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	return dsn
+}
+
+func TestSQLSource(t *testing.T) {
+	ctx := context.Background()
+	dsn := tenantDB(t,
+		`CREATE TABLE tenants (slug TEXT, db_url TEXT, region TEXT, plan TEXT, schema_name TEXT, active INTEGER)`,
+		`INSERT INTO tenants VALUES ('acme', 'postgres://db/acme', 'eu', 'enterprise', 'tenant_acme', 1)`,
+		`INSERT INTO tenants VALUES ('globex', 'postgres://db/globex', NULL, 'free', NULL, 1)`,
+		`INSERT INTO tenants VALUES ('initech', 'postgres://db/initech', 'us', 'free', NULL, 0)`,
+	)
+
+	sourceConfig := func() *config.SQLSourceConfig {
+		return &config.SQLSourceConfig{
+			Driver:       config.SQLDriverSQLite,
+			DSN:          dsn,
+			Query:        "SELECT slug, db_url, region, plan, schema_name FROM tenants WHERE active = 1 ORDER BY slug",
+			IDColumn:     "slug",
+			SchemaColumn: "schema_name",
+			Connection:   map[string]string{"DATABASE_URL": "db_url"},
+			Labels:       map[string]string{"region": "region", "plan": "plan"},
+		}
+	}
+
+	t.Run("maps columns", func(t *testing.T) {
+		source, err := NewSQLSource(sourceConfig())
+		require.NoError(t, err)
+
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		require.Len(t, tenants, 2)
+
+		assert.Equal(t, "acme", tenants[0].ID)
+		assert.Equal(t, "tenant_acme", tenants[0].Schema)
+		assert.Equal(t, map[string]string{"DATABASE_URL": "postgres://db/acme"}, tenants[0].Connection)
+		assert.Equal(t, map[string]string{"region": "eu", "plan": "enterprise"}, tenants[0].Labels)
+
+		// NULL values are left out
+		assert.Equal(t, "globex", tenants[1].ID)
+		assert.Empty(t, tenants[1].Schema)
+		assert.Equal(t, map[string]string{"plan": "free"}, tenants[1].Labels)
+	})
+
+	t.Run("DSN from environment", func(t *testing.T) {
+		cfg := sourceConfig()
+		cfg.DSN = ""
+		cfg.DSNEnv = "MIGRA_TEST_TENANTS_DSN"
+
+		_, err := NewSQLSource(cfg)
+		assert.ErrorContains(t, err, "MIGRA_TEST_TENANTS_DSN environment variable not set")
+
+		t.Setenv("MIGRA_TEST_TENANTS_DSN", dsn)
+		source, err := NewSQLSource(cfg)
+		require.NoError(t, err)
+		tenants, err := source.LoadTenants(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tenants, 2)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		cfg := sourceConfig()
+		cfg.Labels = map[string]string{"tier": "tier"}
+
+		source, err := NewSQLSource(cfg)
+		require.NoError(t, err)
+		_, err = source.LoadTenants(ctx)
+		assert.ErrorContains(t, err, "tenant query returns no column 'tier'")
+	})
+
+	t.Run("duplicate IDs", func(t *testing.T) {
+		cfg := sourceConfig()
+		cfg.Query = "SELECT plan AS slug, db_url, region, plan, schema_name FROM tenants"
+
+		source, err := NewSQLSource(cfg)
+		require.NoError(t, err)
+		_, err = source.LoadTenants(ctx)
+		assert.ErrorContains(t, err, "duplicate tenant ID 'free'")
+	})
+
+	t.Run("no tenants", func(t *testing.T) {
+		cfg := sourceConfig()
+		cfg.Query = "SELECT slug, db_url, region, plan, schema_name FROM tenants WHERE active = 2"
+
+		source, err := NewSQLSource(cfg)
+		require.NoError(t, err)
+		_, err = source.LoadTenants(ctx)
+		assert.ErrorContains(t, err, "query returned no tenants")
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		cfg := sourceConfig()
+		cfg.Query = "SELECT * FROM missing"
+
+		source, err := NewSQLSource(cfg)
+		require.NoError(t, err)
+		_, err = source.LoadTenants(ctx)
+		assert.ErrorContains(t, err, "failed to query tenants")
+	})
+}
@@ -0,0 +1,209 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/process"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// ServiceStatus is the migration status of a service for a tenant
+type ServiceStatus struct {
+	Service string   `json:"service"`
+	Applied []string `json:"applied"`
+	Pending []string `json:"pending"`
+	Error   string   `json:"error,omitempty"`
+	// Drifted is set when the applied migrations differ from those of
+	// most tenants, or could not be determined
+	Drifted bool `json:"drifted,omitempty"`
+}
+
+// TenantStatus is the migration status of every service of a tenant
+type TenantStatus struct {
+	TenantID string          `json:"tenant"`
+	Services []ServiceStatus `json:"services"`
+	Drifted  bool            `json:"drifted"`
+}
+
+// Pending returns the number of pending migrations across services
+func (s *TenantStatus) Pending() int {
+	count := 0
+	for _, service := range s.Services {
+		count += len(service.Pending)
+	}
+	return count
+}
+
+// Status queries the migration status of every service for all tenants,
+// and marks the tenants that drifted from the majority. Tenants not
+// queried before ctx is done report its error for every service.
+func (e *Executor) Status(ctx context.Context, services []migra.Service) ([]TenantStatus, error) {
+	tenants, err := e.source.LoadTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenants: %w", err)
+	}
+	if e.schema != "" {
+		if err := CheckSchemas(e.schema, tenants); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]TenantStatus, len(tenants))
+	semaphore := make(chan struct{}, e.maxParallel)
+	var wg sync.WaitGroup
+	for i, tnt := range tenants {
+		wg.Add(1)
+		go func(idx int, tnt *migra.Tenant) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+			}
+			if err := ctx.Err(); err != nil {
+				statuses[idx] = unqueriedStatus(tnt, services, err)
+				return
+			}
+
+			tenantCtx, cancel := process.WithTimeout(ctx, e.timeout)
+			defer cancel()
+			statuses[idx] = e.tenantStatus(tenantCtx, tnt, services)
+		}(i, tnt)
+	}
+	wg.Wait()
+
+	MarkDrift(statuses)
+	return statuses, nil
+}
+
+// unqueriedStatus is the status of a tenant whose services could not be
+// queried
+func unqueriedStatus(tnt *migra.Tenant, services []migra.Service, err error) TenantStatus {
+	status := TenantStatus{
+		TenantID: tnt.ID,
+		Services: make([]ServiceStatus, 0, len(services)),
+	}
+	for _, service := range services {
+		status.Services = append(status.Services, ServiceStatus{
+			Service: service.Name,
+			Applied: make([]string, 0),
+			Pending: make([]string, 0),
+			Error:   err.Error(),
+		})
+	}
+	return status
+}
+
+// tenantStatus queries the status of every service of a tenant
+func (e *Executor) tenantStatus(ctx context.Context, tnt *migra.Tenant, services []migra.Service) TenantStatus {
+	if e.schema != "" {
+		tnt = withSchema(e.schema, tnt)
+	}
+
+	status := TenantStatus{
+		TenantID: tnt.ID,
+		Services: make([]ServiceStatus, 0, len(services)),
+	}
+	for i := range services {
+		status.Services = append(status.Services, e.serviceStatus(ctx, &services[i], tnt))
+	}
+	return status
+}
+
+// serviceStatus queries the status of a service for a tenant within the
+// service timeout
+func (e *Executor) serviceStatus(ctx context.Context, service *migra.Service, tnt *migra.Tenant) ServiceStatus {
+	start := time.Now()
+	status := ServiceStatus{
+		Service: service.Name,
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+
+	e.emit(migra.Event{Type: migra.EventServiceStarted, Operation: migra.OperationStatus, Service: service.Name, Tenant: tnt.ID})
+
+	adp, err := e.registry.GetForService(service)
+	if err == nil {
+		opCtx, cancel := process.WithTimeout(ctx, service.Timeout)
+		var st *migra.StatusResult
+		st, err = adp.Status(opCtx, service, adapter.TargetSchema(adp, service, tnt))
+		cancel()
+		if err == nil {
+			status.Applied = append(status.Applied, st.Applied...)
+			status.Pending = append(status.Pending, st.Pending...)
+		}
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	e.serviceFinished(migra.OperationStatus, tnt, migra.ServiceResult{
+		ServiceName: service.Name,
+		Success:     err == nil,
+		Duration:    time.Since(start),
+		Error:       status.Error,
+		Output:      fmt.Sprintf("Applied: %d, Pending: %d", len(status.Applied), len(status.Pending)),
+	})
+	return status
+}
+
+// MarkDrift flags the services whose applied migrations differ from those
+// of most tenants, and the tenants having any. Services whose status
+// could not be determined count as drifted. On a tie, the set of the
+// earliest tenant is the majority.
+func MarkDrift(statuses []TenantStatus) {
+	counts := make(map[string]map[string]int)
+	order := make(map[string][]string)
+	for _, tenant := range statuses {
+		for _, service := range tenant.Services {
+			if service.Error != "" {
+				continue
+			}
+			if counts[service.Service] == nil {
+				counts[service.Service] = make(map[string]int)
+			}
+			key := appliedKey(service.Applied)
+			if counts[service.Service][key] == 0 {
+				order[service.Service] = append(order[service.Service], key)
+			}
+			counts[service.Service][key]++
+		}
+	}
+
+	// Sets are visited in first-seen order, so the earliest wins a tie
+	majority := make(map[string]string, len(order))
+	for service, keys := range order {
+		best := keys[0]
+		for _, key := range keys[1:] {
+			if counts[service][key] > counts[service][best] {
+				best = key
+			}
+		}
+		majority[service] = best
+	}
+
+	for i := range statuses {
+		tenant := &statuses[i]
+		tenant.Drifted = false
+		for j := range tenant.Services {
+			service := &tenant.Services[j]
+			service.Drifted = service.Error != "" || appliedKey(service.Applied) != majority[service.Service]
+			if service.Drifted {
+				tenant.Drifted = true
+			}
+		}
+	}
+}
+
+// appliedKey identifies a set of applied migrations regardless of order
+func appliedKey(applied []string) string {
+	sorted := append([]string{}, applied...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\n")
+}
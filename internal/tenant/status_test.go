@@ -0,0 +1,122 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantStatus builds the status of a tenant with a single service
+func tenantStatus(id string, applied ...string) TenantStatus {
+	return TenantStatus{
+		TenantID: id,
+		Services: []ServiceStatus{{Service: "api", Applied: applied}},
+	}
+}
+
+func TestMarkDrift(t *testing.T) {
+	t.Run("majority", func(t *testing.T) {
+		statuses := []TenantStatus{
+			tenantStatus("acme", "0001", "0002"),
+			tenantStatus("globex", "0001"),
+			tenantStatus("initech", "0002", "0001"),
+		}
+		MarkDrift(statuses)
+
+		assert.False(t, statuses[0].Drifted)
+		assert.True(t, statuses[1].Drifted)
+		assert.True(t, statuses[1].Services[0].Drifted)
+		assert.False(t, statuses[2].Drifted, "order of applied migrations does not matter")
+	})
+
+	t.Run("tie goes to the earliest tenant", func(t *testing.T) {
+		statuses := []TenantStatus{
+			tenantStatus("acme", "0001"),
+			tenantStatus("globex", "0001", "0002"),
+		}
+		MarkDrift(statuses)
+
+		assert.False(t, statuses[0].Drifted)
+		assert.True(t, statuses[1].Drifted)
+
+		// The earliest tenant wins even when another set reaches the tied
+		// count first
+		statuses = []TenantStatus{
+			tenantStatus("acme", "0001"),
+			tenantStatus("globex", "0001", "0002"),
+			tenantStatus("initech", "0001", "0002"),
+			tenantStatus("umbrella", "0001"),
+		}
+		MarkDrift(statuses)
+
+		assert.False(t, statuses[0].Drifted)
+		assert.True(t, statuses[1].Drifted)
+		assert.True(t, statuses[2].Drifted)
+		assert.False(t, statuses[3].Drifted)
+	})
+
+	t.Run("errors count as drifted", func(t *testing.T) {
+		failed := tenantStatus("globex")
+		failed.Services[0].Error = "connection refused"
+		statuses := []TenantStatus{tenantStatus("acme", "0001"), failed}
+		MarkDrift(statuses)
+
+		assert.False(t, statuses[0].Drifted)
+		assert.True(t, statuses[1].Drifted)
+	})
+
+	t.Run("pending count", func(t *testing.T) {
+		status := TenantStatus{Services: []ServiceStatus{
+			{Service: "api", Pending: []string{"0003"}},
+			{Service: "billing", Pending: []string{"0001", "0002"}},
+		}}
+		assert.Equal(t, 3, status.Pending())
+	})
+}
+
+// blockingAdapter blocks status queries until their context is done
+type blockingAdapter struct {
+	tenantAdapter
+	started chan string
+}
+
+func (a *blockingAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	a.started <- tenant.ID
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestStatusCancel(t *testing.T) {
+	adp := &blockingAdapter{started: make(chan string, 10)}
+	executor := newTestExecutor(t, makeTenants(5), adp)
+	executor.maxParallel = 1
+	services := []migra.Service{{Name: "api", Type: "fake", Path: t.TempDir()}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []TenantStatus)
+	go func() {
+		statuses, err := executor.Status(ctx, services)
+		assert.NoError(t, err)
+		done <- statuses
+	}()
+
+	<-adp.started
+	cancel()
+
+	select {
+	case statuses := <-done:
+		require.Len(t, statuses, 5)
+		for _, status := range statuses {
+			assert.NotEmpty(t, status.TenantID)
+			assert.True(t, status.Drifted)
+			assert.Contains(t, status.Services[0].Error, "context canceled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("status did not stop after cancellation")
+	}
+	assert.Len(t, adp.started, 0, "queued tenants are not queried")
+}
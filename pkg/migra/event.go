@@ -0,0 +1,44 @@
+package migra
+
+import "time"
+
+// EventType identifies what happened during a run
+type EventType string
+
+const (
+	EventServiceStarted  EventType = "service_started"
+	EventServiceFinished EventType = "service_finished"
+	EventServiceRetry    EventType = "service_retry"
+	EventTenantStarted   EventType = "tenant_started"
+	EventTenantFinished  EventType = "tenant_finished"
+	EventWaveStarted     EventType = "wave_started"
+	EventWaveFinished    EventType = "wave_finished"
+	EventOutput          EventType = "output"
+)
+
+// Event reports progress of a deploy, rollback or status run. Service
+// events carry the service result once finished; tenant events are only
+// emitted by multi-tenant runs, and service events inside them carry the
+// tenant ID. Wave events are emitted by staged tenant rollouts; a failed
+// wave finished event carries why the rollout stopped. Retry events carry the failed attempt's error and the number
+// of the attempt about to start. Output events carry one line of a migration command's stdout
+// or stderr as it is printed.
+type Event struct {
+	Type      EventType      `json:"type"`
+	Operation Operation      `json:"operation"`
+	Service   string         `json:"service,omitempty"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Wave      string         `json:"wave,omitempty"`
+	Success   bool           `json:"success,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Duration  time.Duration  `json:"duration,omitempty"`
+	Attempt   int            `json:"attempt,omitempty"`
+	Result    *ServiceResult `json:"result,omitempty"`
+	Stream    string         `json:"stream,omitempty"`
+	Line      string         `json:"line,omitempty"`
+	Time      time.Time      `json:"time"`
+}
+
+// EventHandler receives run events. Engines that run services or tenants
+// concurrently call it from several goroutines at once.
+type EventHandler func(Event)
@@ -0,0 +1,104 @@
+package migra
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// PluginPrefix is the executable name prefix of adapter plugins.
+// A plugin for type "sqitch" is an executable named migra-adapter-sqitch.
+const PluginPrefix = "migra-adapter-"
+
+// PluginProtocolVersion is the version of the plugin protocol sent in
+// every request
+const PluginProtocolVersion = 1
+
+// PluginRequest is written as JSON to a plugin's stdin. The plugin runs
+// in the service working directory with the service env and tenant
+// connection in its environment, and answers with a JSON Result (deploy,
+// rollback) or StatusResult (status) on stdout. A migration failure is
+// reported as a Result with Success false; a plugin that cannot handle
+// the request exits non-zero with a message on stderr.
+type PluginRequest struct {
+	Version   int       `json:"version"`
+	Operation Operation `json:"operation"`
+	Service   *Service  `json:"service"`
+	Tenant    *Tenant   `json:"tenant,omitempty"`
+	Steps     int       `json:"steps,omitempty"`
+}
+
+// FindPlugins returns the adapter plugins in dirs and on PATH, keyed by
+// type. Plugins in dirs take precedence over those on PATH, and earlier
+// directories over later ones.
+func FindPlugins(dirs []string) map[string]string {
+	plugins := make(map[string]string)
+
+	search := append([]string{}, dirs...)
+	search = append(search, filepath.SplitList(os.Getenv("PATH"))...)
+
+	for _, dir := range search {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			pluginType, ok := pluginType(entry.Name())
+			if !ok || entry.IsDir() {
+				continue
+			}
+			if _, seen := plugins[pluginType]; seen {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if isExecutable(path) {
+				plugins[pluginType] = path
+			}
+		}
+	}
+
+	return plugins
+}
+
+// LookupPlugin returns the path of the plugin for a type, if there is one
+func LookupPlugin(pluginType string, dirs []string) (string, bool) {
+	path, ok := FindPlugins(dirs)[pluginType]
+	return path, ok
+}
+
+// pluginType extracts the adapter type from a plugin executable name
+func pluginType(name string) (string, bool) {
+	if !strings.HasPrefix(name, PluginPrefix) {
+		return "", false
+	}
+
+	name = strings.TrimPrefix(name, PluginPrefix)
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	return name, name != ""
+}
+
+// isExecutable checks if a file can be executed
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode()&0111 != 0
+}
@@ -0,0 +1,71 @@
+package migra
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelRequirement is a single key=value or key!=value condition on
+// tenant labels
+type LabelRequirement struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+// Selector matches tenants whose labels satisfy all of its requirements.
+// An empty selector matches every tenant.
+type Selector []LabelRequirement
+
+// ParseSelector parses a comma-separated list of key=value and key!=value
+// requirements, such as "region=eu,plan!=free"
+func ParseSelector(s string) (Selector, error) {
+	selector := make(Selector, 0)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		req := LabelRequirement{}
+		key, value, ok := strings.Cut(part, "!=")
+		if ok {
+			req.Negate = true
+		} else if key, value, ok = strings.Cut(part, "="); !ok {
+			return nil, fmt.Errorf("invalid selector requirement '%s': expected key=value or key!=value", part)
+		}
+
+		req.Key = strings.TrimSpace(key)
+		req.Value = strings.TrimSpace(value)
+		if req.Key == "" {
+			return nil, fmt.Errorf("invalid selector requirement '%s': empty label key", part)
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// Matches reports whether labels satisfy every requirement. A missing
+// label satisfies only != requirements.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.Key]
+		if req.Negate == (ok && value == req.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the selector as it is parsed
+func (s Selector) String() string {
+	parts := make([]string, len(s))
+	for i, req := range s {
+		op := "="
+		if req.Negate {
+			op = "!="
+		}
+		parts[i] = req.Key + op + req.Value
+	}
+	return strings.Join(parts, ",")
+}
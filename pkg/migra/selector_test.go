@@ -0,0 +1,46 @@
+package migra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	t.Run("equality and inequality", func(t *testing.T) {
+		selector, err := ParseSelector(" region = eu, plan!=free ,")
+		require.NoError(t, err)
+		assert.Equal(t, Selector{
+			{Key: "region", Value: "eu"},
+			{Key: "plan", Value: "free", Negate: true},
+		}, selector)
+		assert.Equal(t, "region=eu,plan!=free", selector.String())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		selector, err := ParseSelector("")
+		require.NoError(t, err)
+		assert.Empty(t, selector)
+		assert.True(t, selector.Matches(nil))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseSelector("region")
+		assert.ErrorContains(t, err, "expected key=value")
+
+		_, err = ParseSelector("=eu")
+		assert.ErrorContains(t, err, "empty label key")
+	})
+}
+
+func TestSelectorMatches(t *testing.T) {
+	selector, err := ParseSelector("region=eu,plan!=free")
+	require.NoError(t, err)
+
+	assert.True(t, selector.Matches(map[string]string{"region": "eu", "plan": "enterprise"}))
+	assert.True(t, selector.Matches(map[string]string{"region": "eu"}))
+	assert.False(t, selector.Matches(map[string]string{"region": "eu", "plan": "free"}))
+	assert.False(t, selector.Matches(map[string]string{"region": "us"}))
+	assert.False(t, selector.Matches(nil))
+}
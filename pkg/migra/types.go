@@ -19,19 +19,75 @@ type Adapter interface {
 	Name() string
 }
 
+// TargetRollbacker is implemented by adapters that can roll back to a
+// named migration rather than a number of steps
+type TargetRollbacker interface {
+	RollbackTo(ctx context.Context, service *Service, tenant *Tenant, target string) (*Result, error)
+}
+
 // Service represents a microservice configuration
 type Service struct {
-	Name       string            `yaml:"name" json:"name"`
-	Type       string            `yaml:"type" json:"type"`
-	Path       string            `yaml:"path" json:"path"`
-	Env        map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
-	WorkingDir string            `yaml:"working_dir,omitempty" json:"working_dir,omitempty"`
+	Name          string            `yaml:"name" json:"name"`
+	Type          string            `yaml:"type" json:"type"`
+	Path          string            `yaml:"path" json:"path"`
+	Env           map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	WorkingDir    string            `yaml:"working_dir,omitempty" json:"working_dir,omitempty"`
+	DependsOn     []string          `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	Command       *CommandSpec      `yaml:"command,omitempty" json:"command,omitempty"`
+	MigrationsDir string            `yaml:"migrations_dir,omitempty" json:"migrations_dir,omitempty"`
+	Driver        string            `yaml:"driver,omitempty" json:"driver,omitempty"`
+	Timeout       time.Duration     `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retry         *RetryPolicy      `yaml:"retry,omitempty" json:"retry,omitempty"`
+}
+
+// RetryPolicy configures how failed operations are retried. Attempts are
+// spaced by Backoff, doubled after each attempt up to MaxBackoff, and
+// randomized by the Jitter fraction. When RetryOn patterns are set, only
+// failures whose output or error matches one of them are retried.
+type RetryPolicy struct {
+	MaxAttempts int           `yaml:"max_attempts" json:"max_attempts"`
+	Backoff     time.Duration `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	MaxBackoff  time.Duration `yaml:"max_backoff,omitempty" json:"max_backoff,omitempty"`
+	Jitter      float64       `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+	RetryOn     []string      `yaml:"retry_on,omitempty" json:"retry_on,omitempty"`
 }
 
+// CommandSpec declares the commands run by the generic command adapter.
+// Each command is a list of arguments, each of which is a Go template
+// with access to .Service, .Tenant and .Steps.
+type CommandSpec struct {
+	Deploy       []string      `yaml:"deploy" json:"deploy"`
+	Rollback     []string      `yaml:"rollback,omitempty" json:"rollback,omitempty"`
+	Status       []string      `yaml:"status,omitempty" json:"status,omitempty"`
+	StatusParser *StatusParser `yaml:"status_parser,omitempty" json:"status_parser,omitempty"`
+}
+
+// StatusParser configures how status command output is parsed.
+// For the regex format, Applied and Pending are patterns matched against
+// each line; the "name" group (or the first group) is the migration name.
+// For the json format, Applied and Pending are dotted paths to arrays of
+// migration names, or of objects whose NameField holds the name.
+type StatusParser struct {
+	Format    string `yaml:"format" json:"format"`
+	Applied   string `yaml:"applied,omitempty" json:"applied,omitempty"`
+	Pending   string `yaml:"pending,omitempty" json:"pending,omitempty"`
+	NameField string `yaml:"name_field,omitempty" json:"name_field,omitempty"`
+}
+
+// Status parser formats
+const (
+	StatusFormatRegex = "regex"
+	StatusFormatJSON  = "json"
+)
+
 // Tenant represents a tenant in multi-tenant architecture
 type Tenant struct {
 	ID         string            `json:"id"`
 	Connection map[string]string `json:"connection"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	// Schema is the tenant schema in schema_per_tenant mode. Sources may
+	// set it; otherwise it is derived from tenancy.schema.
+	Schema string `json:"schema,omitempty"`
 }
 
 // Result represents the result of an adapter operation
@@ -41,6 +97,7 @@ type Result struct {
 	Error     string        `json:"error,omitempty"`
 	Duration  time.Duration `json:"duration"`
 	Timestamp time.Time     `json:"timestamp"`
+	LogFile   string        `json:"log_file,omitempty"`
 }
 
 // StatusResult represents migration status information
@@ -54,9 +111,23 @@ type StatusResult struct {
 type ServiceResult struct {
 	ServiceName string        `json:"service_name"`
 	Success     bool          `json:"success"`
+	Skipped     bool          `json:"skipped,omitempty"`
+	TimedOut    bool          `json:"timed_out,omitempty"`
+	Resumed     bool          `json:"resumed,omitempty"`
 	Duration    time.Duration `json:"duration"`
 	Error       string        `json:"error,omitempty"`
 	Output      string        `json:"output,omitempty"`
+	LogFile     string        `json:"log_file,omitempty"`
+	Attempts    []Attempt     `json:"attempts,omitempty"`
+}
+
+// Attempt is one try of a service operation under a retry policy
+type Attempt struct {
+	Number   int           `json:"number"`
+	Success  bool          `json:"success"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
 }
 
 // Operation defines the type of migration operation
@@ -0,0 +1,792 @@
+// Package orchestrator embeds migra in Go programs. It loads a migra
+// configuration, accepts custom adapters next to the built-in ones and runs
+// deploy, rollback and status with the same engines as the migra CLI,
+// reporting progress through an event callback and returning typed results.
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/migra/migra/internal/adapter"
+	"github.com/migra/migra/internal/config"
+	"github.com/migra/migra/internal/engine"
+	"github.com/migra/migra/internal/lock"
+	"github.com/migra/migra/internal/logger"
+	"github.com/migra/migra/internal/output"
+	"github.com/migra/migra/internal/process"
+	"github.com/migra/migra/internal/state"
+	"github.com/migra/migra/internal/tenant"
+	"github.com/migra/migra/pkg/migra"
+)
+
+// Configuration types, so a configuration can be loaded or built in code
+type (
+	Config          = config.Config
+	ExecutionConfig = config.ExecutionConfig
+	TenancyConfig   = config.TenancyConfig
+	LockConfig      = config.LockConfig
+	LoggingConfig   = config.LoggingConfig
+	DiscoveryConfig = config.DiscoveryConfig
+	PluginsConfig   = config.PluginsConfig
+)
+
+// TenantSourceConfig configures the tenant source, with SQLSourceConfig
+// and HTTPSourceConfig holding the options of the sql and http sources
+type (
+	TenantSourceConfig = config.TenantSourceConfig
+	SQLSourceConfig    = config.SQLSourceConfig
+	HTTPSourceConfig   = config.HTTPSourceConfig
+)
+
+// TenantSource loads the tenants of a multi-tenant run
+type TenantSource = tenant.Source
+
+// TenantResult is the outcome of a run for a single tenant
+type TenantResult = tenant.TenantResult
+
+// TenantFilter selects the tenants of a multi-tenant run by ID and labels
+type TenantFilter = tenant.Filter
+
+// TenantStatus is the migration status of every service of a tenant
+type TenantStatus = tenant.TenantStatus
+
+// Wave is a group of tenants deployed together in a staged rollout
+type Wave = tenant.Wave
+
+// RolloutConfig and WaveConfig stage multi-tenant deploys in waves
+type (
+	RolloutConfig = config.RolloutConfig
+	WaveConfig    = config.WaveConfig
+)
+
+// ErrLocked is wrapped by the error returned when another run holds the
+// run lock
+var ErrLocked = lock.ErrLocked
+
+// ErrTenantDryRun is returned by multi-tenant deploys and rollbacks in dry
+// run mode, which the tenant executor does not support. Waves previews the
+// tenants of a deploy instead.
+var ErrTenantDryRun = errors.New("dry run is not supported for multi-tenant runs, use Waves to preview a deploy")
+
+// Execution strategies
+const (
+	StrategySequential = config.StrategySequential
+	StrategyParallel   = config.StrategyParallel
+	StrategyDAG        = config.StrategyDAG
+)
+
+// Options configures an Orchestrator
+type Options struct {
+	// WorkDir holds the .migra state, run history and file lock.
+	// Defaults to the current directory.
+	WorkDir string
+	// Services restricts runs to the named services. Empty means all.
+	Services []string
+	// Strategy overrides execution.strategy from the configuration
+	Strategy string
+	// DryRun reports what deploy and rollback would do without running
+	// any adapter, taking the run lock or recording history. Multi-tenant
+	// runs refuse it with ErrTenantDryRun.
+	DryRun bool
+	// OnEvent receives service and tenant events as the run progresses
+	OnEvent migra.EventHandler
+	// LogOutput receives migra's log output in the configured logging
+	// format. Nil discards it.
+	LogOutput io.Writer
+	// Verbose adds the structured fields to console log lines
+	Verbose bool
+	// Quiet drops info and debug log messages
+	Quiet bool
+	// TenantSource overrides the tenant source from the configuration
+	TenantSource TenantSource
+	// Tenants restricts multi-tenant runs to the selected tenants
+	Tenants TenantFilter
+	// Environment picks the default tenant selector from
+	// tenancy.selectors when Tenants has no selector of its own
+	Environment string
+	// ConfirmWave is asked before each rollout wave after the first when
+	// tenancy.rollout.confirm is set. Without it such rollouts stop after
+	// the first wave.
+	ConfirmWave func(ctx context.Context, next *Wave) (bool, error)
+}
+
+// RollbackOptions configures how far a rollback goes back.
+// A non-empty Target takes precedence over Steps.
+type RollbackOptions struct {
+	Steps  int
+	Target string
+}
+
+// Result is the outcome of a deploy or rollback
+type Result struct {
+	Operation migra.Operation       `json:"operation"`
+	RunID     string                `json:"run_id,omitempty"`
+	Services  []migra.ServiceResult `json:"services"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Skipped   int                   `json:"skipped"`
+	TimedOut  int                   `json:"timed_out"`
+	Resumed   int                   `json:"resumed"`
+	Duration  time.Duration         `json:"duration"`
+}
+
+// OK reports whether every service succeeded
+func (r *Result) OK() bool {
+	return r.Failed == 0 && r.Skipped == 0
+}
+
+// TenantsResult is the outcome of a multi-tenant deploy
+type TenantsResult struct {
+	Operation migra.Operation `json:"operation"`
+	RunID     string          `json:"run_id,omitempty"`
+	Tenants   []TenantResult  `json:"tenants"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Skipped   int             `json:"skipped"`
+	Resumed   int             `json:"resumed"`
+	Duration  time.Duration   `json:"duration"`
+}
+
+// OK reports whether every tenant succeeded
+func (r *TenantsResult) OK() bool {
+	return r.Failed == 0 && r.Skipped == 0
+}
+
+// ServiceStatus is the migration status of a service, for a tenant when
+// tenancy is enabled
+type ServiceStatus struct {
+	Service string   `json:"service"`
+	Tenant  string   `json:"tenant,omitempty"`
+	Applied []string `json:"applied"`
+	Pending []string `json:"pending"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Orchestrator runs migrations for a configuration
+type Orchestrator struct {
+	cfg      *Config
+	opts     Options
+	registry *adapter.Registry
+	custom   map[string]bool
+	log      logger.Logger
+}
+
+// LoadConfig loads a configuration file, applying defaults and service
+// discovery. The configuration is validated when a run starts.
+func LoadConfig(path string) (*Config, error) {
+	cfg, err := config.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// New creates a new orchestrator. Defaults are applied to cfg, so a
+// configuration built in code only needs its services. Built-in adapters
+// and the configured plugins are available; RegisterAdapter adds more.
+func New(cfg *Config, opts Options) (*Orchestrator, error) {
+	if cfg == nil {
+		return nil, errors.New("config is required")
+	}
+	config.ApplyDefaults(cfg)
+
+	if opts.WorkDir == "" {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine working directory: %w", err)
+		}
+		opts.WorkDir = workDir
+	}
+
+	output := opts.LogOutput
+	if output == nil {
+		output = io.Discard
+	}
+	log := logger.New(logger.Config{
+		Level:   logger.ParseLevel(cfg.Logging.Level),
+		Format:  cfg.Logging.Format,
+		Output:  output,
+		Verbose: opts.Verbose,
+		Quiet:   opts.Quiet,
+	})
+
+	registry := adapter.NewDefaultRegistry()
+	for _, pluginType := range registry.LoadPlugins(cfg.PluginDirs()) {
+		log.Debug("Loaded adapter plugin", logger.F("type", pluginType))
+	}
+
+	return &Orchestrator{
+		cfg:      cfg,
+		opts:     opts,
+		registry: registry,
+		custom:   make(map[string]bool),
+		log:      log,
+	}, nil
+}
+
+// RegisterAdapter makes an adapter available for services of the given
+// type. It replaces a built-in adapter or plugin of the same type.
+func (o *Orchestrator) RegisterAdapter(serviceType string, adp migra.Adapter) {
+	o.registry.Register(serviceType, adp)
+	o.custom[serviceType] = true
+}
+
+// Config returns the configuration the orchestrator runs
+func (o *Orchestrator) Config() *Config {
+	return o.cfg
+}
+
+// Validate validates the configuration, accepting the types of
+// registered adapters
+func (o *Orchestrator) Validate() error {
+	validator := config.NewValidator(o.cfg)
+	types := make([]string, 0, len(o.custom))
+	for t := range o.custom {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	validator.AllowTypes(types...)
+
+	if err := validator.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
+
+// Deploy runs pending migrations for the selected services using the
+// configured execution strategy
+func (o *Orchestrator) Deploy(ctx context.Context) (*Result, error) {
+	return o.run(ctx, migra.OperationDeploy, RollbackOptions{}, "")
+}
+
+// ResumeDeploy reruns a failed deploy, skipping the services that already
+// succeeded in it. The run ID may be a unique prefix of a recorded run.
+func (o *Orchestrator) ResumeDeploy(ctx context.Context, runID string) (*Result, error) {
+	if runID == "" {
+		return nil, errors.New("run ID to resume is required")
+	}
+	return o.run(ctx, migra.OperationDeploy, RollbackOptions{}, runID)
+}
+
+// Rollback rolls back the selected services. With the dag strategy
+// dependents are rolled back before their dependencies.
+func (o *Orchestrator) Rollback(ctx context.Context, opts RollbackOptions) (*Result, error) {
+	if opts.Target == "" && opts.Steps <= 0 {
+		return nil, errors.New("rollback steps must be positive")
+	}
+	return o.run(ctx, migra.OperationRollback, opts, "")
+}
+
+// run executes an operation for the selected services, resuming a deploy
+// run when resumeID is set
+func (o *Orchestrator) run(ctx context.Context, operation migra.Operation, rollback RollbackOptions, resumeID string) (*Result, error) {
+	start := time.Now()
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	strategy, err := o.strategy()
+	if err != nil {
+		return nil, err
+	}
+	services, err := o.services()
+	if err != nil {
+		return nil, err
+	}
+	if len(o.opts.Services) > 0 {
+		o.log.Info(fmt.Sprintf("Filtered to service: %s", strings.Join(o.opts.Services, ", ")))
+	}
+
+	stateManager := o.stateManager()
+
+	if !o.opts.DryRun {
+		release, err := o.acquireLock(ctx, operation)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	parallelLimit := o.cfg.EffectiveParallelLimit()
+	switch strategy {
+	case StrategyParallel:
+		o.log.Info(fmt.Sprintf("Using parallel execution (limit: %d)", parallelLimit))
+	case StrategyDAG:
+		o.log.Info(fmt.Sprintf("Using dependency graph execution (limit: %d)", parallelLimit))
+	default:
+		o.log.Info("Using sequential execution")
+	}
+	eng := engine.New(strategy, o.registry, stateManager, o.log, o.cfg.Execution.StopOnFailure, o.opts.DryRun, parallelLimit)
+	eng.SetEventHandler(o.opts.OnEvent)
+	if operation == migra.OperationRollback {
+		eng.SetRollback(rollback.Steps, rollback.Target)
+	}
+
+	record := state.NewRunRecord(string(operation))
+	if resumeID != "" {
+		point, err := state.LoadResumePoint(o.opts.WorkDir, stateManager, resumeID, false)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resume run: %w", err)
+		}
+		eng.SetResume(point)
+		record.ResumedFrom = point.RunID
+		o.log.Info(fmt.Sprintf("Resuming run %s", point.RunID), logger.F("resumed_from", point.RunID))
+	}
+	if !o.opts.DryRun {
+		stateManager.SetRunID(record.ID)
+		o.log.Info(fmt.Sprintf("Starting run %s", record.ID), logger.F("run_id", record.ID))
+	}
+	ctx = o.withOutput(ctx, operation, record)
+	ctx, cancel := o.withExecutionLimits(ctx)
+	defer cancel()
+	o.log.Info(fmt.Sprintf("Executing migrations for %d service(s)", len(services)))
+	results, err := eng.Execute(ctx, services, operation)
+	if err != nil {
+		return nil, fmt.Errorf("execution failed: %w", err)
+	}
+
+	summary := engine.SummarizeResults(results, time.Since(start))
+	result := &Result{
+		Operation: operation,
+		Services:  results,
+		Succeeded: summary.TotalSuccess,
+		Failed:    summary.TotalFailure,
+		Skipped:   summary.TotalSkipped,
+		TimedOut:  summary.TotalTimeout,
+		Resumed:   summary.TotalResumed,
+		Duration:  summary.Duration,
+	}
+
+	if !o.opts.DryRun {
+		record.AddServiceResults("", results)
+		result.RunID = o.saveHistory(record)
+	}
+	return result, nil
+}
+
+// DeployTenants runs pending migrations for the selected services on
+// every tenant
+func (o *Orchestrator) DeployTenants(ctx context.Context) (*TenantsResult, error) {
+	return o.runTenants(ctx, migra.OperationDeploy, 0, "")
+}
+
+// RollbackTenants rolls back the last steps migrations of the selected
+// services on every tenant, undoing services in reverse order
+func (o *Orchestrator) RollbackTenants(ctx context.Context, steps int) (*TenantsResult, error) {
+	if steps <= 0 {
+		return nil, errors.New("rollback steps must be positive")
+	}
+	return o.runTenants(ctx, migra.OperationRollback, steps, "")
+}
+
+// ResumeTenants reruns a failed multi-tenant deploy, skipping the tenant
+// services that already succeeded in it
+func (o *Orchestrator) ResumeTenants(ctx context.Context, runID string) (*TenantsResult, error) {
+	if runID == "" {
+		return nil, errors.New("run ID to resume is required")
+	}
+	return o.runTenants(ctx, migra.OperationDeploy, 0, runID)
+}
+
+// runTenants runs a multi-tenant deploy or rollback, resuming a deploy
+// when resumeID is set
+func (o *Orchestrator) runTenants(ctx context.Context, operation migra.Operation, steps int, resumeID string) (*TenantsResult, error) {
+	start := time.Now()
+
+	if o.opts.DryRun {
+		return nil, ErrTenantDryRun
+	}
+	if err := o.validateTenancy(); err != nil {
+		return nil, err
+	}
+
+	release, err := o.acquireLock(ctx, operation)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	stateManager := o.stateManager()
+	services, executor, err := o.tenantExecutor(stateManager)
+	if err != nil {
+		return nil, err
+	}
+
+	if operation == migra.OperationRollback {
+		executor.SetRollbackSteps(steps)
+	} else if o.cfg.Tenancy.Rollout != nil {
+		executor.SetRollout(o.cfg.Tenancy.Rollout, o.opts.ConfirmWave)
+	}
+
+	record := state.NewRunRecord(string(operation))
+	if resumeID != "" {
+		point, err := state.LoadResumePoint(o.opts.WorkDir, stateManager, resumeID, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resume run: %w", err)
+		}
+		executor.SetResume(point)
+		record.ResumedFrom = point.RunID
+		o.log.Info(fmt.Sprintf("Resuming run %s", point.RunID), logger.F("resumed_from", point.RunID))
+	}
+	stateManager.SetRunID(record.ID)
+	o.log.Info(fmt.Sprintf("Starting run %s", record.ID), logger.F("run_id", record.ID))
+	ctx = o.withOutput(ctx, operation, record)
+	ctx, cancel := o.withExecutionLimits(ctx)
+	defer cancel()
+	results, err := executor.Execute(ctx, services, operation)
+	if err != nil {
+		return nil, fmt.Errorf("tenant execution failed: %w", err)
+	}
+
+	result := &TenantsResult{
+		Operation: operation,
+		Tenants:   results,
+	}
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			result.Skipped++
+		case r.Success:
+			result.Succeeded++
+		default:
+			result.Failed++
+		}
+		if r.Resumed {
+			result.Resumed++
+		}
+		record.AddServiceResults(r.TenantID, r.Services)
+	}
+	result.RunID = o.saveHistory(record)
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// TenantStatus returns the applied and pending migrations of the selected
+// services for every tenant, marking the tenants whose applied
+// migrations drifted from the majority
+func (o *Orchestrator) TenantStatus(ctx context.Context) ([]TenantStatus, error) {
+	if err := o.validateTenancy(); err != nil {
+		return nil, err
+	}
+	services, executor, err := o.tenantExecutor(o.stateManager())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := o.withExecutionLimits(ctx)
+	defer cancel()
+	return executor.Status(ctx, services)
+}
+
+// validateTenancy checks that tenancy is enabled and the configuration
+// is valid
+func (o *Orchestrator) validateTenancy() error {
+	if o.cfg.Tenancy == nil || !o.cfg.Tenancy.Enabled {
+		return errors.New("tenancy is not enabled in configuration")
+	}
+	return o.Validate()
+}
+
+// tenantExecutor creates a tenant executor for the selected services
+func (o *Orchestrator) tenantExecutor(stateManager *state.Manager) ([]migra.Service, *tenant.Executor, error) {
+	services, err := o.services()
+	if err != nil {
+		return nil, nil, err
+	}
+	source, err := o.tenantSource()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	executor := tenant.NewExecutor(source, o.registry, stateManager, o.log, o.cfg.Tenancy.StopOnFailure, o.cfg.Tenancy.MaxParallel)
+	executor.SetEventHandler(o.opts.OnEvent)
+	executor.SetTimeout(o.cfg.Tenancy.Timeout)
+	if o.cfg.Tenancy.Mode == config.TenancyModeSchema {
+		executor.SetSchemaPerTenant(o.cfg.Tenancy.Schema, o.cfg.Tenancy.CreateSchema)
+	}
+	return services, executor, nil
+}
+
+// RolloutPlan is what a multi-tenant deploy would run
+type RolloutPlan struct {
+	// Tenants is the number of selected tenants
+	Tenants int `json:"tenants"`
+	// Waves holds the selected tenants in rollout order, a single wave of
+	// all tenants when no rollout is configured
+	Waves []Wave `json:"waves"`
+	// Skipped lists the wave tenants excluded by the tenant filter
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Waves returns the waves a multi-tenant deploy would run, a single wave
+// of all tenants when no rollout is configured
+func (o *Orchestrator) Waves(ctx context.Context) ([]Wave, error) {
+	plan, err := o.RolloutPlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Skipped) > 0 {
+		o.log.Info(fmt.Sprintf("Skipping wave tenants excluded by the filter: %s", strings.Join(plan.Skipped, ", ")))
+	}
+	return plan.Waves, nil
+}
+
+// RolloutPlan returns the tenants and waves a multi-tenant deploy would
+// run without running anything
+func (o *Orchestrator) RolloutPlan(ctx context.Context) (*RolloutPlan, error) {
+	if o.cfg.Tenancy == nil || !o.cfg.Tenancy.Enabled {
+		return nil, errors.New("tenancy is not enabled in configuration")
+	}
+	source, err := o.tenantSource()
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := source.LoadTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenants: %w", err)
+	}
+	waves, skipped, err := tenant.PlanWaves(tenants, tenant.ExcludedIDs(source), o.cfg.Tenancy.Rollout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan rollout: %w", err)
+	}
+	return &RolloutPlan{Tenants: len(tenants), Waves: waves, Skipped: skipped}, nil
+}
+
+// Status returns the applied and pending migrations of the selected
+// services, for every tenant when tenancy is enabled. A service whose
+// status cannot be determined has its Error set.
+func (o *Orchestrator) Status(ctx context.Context) ([]ServiceStatus, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	services, err := o.services()
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := []*migra.Tenant{nil}
+	if o.cfg.Tenancy != nil && o.cfg.Tenancy.Enabled {
+		source, err := o.tenantSource()
+		if err != nil {
+			return nil, err
+		}
+		tenants, err = source.LoadTenants(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tenants: %w", err)
+		}
+		if o.cfg.Tenancy.Mode == config.TenancyModeSchema {
+			if err := tenant.CheckSchemas(o.cfg.Tenancy.Schema, tenants); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	statuses := make([]ServiceStatus, 0, len(services)*len(tenants))
+	for _, tnt := range tenants {
+		if tnt != nil && o.cfg.Tenancy.Mode == config.TenancyModeSchema {
+			copied := *tnt
+			copied.Schema = tenant.SchemaName(o.cfg.Tenancy.Schema, tnt)
+			tnt = &copied
+		}
+		for i := range services {
+			statuses = append(statuses, o.serviceStatus(ctx, &services[i], tnt))
+		}
+	}
+	return statuses, nil
+}
+
+// serviceStatus queries the status of a single service and tenant
+func (o *Orchestrator) serviceStatus(ctx context.Context, service *migra.Service, tnt *migra.Tenant) ServiceStatus {
+	start := time.Now()
+	status := ServiceStatus{
+		Service: service.Name,
+		Applied: make([]string, 0),
+		Pending: make([]string, 0),
+	}
+	if tnt != nil {
+		status.Tenant = tnt.ID
+	}
+
+	o.emit(migra.Event{Type: migra.EventServiceStarted, Operation: migra.OperationStatus, Service: service.Name, Tenant: status.Tenant})
+
+	adp, err := o.registry.GetForService(service)
+	if err == nil {
+		var st *migra.StatusResult
+		st, err = adp.Status(ctx, service, adapter.TargetSchema(adp, service, tnt))
+		if err == nil {
+			status.Applied = append(status.Applied, st.Applied...)
+			status.Pending = append(status.Pending, st.Pending...)
+		}
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	result := migra.ServiceResult{
+		ServiceName: service.Name,
+		Success:     err == nil,
+		Duration:    time.Since(start),
+		Error:       status.Error,
+		Output:      fmt.Sprintf("Applied: %d, Pending: %d", len(status.Applied), len(status.Pending)),
+	}
+	o.emit(migra.Event{
+		Type:      migra.EventServiceFinished,
+		Operation: migra.OperationStatus,
+		Service:   service.Name,
+		Tenant:    status.Tenant,
+		Success:   result.Success,
+		Error:     result.Error,
+		Duration:  result.Duration,
+		Result:    &result,
+	})
+	return status
+}
+
+// strategy returns the execution strategy of the options or configuration.
+// Services with dependencies always run with the dag strategy.
+func (o *Orchestrator) strategy() (string, error) {
+	if o.opts.Strategy == "" {
+		return o.cfg.Execution.Strategy, nil
+	}
+	switch o.opts.Strategy {
+	case StrategySequential, StrategyParallel, StrategyDAG:
+		return engine.ResolveStrategy(o.opts.Strategy, o.cfg.Services), nil
+	default:
+		return "", fmt.Errorf("strategy must be '%s', '%s' or '%s', got '%s'", StrategySequential, StrategyParallel, StrategyDAG, o.opts.Strategy)
+	}
+}
+
+// services returns the services selected by the options
+func (o *Orchestrator) services() ([]migra.Service, error) {
+	if len(o.opts.Services) == 0 {
+		return o.cfg.Services, nil
+	}
+
+	byName := make(map[string]migra.Service, len(o.cfg.Services))
+	for _, svc := range o.cfg.Services {
+		byName[svc.Name] = svc
+	}
+
+	services := make([]migra.Service, 0, len(o.opts.Services))
+	for _, name := range o.opts.Services {
+		svc, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("service '%s' not found", name)
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
+
+// tenantSource returns the tenant source from the options or
+// configuration, filtered by the selected tenants
+func (o *Orchestrator) tenantSource() (TenantSource, error) {
+	source := o.opts.TenantSource
+	if source == nil {
+		var err error
+		source, err = tenant.NewSource(o.cfg.Tenancy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filter := o.opts.Tenants
+	if len(filter.Selector) == 0 {
+		selector, err := migra.ParseSelector(o.cfg.DefaultSelector(o.opts.Environment))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector for environment %s: %w", o.opts.Environment, err)
+		}
+		filter.Selector = selector
+	}
+	if filter.Empty() {
+		return source, nil
+	}
+	return tenant.NewFilteredSource(source, filter), nil
+}
+
+// stateManager loads the state in the work directory
+func (o *Orchestrator) stateManager() *state.Manager {
+	stateManager := state.NewManager(o.opts.WorkDir)
+	if err := stateManager.Load(); err != nil {
+		o.log.Warn("Failed to load state, starting fresh", logger.F("error", err.Error()))
+	}
+	return stateManager
+}
+
+// acquireLock takes the run lock when locking is enabled and returns a
+// function releasing it
+func (o *Orchestrator) acquireLock(ctx context.Context, operation migra.Operation) (func(), error) {
+	if o.cfg.Lock == nil || !o.cfg.Lock.Enabled {
+		return func() {}, nil
+	}
+
+	backend, err := lock.NewBackend(o.cfg.Lock, o.opts.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := lock.Acquire(ctx, backend, string(operation), o.cfg.Lock.TTL)
+	if err != nil {
+		backend.Close()
+		var heldErr *lock.HeldError
+		if errors.As(err, &heldErr) {
+			return nil, fmt.Errorf("another run is in progress: %w", err)
+		}
+		return nil, fmt.Errorf("failed to acquire run lock: %w", err)
+	}
+
+	o.log.Debug("Acquired run lock", logger.F("backend", backend.Name()), logger.F("ttl", o.cfg.Lock.TTL.String()))
+	return func() {
+		if err := held.Release(context.Background()); err != nil {
+			o.log.Warn("Failed to release run lock", logger.F("error", err.Error()))
+		}
+		backend.Close()
+	}, nil
+}
+
+// withOutput streams command output to the log and to output events, and
+// spills large outputs to the run log directory
+func (o *Orchestrator) withOutput(ctx context.Context, operation migra.Operation, record *state.RunRecord) context.Context {
+	sink := output.NewSink(o.log, state.RunLogDir(o.opts.WorkDir, record.ID))
+	if o.opts.OnEvent != nil {
+		sink.SetEventHandler(func(event migra.Event) {
+			event.Operation = operation
+			o.opts.OnEvent(event)
+		})
+	}
+	return output.WithSink(ctx, sink)
+}
+
+// withExecutionLimits bounds a run by execution.timeout and sets the grace
+// period stopped migration commands get before they are killed
+func (o *Orchestrator) withExecutionLimits(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = process.WithGracePeriod(ctx, o.cfg.Execution.GracePeriod)
+	return process.WithTimeout(ctx, o.cfg.Execution.Timeout)
+}
+
+// saveHistory records a run in the history and returns its ID. Failing to
+// record history never fails the run itself.
+func (o *Orchestrator) saveHistory(record *state.RunRecord) string {
+	record.Finish()
+	if err := state.NewHistory(o.opts.WorkDir).Append(record); err != nil {
+		o.log.Warn("Failed to record run history", logger.F("error", err.Error()))
+		return ""
+	}
+	o.log.Info(fmt.Sprintf("Recorded run %s", record.ID), logger.F("run_id", record.ID))
+	return record.ID
+}
+
+// emit sends an event to the registered handler, if any
+func (o *Orchestrator) emit(event migra.Event) {
+	if o.opts.OnEvent == nil {
+		return
+	}
+	event.Time = time.Now()
+	o.opts.OnEvent(event)
+}
@@ -0,0 +1,618 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/migra/migra/internal/lock"
+	"github.com/migra/migra/pkg/migra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAdapter records calls and keeps an applied count per service and tenant
+type fakeAdapter struct {
+	mu      sync.Mutex
+	fail    map[string]bool
+	applied map[string]int
+	total   int
+	calls   []string
+}
+
+func newFakeAdapter(total int) *fakeAdapter {
+	return &fakeAdapter{
+		fail:    make(map[string]bool),
+		applied: make(map[string]int),
+		total:   total,
+	}
+}
+
+func (a *fakeAdapter) Name() string { return "fake" }
+
+func (a *fakeAdapter) key(service *migra.Service, tenant *migra.Tenant) string {
+	if tenant == nil {
+		return service.Name
+	}
+	return service.Name + "@" + tenant.ID
+}
+
+func (a *fakeAdapter) Deploy(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.Result, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := a.key(service, tenant)
+	a.calls = append(a.calls, "deploy "+key)
+	if a.fail[service.Name] {
+		return &migra.Result{Success: false, Error: "boom", Timestamp: time.Now()}, nil
+	}
+	a.applied[key] = a.total
+	return &migra.Result{Success: true, Output: "applied", Timestamp: time.Now()}, nil
+}
+
+func (a *fakeAdapter) Rollback(ctx context.Context, service *migra.Service, tenant *migra.Tenant, steps int) (*migra.Result, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := a.key(service, tenant)
+	a.calls = append(a.calls, fmt.Sprintf("rollback %s %d", key, steps))
+	a.applied[key] -= steps
+	return &migra.Result{Success: true, Timestamp: time.Now()}, nil
+}
+
+func (a *fakeAdapter) Status(ctx context.Context, service *migra.Service, tenant *migra.Tenant) (*migra.StatusResult, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.fail[service.Name] {
+		return &migra.StatusResult{LastError: "boom"}, errors.New("boom")
+	}
+
+	status := &migra.StatusResult{Applied: make([]string, 0), Pending: make([]string, 0)}
+	for i := 1; i <= a.total; i++ {
+		name := fmt.Sprintf("%04d", i)
+		if i <= a.applied[a.key(service, tenant)] {
+			status.Applied = append(status.Applied, name)
+		} else {
+			status.Pending = append(status.Pending, name)
+		}
+	}
+	return status, nil
+}
+
+// staticSource returns a fixed list of tenants
+type staticSource []*migra.Tenant
+
+func (s staticSource) LoadTenants(ctx context.Context) ([]*migra.Tenant, error) {
+	return s, nil
+}
+
+// eventRecorder collects events from concurrent runs
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []migra.Event
+}
+
+func (r *eventRecorder) handle(event migra.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) of(eventType migra.EventType) []migra.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]migra.Event, 0)
+	for _, e := range r.events {
+		if e.Type == eventType {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// testConfig builds a configuration of fake services with the given
+// dependencies
+func testConfig(t *testing.T, deps map[string][]string, names ...string) *Config {
+	t.Helper()
+	dir := t.TempDir()
+
+	cfg := &Config{}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(path, 0755))
+		cfg.Services = append(cfg.Services, migra.Service{
+			Name:      name,
+			Type:      "fake",
+			Path:      path,
+			DependsOn: deps[name],
+		})
+	}
+	return cfg
+}
+
+func TestDeploy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs services and reports events", func(t *testing.T) {
+		workDir := t.TempDir()
+		events := &eventRecorder{}
+		orch, err := New(testConfig(t, map[string][]string{"orders": {"users"}}, "users", "orders"), Options{
+			WorkDir: workDir,
+			OnEvent: events.handle,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, StrategyDAG, orch.Config().Execution.Strategy)
+
+		fake := newFakeAdapter(3)
+		orch.RegisterAdapter("fake", fake)
+
+		result, err := orch.Deploy(ctx)
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+		assert.Equal(t, migra.OperationDeploy, result.Operation)
+		assert.Equal(t, 2, result.Succeeded)
+		assert.Len(t, result.Services, 2)
+		assert.NotEmpty(t, result.RunID)
+		assert.Equal(t, []string{"deploy users", "deploy orders"}, fake.calls)
+
+		started := events.of(migra.EventServiceStarted)
+		finished := events.of(migra.EventServiceFinished)
+		require.Len(t, started, 2)
+		require.Len(t, finished, 2)
+		for _, e := range finished {
+			assert.True(t, e.Success)
+			require.NotNil(t, e.Result)
+			assert.Equal(t, e.Service, e.Result.ServiceName)
+			assert.False(t, e.Time.IsZero())
+		}
+
+		assert.FileExists(t, filepath.Join(workDir, ".migra", "history.jsonl"))
+	})
+
+	t.Run("failures and skipped dependents", func(t *testing.T) {
+		events := &eventRecorder{}
+		orch, err := New(testConfig(t, map[string][]string{"orders": {"users"}}, "users", "orders"), Options{
+			WorkDir: t.TempDir(),
+			OnEvent: events.handle,
+		})
+		require.NoError(t, err)
+
+		fake := newFakeAdapter(1)
+		fake.fail["users"] = true
+		orch.RegisterAdapter("fake", fake)
+
+		result, err := orch.Deploy(ctx)
+		require.NoError(t, err)
+		assert.False(t, result.OK())
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, 1, result.Skipped)
+		assert.Equal(t, []string{"deploy users"}, fake.calls)
+
+		// The skipped service still reports a finished event
+		assert.Len(t, events.of(migra.EventServiceStarted), 1)
+		assert.Len(t, events.of(migra.EventServiceFinished), 2)
+	})
+
+	t.Run("service selection and dry run", func(t *testing.T) {
+		workDir := t.TempDir()
+		orch, err := New(testConfig(t, nil, "users", "orders"), Options{
+			WorkDir:  workDir,
+			Services: []string{"orders"},
+			DryRun:   true,
+		})
+		require.NoError(t, err)
+
+		fake := newFakeAdapter(1)
+		orch.RegisterAdapter("fake", fake)
+
+		result, err := orch.Deploy(ctx)
+		require.NoError(t, err)
+		require.Len(t, result.Services, 1)
+		assert.Equal(t, "orders", result.Services[0].ServiceName)
+		assert.Empty(t, result.RunID)
+		assert.Empty(t, fake.calls)
+		assert.NoFileExists(t, filepath.Join(workDir, ".migra", "history.jsonl"))
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		orch, err := New(testConfig(t, nil, "users"), Options{WorkDir: t.TempDir(), Strategy: "paralel"})
+		require.NoError(t, err)
+		fake := newFakeAdapter(1)
+		orch.RegisterAdapter("fake", fake)
+
+		_, err = orch.Deploy(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "got 'paralel'")
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		orch, err := New(testConfig(t, nil, "users"), Options{WorkDir: t.TempDir(), Services: []string{"billing"}})
+		require.NoError(t, err)
+		orch.RegisterAdapter("fake", newFakeAdapter(1))
+
+		_, err = orch.Deploy(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "service 'billing' not found")
+	})
+
+	t.Run("unregistered type fails validation", func(t *testing.T) {
+		orch, err := New(testConfig(t, nil, "users"), Options{WorkDir: t.TempDir()})
+		require.NoError(t, err)
+
+		_, err = orch.Deploy(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported type 'fake'")
+	})
+
+	t.Run("run lock", func(t *testing.T) {
+		workDir := t.TempDir()
+		cfg := testConfig(t, nil, "users")
+		cfg.Lock = &LockConfig{Enabled: true}
+
+		orch, err := New(cfg, Options{WorkDir: workDir})
+		require.NoError(t, err)
+		assert.Equal(t, "file", cfg.Lock.Backend)
+		orch.RegisterAdapter("fake", newFakeAdapter(1))
+
+		result, err := orch.Deploy(ctx)
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+
+		held, err := lock.Acquire(ctx, lock.NewFileBackend(workDir), "deploy", time.Minute)
+		require.NoError(t, err)
+		defer held.Release(ctx)
+
+		_, err = orch.Deploy(ctx)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.Contains(t, err.Error(), "another run is in progress")
+	})
+}
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+
+	orch, err := New(testConfig(t, map[string][]string{"orders": {"users"}}, "users", "orders"), Options{WorkDir: t.TempDir()})
+	require.NoError(t, err)
+	fake := newFakeAdapter(3)
+	orch.RegisterAdapter("fake", fake)
+
+	_, err = orch.Rollback(ctx, RollbackOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rollback steps must be positive")
+
+	_, err = orch.Deploy(ctx)
+	require.NoError(t, err)
+
+	result, err := orch.Rollback(ctx, RollbackOptions{Steps: 2})
+	require.NoError(t, err)
+	assert.True(t, result.OK())
+	assert.Equal(t, migra.OperationRollback, result.Operation)
+
+	// Dependents are rolled back before their dependencies
+	assert.Equal(t, []string{"rollback orders 2", "rollback users 2"}, fake.calls[2:])
+
+	// Rolling back to a target needs an adapter that supports it
+	result, err = orch.Rollback(ctx, RollbackOptions{Target: "0001"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 1, result.Skipped)
+	require.Len(t, result.Services, 2)
+	assert.Contains(t, result.Services[1].Error, "does not support rollback to a target migration")
+}
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("services", func(t *testing.T) {
+		events := &eventRecorder{}
+		orch, err := New(testConfig(t, nil, "users", "orders"), Options{WorkDir: t.TempDir(), OnEvent: events.handle})
+		require.NoError(t, err)
+		fake := newFakeAdapter(2)
+		fake.fail["orders"] = true
+		fake.applied["users"] = 1
+		orch.RegisterAdapter("fake", fake)
+
+		statuses, err := orch.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+
+		assert.Equal(t, "users", statuses[0].Service)
+		assert.Equal(t, []string{"0001"}, statuses[0].Applied)
+		assert.Equal(t, []string{"0002"}, statuses[0].Pending)
+		assert.Empty(t, statuses[0].Error)
+
+		assert.Equal(t, "orders", statuses[1].Service)
+		assert.Equal(t, "boom", statuses[1].Error)
+
+		finished := events.of(migra.EventServiceFinished)
+		require.Len(t, finished, 2)
+		assert.Equal(t, migra.OperationStatus, finished[0].Operation)
+	})
+
+	t.Run("tenants", func(t *testing.T) {
+		cfg := testConfig(t, nil, "users")
+		cfg.Tenancy = &TenancyConfig{Enabled: true, Source: &TenantSourceConfig{Type: "env"}}
+		orch, err := New(cfg, Options{
+			WorkDir:      t.TempDir(),
+			TenantSource: staticSource{{ID: "acme"}, {ID: "globex"}},
+		})
+		require.NoError(t, err)
+		fake := newFakeAdapter(1)
+		fake.applied["users@globex"] = 1
+		orch.RegisterAdapter("fake", fake)
+
+		statuses, err := orch.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.Equal(t, "acme", statuses[0].Tenant)
+		assert.Equal(t, []string{"0001"}, statuses[0].Pending)
+		assert.Equal(t, "globex", statuses[1].Tenant)
+		assert.Empty(t, statuses[1].Pending)
+	})
+}
+
+func TestDeployTenants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("requires tenancy", func(t *testing.T) {
+		orch, err := New(testConfig(t, nil, "users"), Options{WorkDir: t.TempDir()})
+		require.NoError(t, err)
+		orch.RegisterAdapter("fake", newFakeAdapter(1))
+
+		_, err = orch.DeployTenants(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tenancy is not enabled")
+	})
+
+	t.Run("deploys every tenant", func(t *testing.T) {
+		cfg := testConfig(t, nil, "users", "orders")
+		cfg.Tenancy = &TenancyConfig{Enabled: true, Source: &TenantSourceConfig{Type: "env"}}
+
+		events := &eventRecorder{}
+		orch, err := New(cfg, Options{
+			WorkDir:      t.TempDir(),
+			OnEvent:      events.handle,
+			TenantSource: staticSource{{ID: "acme"}, {ID: "globex"}},
+		})
+		require.NoError(t, err)
+		fake := newFakeAdapter(1)
+		orch.RegisterAdapter("fake", fake)
+
+		result, err := orch.DeployTenants(ctx)
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+		assert.Equal(t, 2, result.Succeeded)
+		assert.NotEmpty(t, result.RunID)
+
+		calls := append([]string{}, fake.calls...)
+		sort.Strings(calls)
+		assert.Equal(t, []string{"deploy orders@acme", "deploy orders@globex", "deploy users@acme", "deploy users@globex"}, calls)
+
+		tenantEvents := events.of(migra.EventTenantFinished)
+		require.Len(t, tenantEvents, 2)
+		for _, e := range tenantEvents {
+			assert.True(t, e.Success)
+		}
+		serviceEvents := events.of(migra.EventServiceFinished)
+		require.Len(t, serviceEvents, 4)
+		for _, e := range serviceEvents {
+			assert.NotEmpty(t, e.Tenant)
+		}
+	})
+
+	t.Run("refuses dry runs", func(t *testing.T) {
+		cfg := testConfig(t, nil, "users")
+		cfg.Tenancy = &TenancyConfig{Enabled: true, Source: &TenantSourceConfig{Type: "env"}}
+		workDir := t.TempDir()
+
+		orch, err := New(cfg, Options{
+			WorkDir:      workDir,
+			DryRun:       true,
+			TenantSource: staticSource{{ID: "acme"}, {ID: "globex"}},
+		})
+		require.NoError(t, err)
+		fake := newFakeAdapter(1)
+		orch.RegisterAdapter("fake", fake)
+
+		_, err = orch.DeployTenants(ctx)
+		assert.ErrorIs(t, err, ErrTenantDryRun)
+		_, err = orch.ResumeTenants(ctx, "20250101-000000-abcdef")
+		assert.ErrorIs(t, err, ErrTenantDryRun)
+		assert.Empty(t, fake.calls)
+		assert.NoFileExists(t, filepath.Join(workDir, ".migra", "history.jsonl"))
+
+		waves, err := orch.Waves(ctx)
+		require.NoError(t, err)
+		require.Len(t, waves, 1)
+		assert.Equal(t, []string{"acme", "globex"}, waves[0].TenantIDs())
+
+		plan, err := orch.RolloutPlan(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, plan.Tenants)
+		assert.Len(t, plan.Waves, 1)
+		assert.Empty(t, plan.Skipped)
+	})
+
+	t.Run("selects tenants", func(t *testing.T) {
+		cfg := testConfig(t, nil, "users")
+		cfg.Tenancy = &TenancyConfig{
+			Enabled:   true,
+			Source:    &TenantSourceConfig{Type: "env"},
+			Selectors: map[string]string{"production": "region=eu"},
+		}
+		source := staticSource{
+			{ID: "acme", Labels: map[string]string{"region": "eu"}},
+			{ID: "globex", Labels: map[string]string{"region": "us"}},
+			{ID: "initech", Labels: map[string]string{"region": "eu"}},
+		}
+
+		orch, err := New(cfg, Options{
+			WorkDir:      t.TempDir(),
+			TenantSource: source,
+			Tenants:      TenantFilter{Exclude: []string{"initech"}},
+			Environment:  "production",
+		})
+		require.NoError(t, err)
+		fake := newFakeAdapter(1)
+		orch.RegisterAdapter("fake", fake)
+
+		result, err := orch.DeployTenants(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Succeeded)
+		assert.Equal(t, []string{"deploy users@acme"}, fake.calls)
+	})
+}
+
+func TestTenantRollbackAndStatus(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig(t, nil, "users", "orders")
+	cfg.Tenancy = &TenancyConfig{Enabled: true, Source: &TenantSourceConfig{Type: "env"}}
+	source := staticSource{{ID: "acme"}, {ID: "globex"}, {ID: "initech"}}
+	workDir := t.TempDir()
+	fake := newFakeAdapter(2)
+
+	newOrchestrator := func(opts Options) *Orchestrator {
+		opts.WorkDir = workDir
+		opts.TenantSource = source
+		orch, err := New(cfg, opts)
+		require.NoError(t, err)
+		orch.RegisterAdapter("fake", fake)
+		return orch
+	}
+
+	_, err := newOrchestrator(Options{}).DeployTenants(ctx)
+	require.NoError(t, err)
+
+	t.Run("rolls back selected tenants in reverse service order", func(t *testing.T) {
+		fake.calls = nil
+		result, err := newOrchestrator(Options{Tenants: TenantFilter{IDs: []string{"globex"}}}).RollbackTenants(ctx, 1)
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+		assert.Equal(t, migra.OperationRollback, result.Operation)
+		assert.Equal(t, []string{"rollback orders@globex 1", "rollback users@globex 1"}, fake.calls)
+	})
+
+	t.Run("rejects non-positive steps", func(t *testing.T) {
+		_, err := newOrchestrator(Options{}).RollbackTenants(ctx, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("refuses dry runs", func(t *testing.T) {
+		fake.calls = nil
+		_, err := newOrchestrator(Options{DryRun: true}).RollbackTenants(ctx, 1)
+		assert.ErrorIs(t, err, ErrTenantDryRun)
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("status reports pending and drift", func(t *testing.T) {
+		statuses, err := newOrchestrator(Options{}).TenantStatus(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 3)
+
+		for _, st := range statuses {
+			if st.TenantID == "globex" {
+				assert.True(t, st.Drifted)
+				assert.Equal(t, 2, st.Pending())
+			} else {
+				assert.False(t, st.Drifted)
+				assert.Equal(t, 0, st.Pending())
+			}
+		}
+	})
+}
+
+func TestResume(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("resumes a deploy", func(t *testing.T) {
+		cfg := testConfig(t, nil, "users", "orders", "billing")
+		cfg.Execution.StopOnFailure = true
+
+		orch, err := New(cfg, Options{WorkDir: t.TempDir()})
+		require.NoError(t, err)
+		fake := newFakeAdapter(1)
+		fake.fail["orders"] = true
+		orch.RegisterAdapter("fake", fake)
+
+		// billing is not started once orders fails
+		failed, err := orch.Deploy(ctx)
+		require.NoError(t, err)
+		assert.False(t, failed.OK())
+		assert.Len(t, failed.Services, 2)
+
+		fake.fail["orders"] = false
+		fake.calls = nil
+		result, err := orch.ResumeDeploy(ctx, failed.RunID)
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+		assert.Equal(t, 1, result.Resumed)
+		assert.Equal(t, []string{"deploy orders", "deploy billing"}, fake.calls)
+		assert.True(t, result.Services[0].Resumed)
+
+		// The resumed run can itself be resumed, with nothing left to do
+		fake.calls = nil
+		result, err = orch.ResumeDeploy(ctx, result.RunID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Resumed)
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("resumes a multi-tenant deploy", func(t *testing.T) {
+		cfg := testConfig(t, nil, "users", "orders")
+		cfg.Tenancy = &TenancyConfig{Enabled: true, Source: &TenantSourceConfig{Type: "env"}}
+
+		orch, err := New(cfg, Options{
+			WorkDir:      t.TempDir(),
+			TenantSource: staticSource{{ID: "acme"}, {ID: "globex"}},
+		})
+		require.NoError(t, err)
+		fake := newFakeAdapter(1)
+		fake.fail["orders"] = true
+		orch.RegisterAdapter("fake", fake)
+
+		failed, err := orch.DeployTenants(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, failed.Failed)
+
+		_, err = orch.ResumeDeploy(ctx, failed.RunID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "multi-tenant")
+
+		fake.fail["orders"] = false
+		fake.calls = nil
+		result, err := orch.ResumeTenants(ctx, failed.RunID)
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+		assert.Equal(t, 0, result.Resumed)
+
+		calls := append([]string{}, fake.calls...)
+		sort.Strings(calls)
+		assert.Equal(t, []string{"deploy orders@acme", "deploy orders@globex"}, calls)
+
+		fake.calls = nil
+		result, err = orch.ResumeTenants(ctx, result.RunID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Resumed)
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("rejects unknown runs", func(t *testing.T) {
+		orch, err := New(testConfig(t, nil, "users"), Options{WorkDir: t.TempDir()})
+		require.NoError(t, err)
+		orch.RegisterAdapter("fake", newFakeAdapter(1))
+
+		_, err = orch.ResumeDeploy(ctx, "20250101-missing")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no recorded state")
+	})
+}